@@ -11,7 +11,9 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+	"github.com/nichorainer/backend-go/internal/adapters/storage"
 	"github.com/nichorainer/backend-go/internal/handlers"
+	"github.com/nichorainer/backend-go/internal/middleware"
 )
 
 // Mount Server
@@ -19,8 +21,12 @@ func (app *application) mount() http.Handler {
 	r := chi.NewRouter()
 
 	server := handlers.Server{
-		Repo: repo.New(app.db),
-		DB:   app.db,
+		Repo:           repo.New(app.db),
+		DB:             app.db,
+		Blobs:          app.blobs,
+		Costing:        app.config.costing,
+		OrderNumbers:   app.config.orderNumbers,
+		IdempotencyTTL: app.config.idempotencyTTL,
 	}
 
 	// --- CORS middleware ---
@@ -32,7 +38,8 @@ func (app *application) mount() http.Handler {
 			"https://sm-web-inventory.netlify.app",
 		},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", handlers.IdempotencyKeyHeader},
+		ExposedHeaders:   []string{"Idempotent-Replayed", "X-Total-Count", "X-Total-Quantity", "X-Total-Idr"},
 		AllowCredentials: true,
 	}))
 
@@ -45,12 +52,18 @@ func (app *application) mount() http.Handler {
 	r.Use(chimiddleware.Recoverer)       	// recover from crashes
 	r.Use(chimiddleware.RedirectSlashes) 	// redirect slashes to no slash URL
 	r.Use(chimiddleware.Timeout(60 * time.Second))
+	r.Use(middleware.JWTVerifier)        	// caller from the Authorization bearer token, if any
+	// server.Idempotent is mounted per route: only the order, product and stock create routes
+	// replay retries that carry an Idempotency-Key
 
 	// Health Check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("all good"))
 	})
 
+	// Uploaded product images (LocalStore)
+	r.Handle("/media/*", http.StripPrefix("/media", app.blobs.Handler()))
+
 	// Register and Login
 	r.Post("/register", server.CreateUser)
 	r.Post("/login", server.LoginUser)
@@ -69,19 +82,131 @@ func (app *application) mount() http.Handler {
 	// Products Routes
 	r.Route("/products", func(r chi.Router) {
 		r.Get("/", server.ListProducts)
+		r.Post("/labels", server.PrintProductLabels)
+		// Scanner
+		r.Get("/lookup", server.LookupProduct)
+		r.With(server.Idempotent).Post("/scan", server.ScanAdjustStock)
+		// Inventory valuation (FIFO / weighted average)
+		r.Get("/valuation", server.GetInventoryValuation)
 		r.Get("/{id}", server.GetProductByID)
-		r.Post("/", server.CreateProduct)
+		r.With(server.Idempotent).Post("/", server.CreateProduct)
 		r.Patch("/{id}/stock", server.UpdateProductStock)
+		r.Get("/{id}/movements", server.ListStockMovements)
+		r.Post("/{id}/barcodes", server.CreateProductBarcode)
+		r.Delete("/{id}/barcodes/{barcodeId}", server.DeleteProductBarcode)
+		// Variant SKUs
+		r.Get("/{id}/variants", server.ListProductVariants)
+		r.Post("/{id}/variants", server.CreateProductVariant)
+		r.Patch("/{id}/variants/{variantId}/stock", server.UpdateProductVariantStock)
+		// Images
+		r.Post("/{id}/images", server.UploadProductImages)
+		r.Delete("/{id}/images/{imageId}", server.DeleteProductImage)
+		r.Get("/{id}/prices", server.ListProductPrices)
+		r.Post("/{id}/prices", server.ScheduleProductPrice)
+		r.Delete("/{id}/prices/{priceId}", server.CancelScheduledPrice)
+		r.Get("/{id}/costs", server.ListProductCosts)
+		r.Put("/{id}/cost", server.UpdateProductCost)
+		r.With(server.Idempotent).Post("/{id}/receipts", server.ReceiveProductStock)
+		r.Get("/{id}/cost-layers", server.ListCostLayers)
+		r.Get("/{id}/lots", server.ListProductLots)
+		r.Put("/{id}/serial-tracking", server.UpdateSerialTracking)
+		r.Get("/{id}/serials", server.ListProductSerials)
+		r.Post("/{id}/serials/{serial}/return", server.ReturnProductSerial)
+		// Units of measure / pack sizes
+		r.Get("/{id}/units", server.ListProductUnits)
+		r.Post("/{id}/units", server.CreateProductUnit)
+		r.Delete("/{id}/units/{unitId}", server.DeleteProductUnit)
+		r.Put("/{id}/base-unit", server.UpdateProductBaseUnit)
+		// Bundles / kits
+		r.Get("/{id}/components", server.GetBundleComponents)
+		r.Put("/{id}/components", server.SetBundleComponents)
+		r.Post("/{id}/assemble", server.AssembleKits)
+		r.Post("/{id}/disassemble", server.DisassembleKits)
+		// Barcode / QR labels
+		r.Get("/{id}/barcode", server.GetProductBarcode)
+		r.Get("/{id}/qrcode", server.GetProductQRCode)
 	})
 
 	// Orders Routes
 	r.Route("/orders", func(r chi.Router) {
-		r.Get("/", server.ListOrdersWithProduct)
-		r.Post("/", server.CreateOrder)
+		r.Get("/", server.ListOrders)
+		r.With(server.Idempotent).Post("/", server.CreateOrder)
 		r.Get("/order-number", server.GetNextOrderNumber)
+		r.Get("/{id}", server.GetOrder)
+		r.Patch("/{id}", server.UpdateOrder)
 		r.Put("/{id}/status", server.UpdateOrderStatus)
+		r.Post("/{id}/cancel", server.CancelOrder)
+		r.Get("/{id}/returns", server.ListOrderReturnsForOrder)
+		r.Post("/{id}/returns", server.CreateOrderReturn)
     	r.Delete("/{id}", server.DeleteOrder)
+		r.With(middleware.JWTMiddleware).Get("/deleted", server.ListDeletedOrders)
+		r.With(middleware.JWTMiddleware).Post("/{id}/restore", server.RestoreOrder)
 		r.Get("/top-products", server.GetTopProductsFromOrders)
+		r.Get("/margins", server.GetOrderMargins)
+		r.Get("/margins/products", server.GetMarginsByProduct)
+		r.Get("/margins/platforms", server.GetMarginsByPlatform)
+	})
+
+	// Returns (RMA)
+	r.Route("/returns", func(r chi.Router) {
+		r.Get("/", server.ListReturns)
+		r.Get("/report", server.GetReturnsReport)
+		r.Get("/{id}", server.GetReturn)
+		r.Post("/{id}/inspect", server.InspectReturn)
+		r.Post("/{id}/reject", server.RejectReturn)
+	})
+
+	// Warehouses / stock locations
+	r.Route("/warehouses", func(r chi.Router) {
+		r.Get("/", server.ListWarehouses)
+		r.Post("/", server.CreateWarehouse)
+		r.Get("/{id}/stock", server.ListWarehouseStock)
+	})
+
+	// Batch/lot expiry report
+	r.Get("/lots/expiring", server.ListExpiringLots)
+
+	// Purchase orders to suppliers and goods receiving
+	r.Route("/purchase-orders", func(r chi.Router) {
+		r.Get("/", server.ListPurchaseOrders)
+		r.Post("/", server.CreatePurchaseOrder)
+		r.Get("/{id}", server.GetPurchaseOrder)
+		r.Put("/{id}/items", server.UpdatePurchaseOrderItems)
+		r.Post("/{id}/send", server.SendPurchaseOrder)
+		r.Post("/{id}/cancel", server.CancelPurchaseOrder)
+		r.With(server.Idempotent).Post("/{id}/receipts", server.ReceivePurchaseOrder)
+		r.Get("/{id}/pdf", server.GetPurchaseOrderPDF)
+	})
+
+	// Supplier performance (lead time, fill rate, on-time rate, price trend)
+	r.Route("/suppliers", func(r chi.Router) {
+		r.Get("/", server.ListSuppliers)
+		r.Get("/ranking", server.GetSupplierRanking)
+		r.Get("/{name}", server.GetSupplier)
+	})
+
+	// Stock-take (cycle count) sessions
+	r.Route("/stock-takes", func(r chi.Router) {
+		r.Get("/", server.ListStockTakes)
+		r.Post("/", server.CreateStockTake)
+		r.Get("/{id}", server.GetStockTake)
+		r.Get("/{id}/variance", server.GetStockTakeVariance)
+		r.Post("/{id}/counts", server.RecordStockTakeCounts)
+		r.Post("/{id}/scan", server.ScanStockTakeCount)
+		r.With(middleware.JWTMiddleware).Post("/{id}/approve", server.ApproveStockTake)
+		r.Post("/{id}/cancel", server.CancelStockTake)
+	})
+
+	// Serial number lookup (full history across orders and returns)
+	r.Get("/serials/{serial}", server.LookupSerial)
+
+	// Stock transfers between locations
+	r.Route("/transfers", func(r chi.Router) {
+		r.Get("/", server.ListTransfers)
+		r.With(server.Idempotent).Post("/", server.CreateTransfer)
+		r.Get("/{id}", server.GetTransfer)
+		r.Post("/{id}/receive", server.ReceiveTransfer)
+		r.Post("/{id}/cancel", server.CancelTransfer)
 	})
 
 	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
@@ -116,13 +241,27 @@ type application struct {
 	config configStruct
 	// db driver using pool
 	db *pgxpool.Pool
+	// blob storage for uploaded files
+	blobs *storage.LocalStore
 }
 
 type configStruct struct {
-	addr string
-	db   dbConfig
+	addr  string
+	db    dbConfig
+	media mediaConfig
+	// fifo atau average (COSTING_METHOD)
+	costing handlers.CostingMethod
+	// format nomor order (ORDER_NUMBER_FORMAT, ORDER_NUMBER_RESET)
+	orderNumbers handlers.OrderNumberFormat
+	// berapa lama respons POST dengan Idempotency-Key diputar ulang (IDEMPOTENCY_TTL)
+	idempotencyTTL time.Duration
 }
 
 type dbConfig struct {
 	dsn string
 }
+
+type mediaConfig struct {
+	dir     string
+	baseURL string
+}
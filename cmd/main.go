@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/nichorainer/backend-go/internal/env"
 	"github.com/nichorainer/backend-go/internal/config"
+	"github.com/nichorainer/backend-go/internal/adapters/storage"
+	"github.com/nichorainer/backend-go/internal/handlers"
+	"github.com/nichorainer/backend-go/internal/jobs"
+	"github.com/nichorainer/backend-go/internal/middleware"
 )
 
 func main() {
@@ -15,12 +21,42 @@ func main() {
 			dsn: env.GetString("GOOSE_DBSTRING",
 				"host=localhost user=postgres password=admin dbname=InventoryDB sslmode=disable"),
 		},
+		media: mediaConfig{
+			dir:     env.GetString("MEDIA_DIR", "uploads"),
+			baseURL: env.GetString("MEDIA_BASE_URL", "http://localhost:8080/media"),
+		},
 	}
 
 	// logger
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
+	costing, err := handlers.ParseCostingMethod(env.GetString("COSTING_METHOD", "fifo"))
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	cfg.costing = costing
+
+	orderNumbers, err := handlers.ParseOrderNumberFormat(
+		env.GetString("ORDER_NUMBER_FORMAT", handlers.DefaultOrderNumberFormat.Pattern),
+		env.GetString("ORDER_NUMBER_RESET", "never"),
+	)
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	cfg.orderNumbers = orderNumbers
+	cfg.idempotencyTTL = env.GetDuration("IDEMPOTENCY_TTL", handlers.DefaultIdempotencyTTL)
+
+	// token login; user untuk aksi admin dan audit diambil dari token yang terverifikasi
+	jwtSecret := env.GetString("JWT_SECRET", "")
+	if jwtSecret == "" {
+		slog.Error("Invalid configuration", "error", "JWT_SECRET is required")
+		os.Exit(1)
+	}
+	middleware.InitJWT(jwtSecret)
+
 	// init database pool via config.InitDB()
 	config.InitDB()
 	defer config.GetDB().Close()
@@ -28,10 +64,38 @@ func main() {
 	// log init db
 	logger.Info("Database pool initialized", "dsn", cfg.db.dsn)
 
-	// application pakai pool dari config.GetDB()
+	// local filesystem storage for product images (served under /media)
+	blobs, err := storage.NewLocalStore(cfg.media.dir, cfg.media.baseURL)
+	if err != nil {
+		slog.Error("Failed to init media storage", "error", err)
+		os.Exit(1)
+	}
+
+	// background jobs berhenti saat server berhenti
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// terapkan perubahan harga terjadwal yang sudah jatuh tempo
+	go jobs.Every(ctx, env.GetDuration("PRICE_SCHEDULER_INTERVAL", time.Minute), "apply-due-prices", func(ctx context.Context) error {
+		return jobs.ApplyDuePrices(ctx, config.GetDB())
+	})
+
+	// hapus respons idempotency yang sudah kedaluwarsa
+	go jobs.Every(ctx, env.GetDuration("IDEMPOTENCY_PURGE_INTERVAL", time.Hour), "purge-idempotency-keys", func(ctx context.Context) error {
+		return jobs.PurgeIdempotencyKeys(ctx, config.GetDB())
+	})
+
+	// order yang di-soft delete dihapus permanen setelah ORDER_RETENTION (default 90 hari)
+	orderRetention := env.GetDuration("ORDER_RETENTION", 90*24*time.Hour)
+	go jobs.Every(ctx, env.GetDuration("ORDER_PURGE_INTERVAL", 24*time.Hour), "purge-deleted-orders", func(ctx context.Context) error {
+		return jobs.PurgeDeletedOrders(ctx, config.GetDB(), orderRetention)
+	})
+
+		// application pakai pool dari config.GetDB()
 	api := application{
 		config: cfg,
 		db:     config.GetDB(),
+		blobs:  blobs,
 	}
 
 	// run server
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00006_create_product_variants_table.sql
+CREATE TABLE IF NOT EXISTS product_variants (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE, -- FK ke produk induk
+  product_id TEXT UNIQUE NOT NULL,                                     -- kode SKU varian
+  variant_name TEXT NOT NULL,
+  price_idr BIGINT,                                                    -- NULL = ikut harga produk induk
+  stock INTEGER NOT NULL DEFAULT 0,
+  attributes JSONB NOT NULL DEFAULT '{}'::jsonb,                       -- mis. {"size": "L", "color": "red"}
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_id_from_product ON product_variants(id_from_product);
+
+ALTER TABLE orders
+ADD COLUMN id_from_variant INT REFERENCES product_variants(id); -- FK ke varian yang dipesan (opsional)
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+DROP COLUMN IF EXISTS id_from_variant;
+
+DROP TABLE IF EXISTS product_variants;
+-- +goose StatementEnd
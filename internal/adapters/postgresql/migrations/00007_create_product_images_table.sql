@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00007_create_product_images_table.sql
+CREATE TABLE IF NOT EXISTS product_images (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE, -- FK ke products.id
+  storage_key TEXT NOT NULL,                                           -- key di BlobStore (gambar asli)
+  thumbnail_key TEXT NOT NULL,                                         -- key di BlobStore (thumbnail)
+  content_type TEXT NOT NULL,
+  size_bytes BIGINT NOT NULL,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  sort_order INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_id_from_product ON product_images(id_from_product);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_images;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00008_create_product_barcodes_and_stock_movements.sql
+CREATE TABLE IF NOT EXISTS product_barcodes (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE, -- FK ke products.id
+  barcode TEXT UNIQUE NOT NULL,                                        -- EAN/UPC/kode supplier yang terdaftar
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_id_from_product ON product_barcodes(id_from_product);
+
+-- ledger of every stock change, newest state in stock_after
+CREATE TABLE IF NOT EXISTS stock_movements (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE, -- FK ke products.id
+  quantity INTEGER NOT NULL,                                           -- delta: + masuk, - keluar
+  stock_after INTEGER NOT NULL,
+  reason TEXT NOT NULL,                                                -- mis. scan, damaged, found
+  reference TEXT,                                                      -- kode yang discan / nomor dokumen
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_created ON stock_movements(id_from_product, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS product_barcodes;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00009_create_product_prices_table.sql
+CREATE TABLE IF NOT EXISTS product_prices (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE, -- FK ke products.id
+  price_idr BIGINT NOT NULL,
+  effective_at TIMESTAMP WITH TIME ZONE NOT NULL,                       -- kapan harga mulai berlaku
+  applied_at TIMESTAMP WITH TIME ZONE,                                  -- NULL = masih terjadwal
+  note TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_prices_product_effective ON product_prices(id_from_product, effective_at);
+CREATE INDEX IF NOT EXISTS idx_product_prices_due ON product_prices(effective_at) WHERE applied_at IS NULL;
+
+-- start the history with the price every product has today
+INSERT INTO product_prices (id_from_product, price_idr, effective_at, applied_at, note)
+SELECT id, price_idr, COALESCE(created_at, now()), COALESCE(created_at, now()), 'initial price'
+FROM products;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_prices;
+-- +goose StatementEnd
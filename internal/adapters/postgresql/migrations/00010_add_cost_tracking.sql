@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00010_add_cost_tracking.sql
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS cost_idr BIGINT NOT NULL DEFAULT 0;  -- harga pokok (HPP) per unit
+
+CREATE TABLE IF NOT EXISTS product_costs (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE, -- FK ke products.id
+  cost_idr BIGINT NOT NULL,
+  note TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_costs_product ON product_costs(id_from_product, created_at);
+
+-- unit cost at the time the order was created; NULL for orders from before cost tracking
+ALTER TABLE orders
+ADD COLUMN IF NOT EXISTS unit_cost_idr BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+DROP COLUMN IF EXISTS unit_cost_idr;
+
+DROP TABLE IF EXISTS product_costs;
+
+ALTER TABLE products
+DROP COLUMN IF EXISTS cost_idr;
+-- +goose StatementEnd
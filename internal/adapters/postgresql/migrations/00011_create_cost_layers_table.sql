@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00011_create_cost_layers_table.sql
+-- one layer per stock receipt, consumed oldest first (FIFO)
+CREATE TABLE IF NOT EXISTS cost_layers (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE, -- FK ke products.id
+  quantity INT NOT NULL CHECK (quantity > 0),                            -- jumlah diterima
+  quantity_remaining INT NOT NULL CHECK (quantity_remaining >= 0),       -- sisa yang belum terjual
+  unit_cost_idr BIGINT NOT NULL,
+  received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  reference TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_layers_open ON cost_layers(id_from_product, received_at, id) WHERE quantity_remaining > 0;
+
+-- which layer each sale/adjustment took its units (and cost) from
+CREATE TABLE IF NOT EXISTS cost_layer_consumptions (
+  id SERIAL PRIMARY KEY,
+  id_from_layer INT NOT NULL REFERENCES cost_layers(id) ON DELETE CASCADE,
+  id_from_order INT REFERENCES orders(id) ON DELETE SET NULL,            -- NULL = stock adjustment
+  quantity INT NOT NULL CHECK (quantity > 0),
+  unit_cost_idr BIGINT NOT NULL,                                         -- biaya yang dibebankan (FIFO: biaya layer, average: rata-rata)
+  reference TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_layer_consumptions_layer ON cost_layer_consumptions(id_from_layer);
+CREATE INDEX IF NOT EXISTS idx_cost_layer_consumptions_order ON cost_layer_consumptions(id_from_order);
+
+-- COGS of the order from consumed layers; NULL for orders from before layers existed
+ALTER TABLE orders
+ADD COLUMN IF NOT EXISTS cogs_idr BIGINT;
+
+-- current stock becomes the opening layer at today's cost price
+INSERT INTO cost_layers (id_from_product, quantity, quantity_remaining, unit_cost_idr, reference)
+SELECT id, stock, stock, cost_idr, 'opening balance'
+FROM products
+WHERE stock > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+DROP COLUMN IF EXISTS cogs_idr;
+
+DROP TABLE IF EXISTS cost_layer_consumptions;
+DROP TABLE IF EXISTS cost_layers;
+-- +goose StatementEnd
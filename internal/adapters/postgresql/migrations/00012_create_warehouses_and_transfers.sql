@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00012_create_warehouses_and_transfers.sql
+CREATE TABLE IF NOT EXISTS warehouses (
+  id SERIAL PRIMARY KEY,
+  code TEXT UNIQUE NOT NULL,                 -- kode lokasi (MAIN, GDG2, dst.)
+  name TEXT NOT NULL,
+  address TEXT,
+  is_default BOOLEAN NOT NULL DEFAULT false, -- lokasi untuk perubahan stok tanpa lokasi
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+-- at most one default location
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_default ON warehouses(is_default) WHERE is_default;
+
+-- stock per product per location; products.stock = sum of these plus stock in transit
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+  id_from_warehouse INT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  PRIMARY KEY (id_from_warehouse, id_from_product)
+);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_product ON warehouse_stock(id_from_product);
+
+-- transfer documents: stock leaves the source when created (in_transit) and arrives on receive
+CREATE TABLE IF NOT EXISTS stock_transfers (
+  id SERIAL PRIMARY KEY,
+  id_from_source INT NOT NULL REFERENCES warehouses(id),
+  id_from_destination INT NOT NULL REFERENCES warehouses(id),
+  status TEXT NOT NULL DEFAULT 'in_transit', -- in_transit, received, cancelled
+  note TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+  completed_at TIMESTAMP WITH TIME ZONE,     -- waktu diterima / dibatalkan
+  CHECK (id_from_source <> id_from_destination)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_status ON stock_transfers(status);
+
+CREATE TABLE IF NOT EXISTS stock_transfer_items (
+  id SERIAL PRIMARY KEY,
+  id_from_transfer INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+  id_from_product INT NOT NULL REFERENCES products(id),
+  quantity INT NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfer_items_transfer ON stock_transfer_items(id_from_transfer);
+
+-- location the order is picked from; NULL for orders from before locations existed
+ALTER TABLE orders
+ADD COLUMN IF NOT EXISTS id_from_warehouse INT REFERENCES warehouses(id);
+
+-- existing stock starts in the default location
+INSERT INTO warehouses (code, name, is_default)
+VALUES ('MAIN', 'Main warehouse', true)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO warehouse_stock (id_from_warehouse, id_from_product, quantity)
+SELECT w.id, p.id, p.stock
+FROM products p
+CROSS JOIN warehouses w
+WHERE w.code = 'MAIN' AND p.stock > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+DROP COLUMN IF EXISTS id_from_warehouse;
+
+DROP TABLE IF EXISTS stock_transfer_items;
+DROP TABLE IF EXISTS stock_transfers;
+DROP TABLE IF EXISTS warehouse_stock;
+DROP TABLE IF EXISTS warehouses;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00013_create_stock_lots_table.sql
+-- batch/lot balances per product per location; products without lots are simply not lot-tracked
+CREATE TABLE IF NOT EXISTS stock_lots (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  id_from_warehouse INT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+  lot_number TEXT NOT NULL,                           -- nomor batch dari supplier
+  expiry_date DATE,                                   -- NULL = tidak kedaluwarsa
+  quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  UNIQUE (id_from_product, id_from_warehouse, lot_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_lots_fefo ON stock_lots(id_from_product, id_from_warehouse, expiry_date) WHERE quantity > 0;
+CREATE INDEX IF NOT EXISTS idx_stock_lots_expiry ON stock_lots(expiry_date) WHERE quantity > 0;
+
+-- which lots an order was fulfilled from
+CREATE TABLE IF NOT EXISTS lot_allocations (
+  id SERIAL PRIMARY KEY,
+  id_from_lot INT NOT NULL REFERENCES stock_lots(id) ON DELETE CASCADE,
+  id_from_order INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lot_allocations_order ON lot_allocations(id_from_order);
+
+-- transfers of lot-tracked stock name the lot that moves
+ALTER TABLE stock_transfer_items
+ADD COLUMN IF NOT EXISTS lot_number TEXT,
+ADD COLUMN IF NOT EXISTS expiry_date DATE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_transfer_items
+DROP COLUMN IF EXISTS lot_number,
+DROP COLUMN IF EXISTS expiry_date;
+
+DROP TABLE IF EXISTS lot_allocations;
+DROP TABLE IF EXISTS stock_lots;
+-- +goose StatementEnd
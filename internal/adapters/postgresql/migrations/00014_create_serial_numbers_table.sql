@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00014_create_serial_numbers_table.sql
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS track_serials BOOLEAN NOT NULL DEFAULT false; -- wajib nomor seri per unit
+
+-- one row per physical unit of a serial-tracked product
+CREATE TABLE IF NOT EXISTS serial_numbers (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  serial_number TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'in_stock',                              -- in_stock, sold
+  id_from_warehouse INT REFERENCES warehouses(id) ON DELETE SET NULL,   -- lokasi saat in_stock
+  id_from_order INT REFERENCES orders(id) ON DELETE SET NULL,           -- order terakhir saat sold
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  UNIQUE (id_from_product, serial_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_serial ON serial_numbers(serial_number);
+
+-- full history of a unit: received, shipped, returned
+CREATE TABLE IF NOT EXISTS serial_events (
+  id SERIAL PRIMARY KEY,
+  id_from_serial INT NOT NULL REFERENCES serial_numbers(id) ON DELETE CASCADE,
+  event TEXT NOT NULL,
+  id_from_order INT REFERENCES orders(id) ON DELETE SET NULL,
+  reference TEXT,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_serial_events_serial ON serial_events(id_from_serial);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS serial_events;
+DROP TABLE IF EXISTS serial_numbers;
+
+ALTER TABLE products
+DROP COLUMN IF EXISTS track_serials;
+-- +goose StatementEnd
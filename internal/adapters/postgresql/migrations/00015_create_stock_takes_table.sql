@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00015_create_stock_takes_table.sql
+-- stock-take (cycle count) session for one location
+CREATE TABLE IF NOT EXISTS stock_takes (
+  id SERIAL PRIMARY KEY,
+  id_from_warehouse INT NOT NULL REFERENCES warehouses(id),
+  status TEXT NOT NULL DEFAULT 'open',                            -- open, approved, cancelled
+  note TEXT,
+  created_by INT REFERENCES users(id) ON DELETE SET NULL,
+  approved_by INT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+  completed_at TIMESTAMP WITH TIME ZONE                           -- waktu disetujui / dibatalkan
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_takes_status ON stock_takes(status);
+
+-- expected quantity snapshotted when the session opens, counted quantity from the floor
+CREATE TABLE IF NOT EXISTS stock_take_items (
+  id SERIAL PRIMARY KEY,
+  id_from_stock_take INT NOT NULL REFERENCES stock_takes(id) ON DELETE CASCADE,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  expected_qty INT NOT NULL,
+  counted_qty INT CHECK (counted_qty >= 0),                       -- NULL = belum dihitung
+  counted_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE (id_from_stock_take, id_from_product)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_take_items;
+DROP TABLE IF EXISTS stock_takes;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00016_create_stock_reservations_table.sql
+-- stock held for an open order until it ships (fulfilled) or is cancelled (released)
+CREATE TABLE IF NOT EXISTS stock_reservations (
+  id SERIAL PRIMARY KEY,
+  id_from_order INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  id_from_warehouse INT NOT NULL REFERENCES warehouses(id),
+  quantity INT NOT NULL CHECK (quantity > 0),
+  status TEXT NOT NULL DEFAULT 'active',                          -- active, fulfilled, released
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_order ON stock_reservations(id_from_order);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_active ON stock_reservations(id_from_product, id_from_warehouse)
+  WHERE status = 'active';
+
+-- orders that are still open hold their stock from now on
+INSERT INTO stock_reservations (id_from_order, id_from_product, id_from_warehouse, quantity)
+SELECT o.id, o.id_from_product, COALESCE(o.id_from_warehouse, w.id), o.total_amount
+FROM orders o
+CROSS JOIN warehouses w
+WHERE w.is_default
+  AND o.id_from_product IS NOT NULL
+  AND o.total_amount > 0
+  AND lower(o.status) IN ('pending', 'processing');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_reservations;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00017_create_bundle_components_table.sql
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS is_bundle BOOLEAN NOT NULL DEFAULT false; -- paket dari produk lain
+
+-- what one unit of a bundle is made of; the bundle's own stock is pre-built kits
+CREATE TABLE IF NOT EXISTS bundle_components (
+  id_from_bundle INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  id_from_component INT NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  PRIMARY KEY (id_from_bundle, id_from_component),
+  CHECK (id_from_bundle <> id_from_component)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bundle_components_component ON bundle_components(id_from_component);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS bundle_components;
+
+ALTER TABLE products
+DROP COLUMN IF EXISTS is_bundle;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00018_create_product_units_table.sql
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS base_unit TEXT NOT NULL DEFAULT 'pcs'; -- satuan stok (stock selalu dalam satuan ini)
+
+-- pack sizes a product is bought or counted in, e.g. 1 box = 24 pcs
+CREATE TABLE IF NOT EXISTS product_units (
+  id SERIAL PRIMARY KEY,
+  id_from_product INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  unit_name TEXT NOT NULL,
+  factor INT NOT NULL CHECK (factor > 1),                         -- base units per 1 unit_name
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_units_name ON product_units(id_from_product, lower(unit_name));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_units;
+
+ALTER TABLE products
+DROP COLUMN IF EXISTS base_unit;
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00019_create_purchase_orders_table.sql
+CREATE SEQUENCE IF NOT EXISTS purchase_order_number_seq;
+
+-- purchase order to one supplier, delivered to one location
+CREATE TABLE IF NOT EXISTS purchase_orders (
+  id SERIAL PRIMARY KEY,
+  po_number TEXT NOT NULL UNIQUE DEFAULT ('PO-' || lpad(nextval('purchase_order_number_seq')::text, 6, '0')),
+  supplier_name TEXT NOT NULL,
+  id_from_warehouse INT NOT NULL REFERENCES warehouses(id),
+  status TEXT NOT NULL DEFAULT 'draft',          -- draft, sent, partially_received, received, cancelled
+  note TEXT,
+  expected_at DATE,                              -- perkiraan tanggal barang datang
+  sent_at TIMESTAMP WITH TIME ZONE,
+  closed_at TIMESTAMP WITH TIME ZONE,            -- waktu diterima penuh / dibatalkan
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier ON purchase_orders(supplier_name);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders(status);
+
+-- ordered lines in base units, at the agreed cost per base unit
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+  id SERIAL PRIMARY KEY,
+  id_from_purchase_order INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+  id_from_product INT NOT NULL REFERENCES products(id),
+  quantity INT NOT NULL CHECK (quantity > 0),
+  unit_cost_idr BIGINT NOT NULL CHECK (unit_cost_idr >= 0),
+  received_qty INT NOT NULL DEFAULT 0,
+  UNIQUE (id_from_purchase_order, id_from_product),
+  CHECK (received_qty >= 0 AND received_qty <= quantity)
+);
+
+-- one delivery from the supplier (partial or complete)
+CREATE TABLE IF NOT EXISTS purchase_receipts (
+  id SERIAL PRIMARY KEY,
+  id_from_purchase_order INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+  reference TEXT,                                -- e.g. supplier delivery note
+  received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS purchase_receipt_items (
+  id SERIAL PRIMARY KEY,
+  id_from_receipt INT NOT NULL REFERENCES purchase_receipts(id) ON DELETE CASCADE,
+  id_from_purchase_order_item INT NOT NULL REFERENCES purchase_order_items(id) ON DELETE CASCADE,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  lot_number TEXT
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS purchase_receipt_items;
+DROP TABLE IF EXISTS purchase_receipts;
+DROP TABLE IF EXISTS purchase_order_items;
+DROP TABLE IF EXISTS purchase_orders;
+DROP SEQUENCE IF EXISTS purchase_order_number_seq;
+-- +goose StatementEnd
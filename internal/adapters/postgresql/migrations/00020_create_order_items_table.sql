@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00020_create_order_items_table.sql
+-- satu order bisa berisi beberapa produk; harga dan HPP dicatat per baris
+CREATE TABLE IF NOT EXISTS order_items (
+  id SERIAL PRIMARY KEY,
+  id_from_order INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  id_from_product INT REFERENCES products(id), -- NULL hanya pada order lama tanpa produk
+  id_from_variant INT REFERENCES product_variants(id),
+  product_id TEXT NOT NULL,                    -- kode produk/varian saat dipesan
+  quantity INT NOT NULL CHECK (quantity >= 0), -- 0 hanya pada order lama tanpa jumlah
+  unit_price_idr BIGINT NOT NULL DEFAULT 0,
+  line_total_idr BIGINT NOT NULL DEFAULT 0,    -- unit_price_idr * quantity
+  unit_cost_idr BIGINT NOT NULL DEFAULT 0,     -- HPP per unit saat dipesan
+  cogs_idr BIGINT                              -- biaya dari cost layer yang terpakai
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items(id_from_order);
+CREATE INDEX IF NOT EXISTS idx_order_items_product ON order_items(id_from_product);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_idr BIGINT NOT NULL DEFAULT 0; -- jumlah line_total_idr
+
+-- every existing single-product order becomes a one-item order; orders without a product
+-- or quantity keep their raw code and price on a line of their own
+INSERT INTO order_items (id_from_order, id_from_product, id_from_variant, product_id, quantity,
+                         unit_price_idr, line_total_idr, unit_cost_idr, cogs_idr)
+SELECT o.id,
+       o.id_from_product,
+       o.id_from_variant,
+       COALESCE(o.product_id, p.product_id, ''),
+       GREATEST(COALESCE(o.total_amount, 0), 0),
+       COALESCE(o.price_idr, 0),
+       COALESCE(o.price_idr, 0)::bigint * GREATEST(COALESCE(o.total_amount, 0), 0),
+       COALESCE(o.unit_cost_idr, 0),
+       o.cogs_idr
+FROM orders o
+LEFT JOIN products p ON p.id = o.id_from_product;
+
+UPDATE orders o
+SET total_idr = COALESCE((SELECT SUM(i.line_total_idr) FROM order_items i WHERE i.id_from_order = o.id), 0);
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS id_from_product,
+DROP COLUMN IF EXISTS product_id,
+DROP COLUMN IF EXISTS price_idr,
+DROP COLUMN IF EXISTS id_from_variant,
+DROP COLUMN IF EXISTS unit_cost_idr;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+ADD COLUMN id_from_product INT REFERENCES products(id),
+ADD COLUMN product_id TEXT,
+ADD COLUMN price_idr INTEGER DEFAULT 0,
+ADD COLUMN id_from_variant INT REFERENCES product_variants(id),
+ADD COLUMN unit_cost_idr BIGINT;
+
+-- multi-item orders keep only their first line
+UPDATE orders o
+SET id_from_product = i.id_from_product,
+    product_id = i.product_id,
+    price_idr = i.unit_price_idr,
+    id_from_variant = i.id_from_variant,
+    unit_cost_idr = i.unit_cost_idr
+FROM (
+  SELECT DISTINCT ON (id_from_order) *
+  FROM order_items
+  ORDER BY id_from_order, id
+) i
+WHERE i.id_from_order = o.id;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS total_idr;
+DROP TABLE IF EXISTS order_items;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00021_create_order_status_history_table.sql
+-- one row per status change, so the timeline shows when an order shipped and who did it
+CREATE TABLE IF NOT EXISTS order_status_history (
+  id SERIAL PRIMARY KEY,
+  id_from_order INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  from_status TEXT,                                               -- NULL untuk status awal
+  to_status TEXT NOT NULL,
+  changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+  note TEXT,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order ON order_status_history(id_from_order, created_at);
+
+-- existing orders start their timeline with the status they have now
+INSERT INTO order_status_history (id_from_order, to_status, created_at)
+SELECT id, lower(status), COALESCE(created_at, now())
+FROM orders;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_status_history;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00022_create_order_number_counters_table.sql
+-- last order number handed out per period ('' = never resets, '2026' yearly, '2026-10' monthly)
+CREATE TABLE IF NOT EXISTS order_number_counters (
+  period TEXT PRIMARY KEY,
+  last_value BIGINT NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- continue after the highest #000123 style number already in use
+INSERT INTO order_number_counters (period, last_value)
+SELECT '', MAX(substring(order_number FROM '^#([0-9]+)$')::bigint)
+FROM orders
+HAVING MAX(substring(order_number FROM '^#([0-9]+)$')::bigint) IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_number_counters;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00023_create_idempotency_keys_table.sql
+-- hasil POST yang dikirim dengan header Idempotency-Key, diputar ulang saat client retry
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  idempotency_key TEXT NOT NULL,
+  method TEXT NOT NULL,
+  path TEXT NOT NULL,
+  request_hash TEXT NOT NULL,      -- sha256 of the request body
+  status_code INT,                 -- NULL while the first request is still running
+  content_type TEXT,
+  response_body BYTEA,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (idempotency_key, method, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
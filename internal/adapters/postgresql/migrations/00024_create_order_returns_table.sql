@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00024_create_order_returns_table.sql
+-- orders are cancelled with a reason instead of being deleted
+ALTER TABLE orders
+ADD COLUMN cancel_reason TEXT,
+ADD COLUMN cancelled_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE orders
+SET cancelled_at = COALESCE(updated_at, created_at)
+WHERE lower(status) = 'cancelled';
+
+-- retur barang dari order yang sudah dikirim (RMA)
+CREATE TABLE IF NOT EXISTS order_returns (
+  id SERIAL PRIMARY KEY,
+  id_from_order INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'requested'
+    CHECK (status IN ('requested', 'completed', 'rejected')),
+  reason TEXT NOT NULL,
+  id_from_warehouse INT NOT NULL REFERENCES warehouses(id), -- where restocked units go
+  refund_idr BIGINT NOT NULL DEFAULT 0,                     -- sum of the item refunds once inspected
+  note TEXT,
+  requested_by INT REFERENCES users(id) ON DELETE SET NULL,
+  inspected_by INT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  inspected_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_returns_order ON order_returns(id_from_order);
+CREATE INDEX IF NOT EXISTS idx_order_returns_created ON order_returns(created_at);
+
+CREATE TABLE IF NOT EXISTS order_return_items (
+  id SERIAL PRIMARY KEY,
+  id_from_return INT NOT NULL REFERENCES order_returns(id) ON DELETE CASCADE,
+  id_from_order_item INT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  serial_numbers TEXT[] NOT NULL DEFAULT '{}',              -- units of serial-tracked products
+  outcome TEXT CHECK (outcome IN ('restock', 'damaged', 'discard')), -- NULL until inspected
+  refund_idr BIGINT NOT NULL DEFAULT 0,
+  note TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_return_items_return ON order_return_items(id_from_return);
+CREATE INDEX IF NOT EXISTS idx_order_return_items_order_item ON order_return_items(id_from_order_item);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_return_items;
+DROP TABLE IF EXISTS order_returns;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS cancel_reason,
+DROP COLUMN IF EXISTS cancelled_at;
+-- +goose StatementEnd
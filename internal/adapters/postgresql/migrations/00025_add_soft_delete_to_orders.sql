@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00025_add_soft_delete_to_orders.sql
+-- order yang dihapus disembunyikan dulu; baru dihapus permanen setelah masa retensi
+ALTER TABLE orders
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN deleted_by INT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders(deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS deleted_at,
+DROP COLUMN IF EXISTS deleted_by;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00026_add_variant_to_stock_reservations.sql
+-- baris varian menahan stok varian juga, bukan hanya stok produk induk
+ALTER TABLE stock_reservations
+ADD COLUMN IF NOT EXISTS id_from_variant INT REFERENCES product_variants(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_variant_active ON stock_reservations(id_from_variant)
+  WHERE status = 'active' AND id_from_variant IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_stock_reservations_variant_active;
+
+ALTER TABLE stock_reservations
+DROP COLUMN IF EXISTS id_from_variant;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00027_add_lease_to_idempotency_keys.sql
+-- key yang masih diproses dikunci sebentar saja; kalau prosesnya mati, retry bisa mengambil alih
+ALTER TABLE idempotency_keys
+ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE; -- NULL once the response is stored
+
+UPDATE idempotency_keys
+SET locked_until = created_at + interval '2 minutes'
+WHERE status_code IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys
+DROP COLUMN IF EXISTS locked_until;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00028_add_serials_to_stock_transfer_items.sql
+-- transfers of serial-tracked stock name the units that move
+ALTER TABLE stock_transfer_items
+ADD COLUMN IF NOT EXISTS serial_numbers TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_transfer_items
+DROP COLUMN IF EXISTS serial_numbers;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 00029_add_caller_to_idempotency_keys.sql
+-- key berlaku per pemanggil, jadi dua client dengan key yang sama tidak saling dapat respons
+ALTER TABLE idempotency_keys
+ADD COLUMN IF NOT EXISTS caller TEXT NOT NULL DEFAULT ''; -- user:<users.id> or ip:<address>
+
+ALTER TABLE idempotency_keys
+DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+
+ALTER TABLE idempotency_keys
+ADD PRIMARY KEY (idempotency_key, method, path, caller);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys
+DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+
+ALTER TABLE idempotency_keys
+ADD PRIMARY KEY (idempotency_key, method, path);
+
+ALTER TABLE idempotency_keys
+DROP COLUMN IF EXISTS caller;
+-- +goose StatementEnd
@@ -8,19 +8,120 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type BundleComponent struct {
+	IDFromBundle    int32 `json:"id_from_bundle"`
+	IDFromComponent int32 `json:"id_from_component"`
+	Quantity        int32 `json:"quantity"`
+}
+
+type CostLayer struct {
+	ID                int32              `json:"id"`
+	IDFromProduct     int32              `json:"id_from_product"`
+	Quantity          int32              `json:"quantity"`
+	QuantityRemaining int32              `json:"quantity_remaining"`
+	UnitCostIdr       int64              `json:"unit_cost_idr"`
+	ReceivedAt        pgtype.Timestamptz `json:"received_at"`
+	Reference         pgtype.Text        `json:"reference"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+}
+
+type CostLayerConsumption struct {
+	ID          int32              `json:"id"`
+	IDFromLayer int32              `json:"id_from_layer"`
+	IDFromOrder pgtype.Int4        `json:"id_from_order"`
+	Quantity    int32              `json:"quantity"`
+	UnitCostIdr int64              `json:"unit_cost_idr"`
+	Reference   pgtype.Text        `json:"reference"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	IdempotencyKey string             `json:"idempotency_key"`
+	Method         string             `json:"method"`
+	Path           string             `json:"path"`
+	RequestHash    string             `json:"request_hash"`
+	StatusCode     pgtype.Int4        `json:"status_code"`
+	ContentType    pgtype.Text        `json:"content_type"`
+	ResponseBody   []byte             `json:"response_body"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	LockedUntil    pgtype.Timestamptz `json:"locked_until"`
+	Caller         string             `json:"caller"`
+}
+
+type LotAllocation struct {
+	ID          int32              `json:"id"`
+	IDFromLot   int32              `json:"id_from_lot"`
+	IDFromOrder int32              `json:"id_from_order"`
+	Quantity    int32              `json:"quantity"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type Order struct {
-	ID            int32              `json:"id"`
-	OrderNumber   string             `json:"order_number"`
-	CustomerName  string             `json:"customer_name"`
-	TotalAmount   pgtype.Int4        `json:"total_amount"`
-	Status        string             `json:"status"`
-	Platform      string             `json:"platform"`
-	Destination   string             `json:"destination"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	IDFromProduct pgtype.Int4        `json:"id_from_product"`
-	ProductID     pgtype.Text        `json:"product_id"`
-	PriceIdr      pgtype.Int4        `json:"price_idr"`
+	ID              int32              `json:"id"`
+	OrderNumber     string             `json:"order_number"`
+	CustomerName    string             `json:"customer_name"`
+	TotalAmount     pgtype.Int4        `json:"total_amount"`
+	Status          string             `json:"status"`
+	Platform        string             `json:"platform"`
+	Destination     string             `json:"destination"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	CogsIdr         pgtype.Int8        `json:"cogs_idr"`
+	IDFromWarehouse pgtype.Int4        `json:"id_from_warehouse"`
+	TotalIdr        int64              `json:"total_idr"`
+	CancelReason    pgtype.Text        `json:"cancel_reason"`
+	CancelledAt     pgtype.Timestamptz `json:"cancelled_at"`
+	DeletedAt       pgtype.Timestamptz `json:"deleted_at"`
+	DeletedBy       pgtype.Int4        `json:"deleted_by"`
+}
+
+type OrderItem struct {
+	ID            int32       `json:"id"`
+	IDFromOrder   int32       `json:"id_from_order"`
+	IDFromProduct pgtype.Int4 `json:"id_from_product"`
+	IDFromVariant pgtype.Int4 `json:"id_from_variant"`
+	ProductID     string      `json:"product_id"`
+	Quantity      int32       `json:"quantity"`
+	UnitPriceIdr  int64       `json:"unit_price_idr"`
+	LineTotalIdr  int64       `json:"line_total_idr"`
+	UnitCostIdr   int64       `json:"unit_cost_idr"`
+	CogsIdr       pgtype.Int8 `json:"cogs_idr"`
+}
+
+type OrderReturn struct {
+	ID              int32              `json:"id"`
+	IDFromOrder     int32              `json:"id_from_order"`
+	Status          string             `json:"status"`
+	Reason          string             `json:"reason"`
+	IDFromWarehouse int32              `json:"id_from_warehouse"`
+	RefundIdr       int64              `json:"refund_idr"`
+	Note            pgtype.Text        `json:"note"`
+	RequestedBy     pgtype.Int4        `json:"requested_by"`
+	InspectedBy     pgtype.Int4        `json:"inspected_by"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	InspectedAt     pgtype.Timestamptz `json:"inspected_at"`
+}
+
+type OrderReturnItem struct {
+	ID              int32       `json:"id"`
+	IDFromReturn    int32       `json:"id_from_return"`
+	IDFromOrderItem int32       `json:"id_from_order_item"`
+	Quantity        int32       `json:"quantity"`
+	SerialNumbers   []string    `json:"serial_numbers"`
+	Outcome         pgtype.Text `json:"outcome"`
+	RefundIdr       int64       `json:"refund_idr"`
+	Note            pgtype.Text `json:"note"`
+}
+
+type OrderStatusHistory struct {
+	ID          int32              `json:"id"`
+	IDFromOrder int32              `json:"id_from_order"`
+	FromStatus  pgtype.Text        `json:"from_status"`
+	ToStatus    string             `json:"to_status"`
+	ChangedBy   pgtype.Int4        `json:"changed_by"`
+	Note        pgtype.Text        `json:"note"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type Product struct {
@@ -33,6 +134,199 @@ type Product struct {
 	Stock        int32              `json:"stock"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	CostIdr      int64              `json:"cost_idr"`
+	TrackSerials bool               `json:"track_serials"`
+	IsBundle     bool               `json:"is_bundle"`
+	BaseUnit     string             `json:"base_unit"`
+}
+
+type ProductBarcode struct {
+	ID            int32              `json:"id"`
+	IDFromProduct int32              `json:"id_from_product"`
+	Barcode       string             `json:"barcode"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type ProductCost struct {
+	ID            int32              `json:"id"`
+	IDFromProduct int32              `json:"id_from_product"`
+	CostIdr       int64              `json:"cost_idr"`
+	Note          pgtype.Text        `json:"note"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type ProductImage struct {
+	ID            int32              `json:"id"`
+	IDFromProduct int32              `json:"id_from_product"`
+	StorageKey    string             `json:"storage_key"`
+	ThumbnailKey  string             `json:"thumbnail_key"`
+	ContentType   string             `json:"content_type"`
+	SizeBytes     int64              `json:"size_bytes"`
+	Width         int32              `json:"width"`
+	Height        int32              `json:"height"`
+	SortOrder     int32              `json:"sort_order"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type ProductPrice struct {
+	ID            int32              `json:"id"`
+	IDFromProduct int32              `json:"id_from_product"`
+	PriceIdr      int64              `json:"price_idr"`
+	EffectiveAt   pgtype.Timestamptz `json:"effective_at"`
+	AppliedAt     pgtype.Timestamptz `json:"applied_at"`
+	Note          pgtype.Text        `json:"note"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type ProductUnit struct {
+	ID            int32              `json:"id"`
+	IDFromProduct int32              `json:"id_from_product"`
+	UnitName      string             `json:"unit_name"`
+	Factor        int32              `json:"factor"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type ProductVariant struct {
+	ID            int32              `json:"id"`
+	IDFromProduct int32              `json:"id_from_product"`
+	ProductID     string             `json:"product_id"`
+	VariantName   string             `json:"variant_name"`
+	PriceIdr      pgtype.Int8        `json:"price_idr"`
+	Stock         int32              `json:"stock"`
+	Attributes    []byte             `json:"attributes"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type PurchaseOrder struct {
+	ID              int32              `json:"id"`
+	PoNumber        string             `json:"po_number"`
+	SupplierName    string             `json:"supplier_name"`
+	IDFromWarehouse int32              `json:"id_from_warehouse"`
+	Status          string             `json:"status"`
+	Note            pgtype.Text        `json:"note"`
+	ExpectedAt      pgtype.Date        `json:"expected_at"`
+	SentAt          pgtype.Timestamptz `json:"sent_at"`
+	ClosedAt        pgtype.Timestamptz `json:"closed_at"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+}
+
+type PurchaseOrderItem struct {
+	ID                  int32 `json:"id"`
+	IDFromPurchaseOrder int32 `json:"id_from_purchase_order"`
+	IDFromProduct       int32 `json:"id_from_product"`
+	Quantity            int32 `json:"quantity"`
+	UnitCostIdr         int64 `json:"unit_cost_idr"`
+	ReceivedQty         int32 `json:"received_qty"`
+}
+
+type PurchaseReceipt struct {
+	ID                  int32              `json:"id"`
+	IDFromPurchaseOrder int32              `json:"id_from_purchase_order"`
+	Reference           pgtype.Text        `json:"reference"`
+	ReceivedAt          pgtype.Timestamptz `json:"received_at"`
+}
+
+type PurchaseReceiptItem struct {
+	ID                      int32       `json:"id"`
+	IDFromReceipt           int32       `json:"id_from_receipt"`
+	IDFromPurchaseOrderItem int32       `json:"id_from_purchase_order_item"`
+	Quantity                int32       `json:"quantity"`
+	LotNumber               pgtype.Text `json:"lot_number"`
+}
+
+type SerialEvent struct {
+	ID           int32              `json:"id"`
+	IDFromSerial int32              `json:"id_from_serial"`
+	Event        string             `json:"event"`
+	IDFromOrder  pgtype.Int4        `json:"id_from_order"`
+	Reference    pgtype.Text        `json:"reference"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type SerialNumber struct {
+	ID              int32              `json:"id"`
+	IDFromProduct   int32              `json:"id_from_product"`
+	SerialNumber    string             `json:"serial_number"`
+	Status          string             `json:"status"`
+	IDFromWarehouse pgtype.Int4        `json:"id_from_warehouse"`
+	IDFromOrder     pgtype.Int4        `json:"id_from_order"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+}
+
+type StockLot struct {
+	ID              int32              `json:"id"`
+	IDFromProduct   int32              `json:"id_from_product"`
+	IDFromWarehouse int32              `json:"id_from_warehouse"`
+	LotNumber       string             `json:"lot_number"`
+	ExpiryDate      pgtype.Date        `json:"expiry_date"`
+	Quantity        int32              `json:"quantity"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+}
+
+type StockMovement struct {
+	ID            int32              `json:"id"`
+	IDFromProduct int32              `json:"id_from_product"`
+	Quantity      int32              `json:"quantity"`
+	StockAfter    int32              `json:"stock_after"`
+	Reason        string             `json:"reason"`
+	Reference     pgtype.Text        `json:"reference"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type StockReservation struct {
+	ID              int32              `json:"id"`
+	IDFromOrder     int32              `json:"id_from_order"`
+	IDFromProduct   int32              `json:"id_from_product"`
+	IDFromWarehouse int32              `json:"id_from_warehouse"`
+	Quantity        int32              `json:"quantity"`
+	Status          string             `json:"status"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	IDFromVariant   pgtype.Int4        `json:"id_from_variant"`
+}
+
+type StockTake struct {
+	ID              int32              `json:"id"`
+	IDFromWarehouse int32              `json:"id_from_warehouse"`
+	Status          string             `json:"status"`
+	Note            pgtype.Text        `json:"note"`
+	CreatedBy       pgtype.Int4        `json:"created_by"`
+	ApprovedBy      pgtype.Int4        `json:"approved_by"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	CompletedAt     pgtype.Timestamptz `json:"completed_at"`
+}
+
+type StockTakeItem struct {
+	ID              int32              `json:"id"`
+	IDFromStockTake int32              `json:"id_from_stock_take"`
+	IDFromProduct   int32              `json:"id_from_product"`
+	ExpectedQty     int32              `json:"expected_qty"`
+	CountedQty      pgtype.Int4        `json:"counted_qty"`
+	CountedAt       pgtype.Timestamptz `json:"counted_at"`
+}
+
+type StockTransfer struct {
+	ID                int32              `json:"id"`
+	IDFromSource      int32              `json:"id_from_source"`
+	IDFromDestination int32              `json:"id_from_destination"`
+	Status            string             `json:"status"`
+	Note              pgtype.Text        `json:"note"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	CompletedAt       pgtype.Timestamptz `json:"completed_at"`
+}
+
+type StockTransferItem struct {
+	ID             int32       `json:"id"`
+	IDFromTransfer int32       `json:"id_from_transfer"`
+	IDFromProduct  int32       `json:"id_from_product"`
+	Quantity       int32       `json:"quantity"`
+	LotNumber      pgtype.Text `json:"lot_number"`
+	ExpiryDate     pgtype.Date `json:"expiry_date"`
+	SerialNumbers  []string    `json:"serial_numbers"`
 }
 
 type User struct {
@@ -47,3 +341,19 @@ type User struct {
 	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
 	Permissions  []byte           `json:"permissions"`
 }
+
+type Warehouse struct {
+	ID        int32              `json:"id"`
+	Code      string             `json:"code"`
+	Name      string             `json:"name"`
+	Address   pgtype.Text        `json:"address"`
+	IsDefault bool               `json:"is_default"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type WarehouseStock struct {
+	IDFromWarehouse int32              `json:"id_from_warehouse"`
+	IDFromProduct   int32              `json:"id_from_product"`
+	Quantity        int32              `json:"quantity"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+}
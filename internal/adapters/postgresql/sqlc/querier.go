@@ -6,36 +6,238 @@ package repo
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	// Adds quantity (may be negative) to the product's balance at the location; the CHECK on
+	// warehouse_stock.quantity rejects changes that would go below zero.
+	AdjustWarehouseStock(ctx context.Context, arg AdjustWarehouseStockParams) (WarehouseStock, error)
+	// Idempotency Keys
+	// Claims a key for a new request. Affects no row when the key is already taken and not expired,
+	// unless the request that claimed it never finished and its lock ran out.
+	ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (int64, error)
+	// Moves the order's active reservations to fulfilled (shipped) or released (cancelled).
+	CloseOrderReservations(ctx context.Context, arg CloseOrderReservationsParams) ([]StockReservation, error)
+	CloseOrderReturn(ctx context.Context, arg CloseOrderReturnParams) (OrderReturn, error)
+	CompleteStockTake(ctx context.Context, arg CompleteStockTakeParams) (StockTake, error)
+	CompleteStockTransfer(ctx context.Context, arg CompleteStockTransferParams) (StockTransfer, error)
+	ConsumeCostLayer(ctx context.Context, arg ConsumeCostLayerParams) error
+	CountBundlesWithComponent(ctx context.Context, idFromComponent int32) (int64, error)
+	CountImagesByProduct(ctx context.Context, idFromProduct int32) (int64, error)
+	// Bundle Components
+	CreateBundleComponent(ctx context.Context, arg CreateBundleComponentParams) (BundleComponent, error)
+	// Cost Layers
+	CreateCostLayer(ctx context.Context, arg CreateCostLayerParams) (CostLayer, error)
+	CreateCostLayerConsumption(ctx context.Context, arg CreateCostLayerConsumptionParams) (CostLayerConsumption, error)
+	CreateLotAllocation(ctx context.Context, arg CreateLotAllocationParams) (LotAllocation, error)
 	// Orders
 	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error)
+	// Order returns (RMA)
+	CreateOrderReturn(ctx context.Context, arg CreateOrderReturnParams) (OrderReturn, error)
+	CreateOrderReturnItem(ctx context.Context, arg CreateOrderReturnItemParams) (OrderReturnItem, error)
+	CreateOrderStatusHistory(ctx context.Context, arg CreateOrderStatusHistoryParams) (OrderStatusHistory, error)
 	// Products
 	CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error)
+	// Product Barcodes
+	CreateProductBarcode(ctx context.Context, arg CreateProductBarcodeParams) (ProductBarcode, error)
+	// Product Costs
+	CreateProductCost(ctx context.Context, arg CreateProductCostParams) (ProductCost, error)
+	// Product Images
+	CreateProductImage(ctx context.Context, arg CreateProductImageParams) (ProductImage, error)
+	// Product Prices
+	CreateProductPrice(ctx context.Context, arg CreateProductPriceParams) (ProductPrice, error)
+	// Product Units
+	CreateProductUnit(ctx context.Context, arg CreateProductUnitParams) (ProductUnit, error)
+	// Product Variants
+	CreateProductVariant(ctx context.Context, arg CreateProductVariantParams) (ProductVariant, error)
+	// Purchase Orders
+	CreatePurchaseOrder(ctx context.Context, arg CreatePurchaseOrderParams) (PurchaseOrder, error)
+	CreatePurchaseOrderItem(ctx context.Context, arg CreatePurchaseOrderItemParams) (PurchaseOrderItem, error)
+	CreatePurchaseReceipt(ctx context.Context, arg CreatePurchaseReceiptParams) (PurchaseReceipt, error)
+	CreatePurchaseReceiptItem(ctx context.Context, arg CreatePurchaseReceiptItemParams) (PurchaseReceiptItem, error)
+	CreateSerialEvent(ctx context.Context, arg CreateSerialEventParams) (SerialEvent, error)
+	// Serial Numbers
+	CreateSerialNumber(ctx context.Context, arg CreateSerialNumberParams) (SerialNumber, error)
+	// Stock Movements
+	CreateStockMovement(ctx context.Context, arg CreateStockMovementParams) (StockMovement, error)
+	// Stock Reservations
+	CreateStockReservation(ctx context.Context, arg CreateStockReservationParams) (StockReservation, error)
+	// Stock Takes
+	CreateStockTake(ctx context.Context, arg CreateStockTakeParams) (StockTake, error)
+	// Stock Transfers
+	CreateStockTransfer(ctx context.Context, arg CreateStockTransferParams) (StockTransfer, error)
+	CreateStockTransferItem(ctx context.Context, arg CreateStockTransferItemParams) (StockTransferItem, error)
 	// internal/adapters/postgresql/sqlc/queries.sql
 	// Users
 	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
-	DeleteOrder(ctx context.Context, id int32) error
-	GetLastOrderNumber(ctx context.Context) (string, error)
+	// Warehouses
+	CreateWarehouse(ctx context.Context, arg CreateWarehouseParams) (Warehouse, error)
+	DeleteBundleComponents(ctx context.Context, idFromBundle int32) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	DeleteProductBarcode(ctx context.Context, arg DeleteProductBarcodeParams) (int64, error)
+	DeleteProductImage(ctx context.Context, id int32) error
+	DeleteProductUnit(ctx context.Context, arg DeleteProductUnitParams) (int64, error)
+	DeletePurchaseOrderItems(ctx context.Context, idFromPurchaseOrder int32) error
+	DeleteScheduledPrice(ctx context.Context, arg DeleteScheduledPriceParams) (int64, error)
+	// On-hand quantity at a location and how much of it active reservations hold.
+	GetAvailableStock(ctx context.Context, arg GetAvailableStockParams) (GetAvailableStockRow, error)
+	GetDefaultWarehouse(ctx context.Context) (Warehouse, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	// Stock quantity and value per product as of a point in time, rebuilt from layers received and
+	// consumptions recorded up to that moment. Works for FIFO and weighted average alike because each
+	// consumption stores the unit cost it was charged at.
+	GetInventoryValuation(ctx context.Context, asOf pgtype.Timestamptz) ([]GetInventoryValuationRow, error)
+	GetMarginsByPlatform(ctx context.Context, arg GetMarginsByPlatformParams) ([]GetMarginsByPlatformRow, error)
+	GetMarginsByProduct(ctx context.Context, arg GetMarginsByProductParams) ([]GetMarginsByProductRow, error)
 	GetOrderByID(ctx context.Context, id int32) (Order, error)
+	GetOrderForUpdate(ctx context.Context, id int32) (Order, error)
+	// Margin reports
+	// revenue = line totals of the order items, cogs = cogs_idr from consumed cost layers, falling back to
+	// unit_cost_idr * quantity (unit cost captured at order time).
+	// Cancelled orders are excluded; orders created before cost tracking have no unit cost and count as zero COGS.
+	GetOrderMargins(ctx context.Context, arg GetOrderMarginsParams) ([]GetOrderMarginsRow, error)
+	GetOrderReturnForUpdate(ctx context.Context, id int32) (OrderReturn, error)
+	// Count and sums of the orders ListOrders matches, ignoring the page.
+	GetOrderTotals(ctx context.Context, arg GetOrderTotalsParams) (GetOrderTotalsRow, error)
 	GetPermissionsByID(ctx context.Context, id int32) ([]byte, error)
+	// Resolves a scanned code: either the product's own product_id or one of its registered barcodes.
+	GetProductByCode(ctx context.Context, code string) (Product, error)
 	GetProductByID(ctx context.Context, id int32) (Product, error)
+	// Same as GetProductByID but locks the row until the surrounding transaction ends.
+	GetProductForUpdate(ctx context.Context, id int32) (Product, error)
+	GetProductImageByID(ctx context.Context, id int32) (ProductImage, error)
+	// Units left in the product's layers and their book value (received value minus consumed value).
+	GetProductInventoryValue(ctx context.Context, idFromProduct int32) (GetProductInventoryValueRow, error)
+	GetProductUnitByName(ctx context.Context, arg GetProductUnitByNameParams) (ProductUnit, error)
+	GetProductVariantByID(ctx context.Context, id int32) (ProductVariant, error)
+	GetProductVariantForUpdate(ctx context.Context, id int32) (ProductVariant, error)
+	GetProductsByIDs(ctx context.Context, ids []int32) ([]Product, error)
+	GetPurchaseOrderByID(ctx context.Context, id int32) (PurchaseOrder, error)
+	GetPurchaseOrderForUpdate(ctx context.Context, id int32) (PurchaseOrder, error)
+	// How much of a variant's stock active reservations hold.
+	GetReservedVariantStock(ctx context.Context, idFromVariant pgtype.Int4) (int64, error)
+	// Completed returns per product: returned quantity by inspection outcome and the refunds paid.
+	GetReturnsReport(ctx context.Context, arg GetReturnsReportParams) ([]GetReturnsReportRow, error)
+	GetSerialForUpdate(ctx context.Context, arg GetSerialForUpdateParams) (SerialNumber, error)
+	GetStockTakeByID(ctx context.Context, id int32) (StockTake, error)
+	GetStockTakeForUpdate(ctx context.Context, id int32) (StockTake, error)
+	GetStockTransferByID(ctx context.Context, id int32) (StockTransfer, error)
+	GetStockTransferForUpdate(ctx context.Context, id int32) (StockTransfer, error)
+	// Supplier analytics
+	// Suppliers are keyed by products.supplier_name (plus names only used on purchase orders).
+	// Only POs that went to the supplier count: lead time runs from sending the PO to its first
+	// receipt, fill rate is received / ordered quantity, and a PO with expected_at is on time when
+	// it was received in full by that date (open POs count once the date has passed).
+	GetSupplierPerformance(ctx context.Context, arg GetSupplierPerformanceParams) ([]GetSupplierPerformanceRow, error)
+	// Quantity-weighted purchase cost per product and month.
+	GetSupplierPriceTrend(ctx context.Context, arg GetSupplierPriceTrendParams) ([]GetSupplierPriceTrendRow, error)
 	GetTopProductsFromOrders(ctx context.Context) ([]GetTopProductsFromOrdersRow, error)
 	GetUserByUsernameOrEmail(ctx context.Context, arg GetUserByUsernameOrEmailParams) (GetUserByUsernameOrEmailRow, error)
-	ListOrdersWithProduct(ctx context.Context, arg ListOrdersWithProductParams) ([]ListOrdersWithProductRow, error)
+	GetWarehouseByID(ctx context.Context, id int32) (Warehouse, error)
+	ListActiveReservationsByOrderID(ctx context.Context, idFromOrder int32) ([]StockReservation, error)
+	ListBarcodesByProductIDs(ctx context.Context, productIds []int32) ([]ProductBarcode, error)
+	ListBundleComponentsByBundleIDs(ctx context.Context, bundleIds []int32) ([]ListBundleComponentsByBundleIDsRow, error)
+	ListCostLayersByProduct(ctx context.Context, arg ListCostLayersByProductParams) ([]CostLayer, error)
+	ListCostsByProductIDs(ctx context.Context, productIds []int32) ([]ProductCost, error)
+	ListDeletedOrders(ctx context.Context, arg ListDeletedOrdersParams) ([]Order, error)
+	// Scheduled prices whose time has come, oldest first; locked so concurrent schedulers skip them.
+	ListDuePrices(ctx context.Context, limit int32) ([]ProductPrice, error)
+	// Lots with stock that expire within the given number of days, already expired ones included.
+	ListExpiringLots(ctx context.Context, arg ListExpiringLotsParams) ([]ListExpiringLotsRow, error)
+	ListImagesByProductIDs(ctx context.Context, productIds []int32) ([]ProductImage, error)
+	ListInTransitByProductIDs(ctx context.Context, productIds []int32) ([]ListInTransitByProductIDsRow, error)
+	ListLotsByProductIDs(ctx context.Context, productIds []int32) ([]StockLot, error)
+	// Layers with units left, oldest first; locked so concurrent sales never consume the same unit twice.
+	ListOpenCostLayers(ctx context.Context, idFromProduct int32) ([]CostLayer, error)
+	// Lots with stock at a location in first-expired-first-out order, locked for the allocation.
+	// Expired lots are left out unless include_expired is set (e.g. for write-offs).
+	ListOpenLots(ctx context.Context, arg ListOpenLotsParams) ([]StockLot, error)
+	ListOrderItemsByOrderIDs(ctx context.Context, orderIds []int32) ([]ListOrderItemsByOrderIDsRow, error)
+	ListOrderReturnItemsByReturnIDs(ctx context.Context, returnIds []int32) ([]ListOrderReturnItemsByReturnIDsRow, error)
+	ListOrderReturns(ctx context.Context, arg ListOrderReturnsParams) ([]ListOrderReturnsRow, error)
+	ListOrderStatusHistory(ctx context.Context, idFromOrder int32) ([]ListOrderStatusHistoryRow, error)
+	// Filters are optional; status matches the normalized status (legacy "shipped" is shipping),
+	// customer and search (order number) match substrings.
+	ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error)
+	ListPricesByProductIDs(ctx context.Context, productIds []int32) ([]ProductPrice, error)
 	ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error)
+	ListPurchaseOrderItems(ctx context.Context, idFromPurchaseOrder int32) ([]ListPurchaseOrderItemsRow, error)
+	ListPurchaseOrders(ctx context.Context, arg ListPurchaseOrdersParams) ([]PurchaseOrder, error)
+	ListPurchaseReceipts(ctx context.Context, idFromPurchaseOrder int32) ([]PurchaseReceipt, error)
+	ListReservedByProductIDs(ctx context.Context, productIds []int32) ([]ListReservedByProductIDsRow, error)
+	// Quantity per order line already in returns that were not rejected.
+	ListReturnedQuantitiesByOrderID(ctx context.Context, idFromOrder int32) ([]ListReturnedQuantitiesByOrderIDRow, error)
+	ListSerialEventsBySerialIDs(ctx context.Context, serialIds []int32) ([]ListSerialEventsBySerialIDsRow, error)
+	ListSerialsByNumber(ctx context.Context, serialNumber string) ([]ListSerialsByNumberRow, error)
+	ListSerialsByProduct(ctx context.Context, arg ListSerialsByProductParams) ([]SerialNumber, error)
+	ListStockMovementsByProduct(ctx context.Context, arg ListStockMovementsByProductParams) ([]StockMovement, error)
+	ListStockTakeItems(ctx context.Context, idFromStockTake int32) ([]ListStockTakeItemsRow, error)
+	ListStockTakes(ctx context.Context, arg ListStockTakesParams) ([]StockTake, error)
+	ListStockTransferItems(ctx context.Context, idFromTransfer int32) ([]ListStockTransferItemsRow, error)
+	ListStockTransfers(ctx context.Context, arg ListStockTransfersParams) ([]StockTransfer, error)
+	ListUnitsByProductIDs(ctx context.Context, productIds []int32) ([]ProductUnit, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	ListVariantsByProduct(ctx context.Context, idFromProduct int32) ([]ProductVariant, error)
+	ListVariantsByProductIDs(ctx context.Context, productIds []int32) ([]ProductVariant, error)
+	ListWarehouseStock(ctx context.Context, idFromWarehouse int32) ([]ListWarehouseStockRow, error)
+	ListWarehouseStockByProductIDs(ctx context.Context, productIds []int32) ([]ListWarehouseStockByProductIDsRow, error)
+	ListWarehouses(ctx context.Context) ([]Warehouse, error)
+	MarkPriceApplied(ctx context.Context, id int32) error
+	// Takes the next order number of a period; the row lock serializes concurrent orders.
+	NextOrderNumberSequence(ctx context.Context, period string) (int64, error)
 	// Utility queries
 	// This is a helper to get a next sequence number for product id generation if you prefer DB-side sequence.
 	NextProductSequence(ctx context.Context) (int64, error)
+	// The number the next order of a period would get, without taking it.
+	PeekOrderNumberSequence(ctx context.Context, period string) (int64, error)
+	// Removes soft-deleted orders for good; their lines, reservations and history cascade.
+	PurgeDeletedOrders(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error)
+	// Adds to the received quantity; the CHECK rejects receiving more than was ordered.
+	ReceivePurchaseOrderItem(ctx context.Context, arg ReceivePurchaseOrderItemParams) (PurchaseOrderItem, error)
+	// products found on the shelf but missing from the snapshot are added with the current
+	// location balance as expected quantity; add_to_count sums scans instead of overwriting
+	RecordStockTakeCount(ctx context.Context, arg RecordStockTakeCountParams) (StockTakeItem, error)
+	// Puts the units an unshipped order consumed back on their layers and drops the consumptions.
+	ReleaseCostLayerConsumptions(ctx context.Context, idFromOrder pgtype.Int4) error
+	// Puts the units allocated to an unshipped order back into their lots and drops the allocations.
+	ReleaseLotAllocations(ctx context.Context, idFromOrder int32) error
+	RestoreOrder(ctx context.Context, id int32) (Order, error)
+	SaveIdempotencyResponse(ctx context.Context, arg SaveIdempotencyResponseParams) error
+	SetOrderCancellation(ctx context.Context, arg SetOrderCancellationParams) (Order, error)
+	SetOrderReturnItemOutcome(ctx context.Context, arg SetOrderReturnItemOutcomeParams) error
+	// expected quantities are the location balances at the moment the session opens
+	SnapshotStockTakeItems(ctx context.Context, arg SnapshotStockTakeItemsParams) (int64, error)
+	SoftDeleteOrder(ctx context.Context, arg SoftDeleteOrderParams) (Order, error)
+	TakeFromLot(ctx context.Context, arg TakeFromLotParams) error
+	UpdateOrderCogs(ctx context.Context, arg UpdateOrderCogsParams) (Order, error)
+	UpdateOrderDetails(ctx context.Context, arg UpdateOrderDetailsParams) (Order, error)
+	UpdateOrderItemCogs(ctx context.Context, arg UpdateOrderItemCogsParams) error
 	UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error)
 	UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error)
+	UpdateProductBaseUnit(ctx context.Context, arg UpdateProductBaseUnitParams) (Product, error)
+	UpdateProductCost(ctx context.Context, arg UpdateProductCostParams) (Product, error)
+	UpdateProductIsBundle(ctx context.Context, arg UpdateProductIsBundleParams) (Product, error)
+	UpdateProductPrice(ctx context.Context, arg UpdateProductPriceParams) (Product, error)
+	UpdateProductSerialTracking(ctx context.Context, arg UpdateProductSerialTrackingParams) (Product, error)
 	UpdateProductStock(ctx context.Context, arg UpdateProductStockParams) (Product, error)
 	UpdateProductStockByDelta(ctx context.Context, arg UpdateProductStockByDeltaParams) (Product, error)
+	UpdateProductVariantStock(ctx context.Context, arg UpdateProductVariantStockParams) (ProductVariant, error)
+	UpdateProductVariantStockByDelta(ctx context.Context, arg UpdateProductVariantStockByDeltaParams) (ProductVariant, error)
+	// sent_at is stamped when the PO goes to the supplier, closed_at when it is received in full
+	// or cancelled.
+	UpdatePurchaseOrderStatus(ctx context.Context, arg UpdatePurchaseOrderStatusParams) (PurchaseOrder, error)
+	UpdateSerialStatus(ctx context.Context, arg UpdateSerialStatusParams) (SerialNumber, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateUserRow, error)
 	UpdateUserPermissions(ctx context.Context, arg UpdateUserPermissionsParams) error
 	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error
+	// Stock Lots
+	// Adds quantity (may be negative) to a lot at a location, creating the lot on first receipt.
+	// The CHECK on stock_lots.quantity rejects taking more than the lot holds.
+	UpsertStockLot(ctx context.Context, arg UpsertStockLotParams) (StockLot, error)
 	UserByID(ctx context.Context, id int32) (UserByIDRow, error)
 }
 
@@ -62,9 +62,9 @@ WHERE id = $1;
 -- Products
 
 -- name: CreateProduct :one
-INSERT INTO products (product_id, product_name, supplier_name, category, price_idr, stock)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at;
+INSERT INTO products (product_id, product_name, supplier_name, category, price_idr, stock, cost_idr, track_serials, base_unit)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
 
 -- name: GetProductByID :one
 SELECT
@@ -76,11 +76,75 @@ SELECT
   price_idr,
   stock,
   created_at,
-  updated_at
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
 FROM products
 WHERE id = $1
 LIMIT 1;
 
+-- name: GetProductsByIDs :many
+SELECT
+  id,
+  product_id,
+  product_name,
+  supplier_name,
+  category,
+  price_idr,
+  stock,
+  created_at,
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
+FROM products
+WHERE id = ANY(sqlc.arg(ids)::int[])
+ORDER BY id;
+
+-- name: GetProductByCode :one
+-- Resolves a scanned code: either the product's own product_id or one of its registered barcodes.
+SELECT
+  id,
+  product_id,
+  product_name,
+  supplier_name,
+  category,
+  price_idr,
+  stock,
+  created_at,
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
+FROM products
+WHERE product_id = sqlc.arg(code)
+   OR id IN (SELECT id_from_product FROM product_barcodes WHERE barcode = sqlc.arg(code))
+LIMIT 1;
+
+-- name: GetProductForUpdate :one
+-- Same as GetProductByID but locks the row until the surrounding transaction ends.
+SELECT
+  id,
+  product_id,
+  product_name,
+  supplier_name,
+  category,
+  price_idr,
+  stock,
+  created_at,
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
+FROM products
+WHERE id = $1
+FOR UPDATE;
+
 -- name: ListProducts :many
 SELECT
   id,
@@ -91,7 +155,11 @@ SELECT
   price_idr,
   stock,
   created_at,
-  updated_at
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
 FROM products
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
@@ -101,7 +169,7 @@ UPDATE products
 SET stock = $2,
     updated_at = now()
 WHERE id = $1
-RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at;
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
 
 -- name: UpdateProductStockByDelta :one
 UPDATE products
@@ -109,7 +177,42 @@ SET stock = stock + $2,
     updated_at = now()
 WHERE id = $1
   AND (stock + $2) >= 0
-RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at;
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
+
+-- name: UpdateProductPrice :one
+UPDATE products
+SET price_idr = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
+
+-- name: UpdateProductCost :one
+UPDATE products
+SET cost_idr = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
+
+-- name: UpdateProductBaseUnit :one
+UPDATE products
+SET base_unit = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
+
+-- name: UpdateProductIsBundle :one
+UPDATE products
+SET is_bundle = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
+
+-- name: UpdateProductSerialTracking :one
+UPDATE products
+SET track_serials = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
 
 -- name: UpdateProduct :one
 UPDATE products
@@ -121,78 +224,1185 @@ SET product_id    = $2,
     stock         = $7,
     updated_at    = now()
 WHERE id = $1
-RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at;
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit;
+
+-- Product Variants
+
+-- name: CreateProductVariant :one
+INSERT INTO product_variants (id_from_product, product_id, variant_name, price_idr, stock, attributes)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetProductVariantByID :one
+SELECT * FROM product_variants
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetProductVariantForUpdate :one
+SELECT * FROM product_variants
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListVariantsByProduct :many
+SELECT * FROM product_variants
+WHERE id_from_product = $1
+ORDER BY id;
+
+-- name: ListVariantsByProductIDs :many
+SELECT * FROM product_variants
+WHERE id_from_product = ANY(sqlc.arg(product_ids)::int[])
+ORDER BY id_from_product, id;
+
+-- name: UpdateProductVariantStock :one
+UPDATE product_variants
+SET stock = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateProductVariantStockByDelta :one
+UPDATE product_variants
+SET stock = stock + $2,
+    updated_at = now()
+WHERE id = $1
+  AND (stock + $2) >= 0
+RETURNING *;
+
+-- Product Images
+
+-- name: CreateProductImage :one
+INSERT INTO product_images (id_from_product, storage_key, thumbnail_key, content_type, size_bytes, width, height, sort_order)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetProductImageByID :one
+SELECT * FROM product_images
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListImagesByProductIDs :many
+SELECT * FROM product_images
+WHERE id_from_product = ANY(sqlc.arg(product_ids)::int[])
+ORDER BY id_from_product, sort_order, id;
+
+-- name: CountImagesByProduct :one
+SELECT COUNT(*) FROM product_images
+WHERE id_from_product = $1;
+
+-- name: DeleteProductImage :exec
+DELETE FROM product_images
+WHERE id = $1;
+
+-- Product Barcodes
+
+-- name: CreateProductBarcode :one
+INSERT INTO product_barcodes (id_from_product, barcode)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: ListBarcodesByProductIDs :many
+SELECT * FROM product_barcodes
+WHERE id_from_product = ANY(sqlc.arg(product_ids)::int[])
+ORDER BY id_from_product, id;
+
+-- name: DeleteProductBarcode :execrows
+DELETE FROM product_barcodes
+WHERE id = $1 AND id_from_product = $2;
+
+-- Stock Movements
+
+-- name: CreateStockMovement :one
+INSERT INTO stock_movements (id_from_product, quantity, stock_after, reason, reference)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListStockMovementsByProduct :many
+SELECT * FROM stock_movements
+WHERE id_from_product = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- Product Prices
+
+-- name: CreateProductPrice :one
+INSERT INTO product_prices (id_from_product, price_idr, effective_at, applied_at, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListPricesByProductIDs :many
+SELECT * FROM product_prices
+WHERE id_from_product = ANY(sqlc.arg(product_ids)::int[])
+ORDER BY id_from_product, effective_at DESC, id DESC;
+
+-- name: ListDuePrices :many
+-- Scheduled prices whose time has come, oldest first; locked so concurrent schedulers skip them.
+SELECT * FROM product_prices
+WHERE applied_at IS NULL
+  AND effective_at <= now()
+ORDER BY effective_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkPriceApplied :exec
+UPDATE product_prices
+SET applied_at = now()
+WHERE id = $1;
+
+-- name: DeleteScheduledPrice :execrows
+DELETE FROM product_prices
+WHERE id = $1
+  AND id_from_product = $2
+  AND applied_at IS NULL;
+
+-- Product Costs
+
+-- name: CreateProductCost :one
+INSERT INTO product_costs (id_from_product, cost_idr, note)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListCostsByProductIDs :many
+SELECT * FROM product_costs
+WHERE id_from_product = ANY(sqlc.arg(product_ids)::int[])
+ORDER BY id_from_product, created_at DESC, id DESC;
+
+-- Cost Layers
+
+-- name: CreateCostLayer :one
+INSERT INTO cost_layers (id_from_product, quantity, quantity_remaining, unit_cost_idr, received_at, reference)
+VALUES ($1, $2, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListCostLayersByProduct :many
+SELECT * FROM cost_layers
+WHERE id_from_product = $1
+ORDER BY received_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListOpenCostLayers :many
+-- Layers with units left, oldest first; locked so concurrent sales never consume the same unit twice.
+SELECT * FROM cost_layers
+WHERE id_from_product = $1
+  AND quantity_remaining > 0
+ORDER BY received_at, id
+FOR UPDATE;
+
+-- name: ConsumeCostLayer :exec
+UPDATE cost_layers
+SET quantity_remaining = quantity_remaining - $2
+WHERE id = $1;
+
+-- name: CreateCostLayerConsumption :one
+INSERT INTO cost_layer_consumptions (id_from_layer, id_from_order, quantity, unit_cost_idr, reference)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ReleaseCostLayerConsumptions :exec
+-- Puts the units an unshipped order consumed back on their layers and drops the consumptions.
+WITH released AS (
+  DELETE FROM cost_layer_consumptions
+  WHERE id_from_order = $1
+  RETURNING id_from_layer, quantity
+)
+UPDATE cost_layers l
+SET quantity_remaining = l.quantity_remaining + r.quantity
+FROM (SELECT id_from_layer, SUM(quantity)::int AS quantity FROM released GROUP BY id_from_layer) r
+WHERE l.id = r.id_from_layer;
+
+-- name: GetProductInventoryValue :one
+-- Units left in the product's layers and their book value (received value minus consumed value).
+SELECT
+  (SELECT COALESCE(SUM(l.quantity_remaining), 0)
+     FROM cost_layers l
+    WHERE l.id_from_product = $1)::bigint AS quantity,
+  ((SELECT COALESCE(SUM(l.quantity::bigint * l.unit_cost_idr), 0)
+      FROM cost_layers l
+     WHERE l.id_from_product = $1)
+   - (SELECT COALESCE(SUM(c.quantity::bigint * c.unit_cost_idr), 0)
+        FROM cost_layer_consumptions c
+        JOIN cost_layers l ON l.id = c.id_from_layer
+       WHERE l.id_from_product = $1))::bigint AS value_idr;
+
+-- name: GetInventoryValuation :many
+-- Stock quantity and value per product as of a point in time, rebuilt from layers received and
+-- consumptions recorded up to that moment. Works for FIFO and weighted average alike because each
+-- consumption stores the unit cost it was charged at.
+SELECT p.id,
+       p.product_id,
+       p.product_name,
+       (COALESCE(r.quantity, 0) - COALESCE(c.quantity, 0))::bigint AS quantity,
+       (COALESCE(r.value_idr, 0) - COALESCE(c.value_idr, 0))::bigint AS value_idr
+FROM products p
+LEFT JOIN (
+  SELECT id_from_product,
+         SUM(quantity) AS quantity,
+         SUM(quantity::bigint * unit_cost_idr) AS value_idr
+  FROM cost_layers
+  WHERE received_at <= sqlc.arg(as_of)::timestamptz
+  GROUP BY id_from_product
+) r ON r.id_from_product = p.id
+LEFT JOIN (
+  SELECT l.id_from_product,
+         SUM(cc.quantity) AS quantity,
+         SUM(cc.quantity::bigint * cc.unit_cost_idr) AS value_idr
+  FROM cost_layer_consumptions cc
+  JOIN cost_layers l ON l.id = cc.id_from_layer
+  WHERE cc.created_at <= sqlc.arg(as_of)::timestamptz
+  GROUP BY l.id_from_product
+) c ON c.id_from_product = p.id
+WHERE COALESCE(r.quantity, 0) - COALESCE(c.quantity, 0) <> 0
+ORDER BY p.product_id;
+
+-- Warehouses
+
+-- name: CreateWarehouse :one
+INSERT INTO warehouses (code, name, address)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListWarehouses :many
+SELECT * FROM warehouses
+ORDER BY is_default DESC, code;
+
+-- name: GetWarehouseByID :one
+SELECT * FROM warehouses
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetDefaultWarehouse :one
+SELECT * FROM warehouses
+WHERE is_default
+LIMIT 1;
+
+-- name: AdjustWarehouseStock :one
+-- Adds quantity (may be negative) to the product's balance at the location; the CHECK on
+-- warehouse_stock.quantity rejects changes that would go below zero.
+INSERT INTO warehouse_stock (id_from_warehouse, id_from_product, quantity)
+VALUES ($1, $2, $3)
+ON CONFLICT (id_from_warehouse, id_from_product)
+DO UPDATE SET quantity = warehouse_stock.quantity + EXCLUDED.quantity,
+              updated_at = now()
+RETURNING *;
+
+-- name: ListWarehouseStock :many
+SELECT s.id_from_product,
+       p.product_id,
+       p.product_name,
+       s.quantity,
+       s.updated_at
+FROM warehouse_stock s
+JOIN products p ON p.id = s.id_from_product
+WHERE s.id_from_warehouse = $1
+  AND s.quantity > 0
+ORDER BY p.product_id;
+
+-- name: ListWarehouseStockByProductIDs :many
+SELECT s.id_from_product,
+       s.id_from_warehouse,
+       w.code,
+       w.name,
+       s.quantity
+FROM warehouse_stock s
+JOIN warehouses w ON w.id = s.id_from_warehouse
+WHERE s.id_from_product = ANY(sqlc.arg(product_ids)::int[])
+ORDER BY s.id_from_product, w.is_default DESC, w.code;
+
+-- Stock Transfers
+
+-- name: CreateStockTransfer :one
+INSERT INTO stock_transfers (id_from_source, id_from_destination, note)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CreateStockTransferItem :one
+INSERT INTO stock_transfer_items (id_from_transfer, id_from_product, quantity, lot_number, expiry_date, serial_numbers)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetStockTransferByID :one
+SELECT * FROM stock_transfers
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetStockTransferForUpdate :one
+SELECT * FROM stock_transfers
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListStockTransfers :many
+SELECT * FROM stock_transfers
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: ListStockTransferItems :many
+SELECT i.id,
+       i.id_from_transfer,
+       i.id_from_product,
+       p.product_id,
+       p.product_name,
+       i.quantity,
+       i.lot_number,
+       i.expiry_date,
+       i.serial_numbers
+FROM stock_transfer_items i
+JOIN products p ON p.id = i.id_from_product
+WHERE i.id_from_transfer = $1
+ORDER BY i.id;
+
+-- name: CompleteStockTransfer :one
+UPDATE stock_transfers
+SET status = $2,
+    completed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListInTransitByProductIDs :many
+SELECT i.id_from_product,
+       SUM(i.quantity)::bigint AS quantity
+FROM stock_transfer_items i
+JOIN stock_transfers t ON t.id = i.id_from_transfer
+WHERE t.status = 'in_transit'
+  AND i.id_from_product = ANY(sqlc.arg(product_ids)::int[])
+GROUP BY i.id_from_product;
+
+-- Stock Lots
+
+-- name: UpsertStockLot :one
+-- Adds quantity (may be negative) to a lot at a location, creating the lot on first receipt.
+-- The CHECK on stock_lots.quantity rejects taking more than the lot holds.
+INSERT INTO stock_lots (id_from_product, id_from_warehouse, lot_number, expiry_date, quantity)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id_from_product, id_from_warehouse, lot_number)
+DO UPDATE SET quantity = stock_lots.quantity + EXCLUDED.quantity,
+              expiry_date = COALESCE(EXCLUDED.expiry_date, stock_lots.expiry_date),
+              updated_at = now()
+RETURNING *;
+
+-- name: ListOpenLots :many
+-- Lots with stock at a location in first-expired-first-out order, locked for the allocation.
+-- Expired lots are left out unless include_expired is set (e.g. for write-offs).
+SELECT * FROM stock_lots
+WHERE id_from_product = sqlc.arg(id_from_product)
+  AND id_from_warehouse = sqlc.arg(id_from_warehouse)
+  AND quantity > 0
+  AND (sqlc.arg(include_expired)::bool OR expiry_date IS NULL OR expiry_date >= CURRENT_DATE)
+ORDER BY expiry_date NULLS LAST, id
+FOR UPDATE;
+
+-- name: TakeFromLot :exec
+UPDATE stock_lots
+SET quantity = quantity - $2,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: CreateLotAllocation :one
+INSERT INTO lot_allocations (id_from_lot, id_from_order, quantity)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ReleaseLotAllocations :exec
+-- Puts the units allocated to an unshipped order back into their lots and drops the allocations.
+WITH released AS (
+  DELETE FROM lot_allocations
+  WHERE id_from_order = $1
+  RETURNING id_from_lot, quantity
+)
+UPDATE stock_lots l
+SET quantity = l.quantity + r.quantity,
+    updated_at = now()
+FROM (SELECT id_from_lot, SUM(quantity)::int AS quantity FROM released GROUP BY id_from_lot) r
+WHERE l.id = r.id_from_lot;
+
+-- name: ListLotsByProductIDs :many
+SELECT * FROM stock_lots
+WHERE id_from_product = ANY(sqlc.arg(product_ids)::int[])
+  AND quantity > 0
+ORDER BY id_from_product, expiry_date NULLS LAST, id;
+
+-- name: ListExpiringLots :many
+-- Lots with stock that expire within the given number of days, already expired ones included.
+SELECT l.id,
+       l.id_from_product,
+       p.product_id,
+       p.product_name,
+       l.id_from_warehouse,
+       w.code AS warehouse_code,
+       l.lot_number,
+       l.expiry_date,
+       l.quantity,
+       (l.expiry_date - CURRENT_DATE)::int AS days_left
+FROM stock_lots l
+JOIN products p ON p.id = l.id_from_product
+JOIN warehouses w ON w.id = l.id_from_warehouse
+WHERE l.quantity > 0
+  AND l.expiry_date IS NOT NULL
+  AND l.expiry_date <= CURRENT_DATE + sqlc.arg(days)::int
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR l.id_from_warehouse = sqlc.narg(warehouse_id))
+ORDER BY l.expiry_date, p.product_id, l.lot_number;
+
+-- Serial Numbers
+
+-- name: CreateSerialNumber :one
+INSERT INTO serial_numbers (id_from_product, serial_number, id_from_warehouse)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetSerialForUpdate :one
+SELECT * FROM serial_numbers
+WHERE id_from_product = $1 AND serial_number = $2
+FOR UPDATE;
+
+-- name: UpdateSerialStatus :one
+UPDATE serial_numbers
+SET status = $2,
+    id_from_warehouse = $3,
+    id_from_order = $4,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateSerialEvent :one
+INSERT INTO serial_events (id_from_serial, event, id_from_order, reference)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListSerialsByProduct :many
+SELECT * FROM serial_numbers
+WHERE id_from_product = sqlc.arg(id_from_product)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+ORDER BY serial_number
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: ListSerialsByNumber :many
+SELECT s.id,
+       s.id_from_product,
+       p.product_id,
+       p.product_name,
+       s.serial_number,
+       s.status,
+       s.id_from_warehouse,
+       s.id_from_order,
+       s.created_at,
+       s.updated_at
+FROM serial_numbers s
+JOIN products p ON p.id = s.id_from_product
+WHERE s.serial_number = $1
+ORDER BY s.id;
+
+-- name: ListSerialEventsBySerialIDs :many
+SELECT e.id,
+       e.id_from_serial,
+       e.event,
+       e.id_from_order,
+       o.order_number,
+       o.customer_name,
+       e.reference,
+       e.created_at
+FROM serial_events e
+LEFT JOIN orders o ON o.id = e.id_from_order
+WHERE e.id_from_serial = ANY(sqlc.arg(serial_ids)::int[])
+ORDER BY e.id_from_serial, e.created_at, e.id;
+
+-- Stock Takes
+
+-- name: CreateStockTake :one
+INSERT INTO stock_takes (id_from_warehouse, note, created_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: SnapshotStockTakeItems :execrows
+-- expected quantities are the location balances at the moment the session opens
+INSERT INTO stock_take_items (id_from_stock_take, id_from_product, expected_qty)
+SELECT sqlc.arg(id_from_stock_take)::int, ws.id_from_product, ws.quantity
+FROM warehouse_stock ws
+JOIN products p ON p.id = ws.id_from_product
+WHERE ws.id_from_warehouse = sqlc.arg(id_from_warehouse)
+  AND (sqlc.narg(category)::text IS NULL OR p.category = sqlc.narg(category));
+
+-- name: GetStockTakeByID :one
+SELECT * FROM stock_takes
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetStockTakeForUpdate :one
+SELECT * FROM stock_takes
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListStockTakes :many
+SELECT * FROM stock_takes
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: ListStockTakeItems :many
+SELECT i.id,
+       i.id_from_stock_take,
+       i.id_from_product,
+       p.product_id,
+       p.product_name,
+       p.cost_idr,
+       i.expected_qty,
+       i.counted_qty,
+       i.counted_at
+FROM stock_take_items i
+JOIN products p ON p.id = i.id_from_product
+WHERE i.id_from_stock_take = $1
+ORDER BY p.product_id;
+
+-- name: RecordStockTakeCount :one
+-- products found on the shelf but missing from the snapshot are added with the current
+-- location balance as expected quantity; add_to_count sums scans instead of overwriting
+INSERT INTO stock_take_items (id_from_stock_take, id_from_product, expected_qty, counted_qty, counted_at)
+VALUES (
+  sqlc.arg(id_from_stock_take),
+  sqlc.arg(id_from_product),
+  COALESCE((SELECT ws.quantity FROM warehouse_stock ws
+            WHERE ws.id_from_warehouse = sqlc.arg(id_from_warehouse)
+              AND ws.id_from_product = sqlc.arg(id_from_product)), 0),
+  sqlc.arg(counted_qty),
+  now()
+)
+ON CONFLICT (id_from_stock_take, id_from_product) DO UPDATE
+SET counted_qty = CASE WHEN sqlc.arg(add_to_count)::boolean
+                       THEN COALESCE(stock_take_items.counted_qty, 0) + EXCLUDED.counted_qty
+                       ELSE EXCLUDED.counted_qty END,
+    counted_at = now()
+RETURNING *;
+
+-- name: CompleteStockTake :one
+UPDATE stock_takes
+SET status = $2,
+    approved_by = $3,
+    completed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- Stock Reservations
+
+-- name: CreateStockReservation :one
+INSERT INTO stock_reservations (id_from_order, id_from_product, id_from_warehouse, quantity, id_from_variant)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CloseOrderReservations :many
+-- Moves the order's active reservations to fulfilled (shipped) or released (cancelled).
+UPDATE stock_reservations
+SET status = $2,
+    updated_at = now()
+WHERE id_from_order = $1 AND status = 'active'
+RETURNING *;
+
+-- name: ListActiveReservationsByOrderID :many
+SELECT * FROM stock_reservations
+WHERE id_from_order = $1 AND status = 'active'
+ORDER BY id;
+
+-- name: GetAvailableStock :one
+-- On-hand quantity at a location and how much of it active reservations hold.
+SELECT COALESCE((SELECT ws.quantity FROM warehouse_stock ws
+                 WHERE ws.id_from_product = sqlc.arg(id_from_product)
+                   AND ws.id_from_warehouse = sqlc.arg(id_from_warehouse)), 0)::bigint AS on_hand,
+       COALESCE((SELECT SUM(r.quantity) FROM stock_reservations r
+                 WHERE r.id_from_product = sqlc.arg(id_from_product)
+                   AND r.id_from_warehouse = sqlc.arg(id_from_warehouse)
+                   AND r.status = 'active'), 0)::bigint AS reserved;
+
+-- name: GetReservedVariantStock :one
+-- How much of a variant's stock active reservations hold.
+SELECT COALESCE(SUM(quantity), 0)::bigint AS reserved
+FROM stock_reservations
+WHERE id_from_variant = $1 AND status = 'active';
+
+-- name: ListReservedByProductIDs :many
+SELECT id_from_product,
+       id_from_warehouse,
+       SUM(quantity)::bigint AS quantity
+FROM stock_reservations
+WHERE status = 'active'
+  AND id_from_product = ANY(sqlc.arg(product_ids)::int[])
+GROUP BY id_from_product, id_from_warehouse;
+
+-- Bundle Components
+
+-- name: CreateBundleComponent :one
+INSERT INTO bundle_components (id_from_bundle, id_from_component, quantity)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: DeleteBundleComponents :exec
+DELETE FROM bundle_components
+WHERE id_from_bundle = $1;
+
+-- name: ListBundleComponentsByBundleIDs :many
+SELECT c.id_from_bundle,
+       c.id_from_component,
+       p.product_id,
+       p.product_name,
+       p.stock,
+       p.cost_idr,
+       c.quantity
+FROM bundle_components c
+JOIN products p ON p.id = c.id_from_component
+WHERE c.id_from_bundle = ANY(sqlc.arg(bundle_ids)::int[])
+ORDER BY c.id_from_bundle, p.product_id;
+
+-- name: CountBundlesWithComponent :one
+SELECT COUNT(*) FROM bundle_components
+WHERE id_from_component = $1;
+
+-- Product Units
+
+-- name: CreateProductUnit :one
+INSERT INTO product_units (id_from_product, unit_name, factor)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetProductUnitByName :one
+SELECT * FROM product_units
+WHERE id_from_product = $1 AND lower(unit_name) = lower(sqlc.arg(unit_name)::text)
+LIMIT 1;
+
+-- name: ListUnitsByProductIDs :many
+SELECT * FROM product_units
+WHERE id_from_product = ANY(sqlc.arg(product_ids)::int[])
+ORDER BY id_from_product, factor DESC;
+
+-- name: DeleteProductUnit :execrows
+DELETE FROM product_units
+WHERE id = $1 AND id_from_product = $2;
+
+-- Purchase Orders
+
+-- name: CreatePurchaseOrder :one
+INSERT INTO purchase_orders (supplier_name, id_from_warehouse, note, expected_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetPurchaseOrderByID :one
+SELECT * FROM purchase_orders
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetPurchaseOrderForUpdate :one
+SELECT * FROM purchase_orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListPurchaseOrders :many
+SELECT * FROM purchase_orders
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(supplier_name)::text IS NULL OR supplier_name = sqlc.narg(supplier_name))
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: UpdatePurchaseOrderStatus :one
+-- sent_at is stamped when the PO goes to the supplier, closed_at when it is received in full
+-- or cancelled.
+UPDATE purchase_orders
+SET status = sqlc.arg(status),
+    sent_at = CASE WHEN sqlc.arg(status) = 'sent' THEN now() ELSE sent_at END,
+    closed_at = CASE WHEN sqlc.arg(status) IN ('received', 'cancelled') THEN now() ELSE closed_at END,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreatePurchaseOrderItem :one
+INSERT INTO purchase_order_items (id_from_purchase_order, id_from_product, quantity, unit_cost_idr)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeletePurchaseOrderItems :exec
+DELETE FROM purchase_order_items
+WHERE id_from_purchase_order = $1;
+
+-- name: ListPurchaseOrderItems :many
+SELECT i.id,
+       i.id_from_purchase_order,
+       i.id_from_product,
+       p.product_id,
+       p.product_name,
+       p.base_unit,
+       i.quantity,
+       i.unit_cost_idr,
+       i.received_qty
+FROM purchase_order_items i
+JOIN products p ON p.id = i.id_from_product
+WHERE i.id_from_purchase_order = $1
+ORDER BY i.id;
+
+-- name: ReceivePurchaseOrderItem :one
+-- Adds to the received quantity; the CHECK rejects receiving more than was ordered.
+UPDATE purchase_order_items
+SET received_qty = received_qty + $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CreatePurchaseReceipt :one
+INSERT INTO purchase_receipts (id_from_purchase_order, reference, received_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CreatePurchaseReceiptItem :one
+INSERT INTO purchase_receipt_items (id_from_receipt, id_from_purchase_order_item, quantity, lot_number)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListPurchaseReceipts :many
+SELECT * FROM purchase_receipts
+WHERE id_from_purchase_order = $1
+ORDER BY received_at, id;
 
 -- Orders
 
 -- name: CreateOrder :one
 INSERT INTO orders (
     order_number,
-    id_from_product,
-    product_id,
     customer_name,
     platform,
     destination,
     total_amount,
+    total_idr,
     status,
-    price_idr,
-    created_at
+    created_at,
+    id_from_warehouse
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
 RETURNING *;
 
 -- name: GetOrderByID :one
 SELECT * FROM orders
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
--- name: GetLastOrderNumber :one
-SELECT order_number
-FROM orders
+-- name: GetOrderForUpdate :one
+SELECT * FROM orders
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: ListOrders :many
+-- Filters are optional; status matches the normalized status (legacy "shipped" is shipping),
+-- customer and search (order number) match substrings.
+SELECT * FROM orders
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(status)::text IS NULL
+       OR (CASE lower(status) WHEN 'shipped' THEN 'shipping' ELSE lower(status) END) = sqlc.narg(status))
+  AND (sqlc.narg(platform)::text IS NULL OR platform ILIKE sqlc.narg(platform))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(customer)::text IS NULL OR customer_name ILIKE '%' || sqlc.narg(customer) || '%')
+  AND (sqlc.narg(search)::text IS NULL OR order_number ILIKE '%' || sqlc.narg(search) || '%')
 ORDER BY id DESC
-LIMIT 1;
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
 
--- name: ListOrdersWithProduct :many
-SELECT
-  o.id,
-  o.order_number,
-  o.id_from_product,       -- FK ke products.id
-  o.product_id,            -- kode produk (text)
-  o.customer_name,
-  o.total_amount,
-  o.status,
-  o.platform,
-  o.destination,
-  o.price_idr,             -- harga produk
-  o.created_at,
-  p.product_name
-FROM orders o
-JOIN products p ON o.id_from_product = p.id
-ORDER BY o.id DESC
-LIMIT $1 OFFSET $2;
+-- name: GetOrderTotals :one
+-- Count and sums of the orders ListOrders matches, ignoring the page.
+SELECT COUNT(*) AS order_count,
+       COALESCE(SUM(total_amount), 0)::bigint AS total_quantity,
+       COALESCE(SUM(total_idr), 0)::bigint AS total_idr
+FROM orders
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(status)::text IS NULL
+       OR (CASE lower(status) WHEN 'shipped' THEN 'shipping' ELSE lower(status) END) = sqlc.narg(status))
+  AND (sqlc.narg(platform)::text IS NULL OR platform ILIKE sqlc.narg(platform))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(customer)::text IS NULL OR customer_name ILIKE '%' || sqlc.narg(customer) || '%')
+  AND (sqlc.narg(search)::text IS NULL OR order_number ILIKE '%' || sqlc.narg(search) || '%');
+
+-- name: UpdateOrderDetails :one
+UPDATE orders
+SET customer_name = COALESCE(sqlc.narg(customer_name), customer_name),
+    destination = COALESCE(sqlc.narg(destination), destination),
+    platform = COALESCE(sqlc.narg(platform), platform),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
 
 -- name: UpdateOrderStatus :one
 UPDATE orders
-SET status = $2
+SET status = $2,
+    updated_at = now()
 WHERE id = $1
 RETURNING *;
 
--- name: DeleteOrder :exec
+-- name: UpdateOrderCogs :one
+UPDATE orders
+SET cogs_idr = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: SoftDeleteOrder :one
+UPDATE orders
+SET deleted_at = now(),
+    deleted_by = $2
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreOrder :one
+UPDATE orders
+SET deleted_at = NULL,
+    deleted_by = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: ListDeletedOrders :many
+SELECT * FROM orders
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: PurgeDeletedOrders :execrows
+-- Removes soft-deleted orders for good; their lines, reservations and history cascade.
 DELETE FROM orders
+WHERE deleted_at < $1;
+
+-- name: CreateOrderItem :one
+INSERT INTO order_items (
+    id_from_order,
+    id_from_product,
+    id_from_variant,
+    product_id,
+    quantity,
+    unit_price_idr,
+    line_total_idr,
+    unit_cost_idr,
+    cogs_idr
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: UpdateOrderItemCogs :exec
+UPDATE order_items
+SET cogs_idr = $2
 WHERE id = $1;
 
+-- name: ListOrderItemsByOrderIDs :many
+SELECT i.id,
+       i.id_from_order,
+       i.id_from_product,
+       i.id_from_variant,
+       i.product_id,
+       p.product_name,
+       v.variant_name,
+       i.quantity,
+       i.unit_price_idr,
+       i.line_total_idr,
+       i.unit_cost_idr,
+       i.cogs_idr
+FROM order_items i
+LEFT JOIN products p ON p.id = i.id_from_product
+LEFT JOIN product_variants v ON v.id = i.id_from_variant
+WHERE i.id_from_order = ANY(sqlc.arg(order_ids)::int[])
+ORDER BY i.id_from_order, i.id;
+
+-- name: CreateOrderStatusHistory :one
+INSERT INTO order_status_history (id_from_order, from_status, to_status, changed_by, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListOrderStatusHistory :many
+SELECT h.id,
+       h.id_from_order,
+       h.from_status,
+       h.to_status,
+       h.changed_by,
+       u.full_name AS changed_by_name,
+       h.note,
+       h.created_at
+FROM order_status_history h
+LEFT JOIN users u ON u.id = h.changed_by
+WHERE h.id_from_order = $1
+ORDER BY h.created_at, h.id;
+
 -- name: GetTopProductsFromOrders :many
-SELECT o.product_id,
+SELECT i.product_id,
        p.product_name,
-       SUM(o.total_amount) AS total_sold
-FROM orders o
-JOIN products p ON o.product_id = p.product_id
-GROUP BY o.product_id, p.product_name
+       SUM(i.quantity) AS total_sold
+FROM order_items i
+JOIN orders o ON o.id = i.id_from_order
+JOIN products p ON p.id = i.id_from_product
+WHERE o.deleted_at IS NULL
+GROUP BY i.product_id, p.product_name
 ORDER BY total_sold DESC
 LIMIT 5;
 
+-- name: SetOrderCancellation :one
+UPDATE orders
+SET cancel_reason = sqlc.arg(cancel_reason),
+    cancelled_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: NextOrderNumberSequence :one
+-- Takes the next order number of a period; the row lock serializes concurrent orders.
+INSERT INTO order_number_counters (period, last_value)
+VALUES (sqlc.arg(period), 1)
+ON CONFLICT (period) DO UPDATE
+SET last_value = order_number_counters.last_value + 1,
+    updated_at = now()
+RETURNING last_value;
+
+-- name: PeekOrderNumberSequence :one
+-- The number the next order of a period would get, without taking it.
+SELECT (COALESCE(MAX(last_value), 0) + 1)::bigint AS next_value
+FROM order_number_counters
+WHERE period = sqlc.arg(period);
+
+-- Order returns (RMA)
+
+-- name: CreateOrderReturn :one
+INSERT INTO order_returns (id_from_order, reason, id_from_warehouse, note, requested_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CreateOrderReturnItem :one
+INSERT INTO order_return_items (id_from_return, id_from_order_item, quantity, serial_numbers)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetOrderReturnForUpdate :one
+SELECT * FROM order_returns
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListOrderReturns :many
+SELECT r.*,
+       o.order_number
+FROM order_returns r
+JOIN orders o ON o.id = r.id_from_order
+WHERE (sqlc.narg(return_id)::int IS NULL OR r.id = sqlc.narg(return_id))
+  AND (sqlc.narg(order_id)::int IS NULL OR r.id_from_order = sqlc.narg(order_id))
+  AND (sqlc.narg(status)::text IS NULL OR r.status = sqlc.narg(status))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR r.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR r.created_at < sqlc.narg(created_to))
+ORDER BY r.id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: ListOrderReturnItemsByReturnIDs :many
+SELECT ri.*,
+       p.id AS id_from_product,
+       i.id_from_variant,
+       i.product_id,
+       p.product_name,
+       p.track_serials,
+       i.unit_price_idr,
+       i.unit_cost_idr,
+       i.quantity AS ordered_qty,
+       i.cogs_idr
+FROM order_return_items ri
+JOIN order_items i ON i.id = ri.id_from_order_item
+JOIN products p ON p.id = i.id_from_product
+WHERE ri.id_from_return = ANY(sqlc.arg(return_ids)::int[])
+ORDER BY ri.id_from_return, ri.id;
+
+-- name: ListReturnedQuantitiesByOrderID :many
+-- Quantity per order line already in returns that were not rejected.
+SELECT ri.id_from_order_item,
+       SUM(ri.quantity)::bigint AS returned_qty
+FROM order_return_items ri
+JOIN order_returns r ON r.id = ri.id_from_return
+WHERE r.id_from_order = $1
+  AND r.status <> 'rejected'
+GROUP BY ri.id_from_order_item;
+
+-- name: SetOrderReturnItemOutcome :exec
+UPDATE order_return_items
+SET outcome = $2,
+    refund_idr = $3,
+    note = $4
+WHERE id = $1;
+
+-- name: CloseOrderReturn :one
+UPDATE order_returns
+SET status = $2,
+    refund_idr = $3,
+    inspected_by = $4,
+    inspected_at = now(),
+    note = COALESCE(sqlc.narg(note), note)
+WHERE id = $1
+RETURNING *;
+
+-- name: GetReturnsReport :many
+-- Completed returns per product: returned quantity by inspection outcome and the refunds paid.
+SELECT p.id AS id_from_product,
+       p.product_id,
+       p.product_name,
+       COUNT(DISTINCT r.id) AS return_count,
+       SUM(ri.quantity)::bigint AS returned_qty,
+       COALESCE(SUM(ri.quantity) FILTER (WHERE ri.outcome = 'restock'), 0)::bigint AS restocked_qty,
+       COALESCE(SUM(ri.quantity) FILTER (WHERE ri.outcome = 'damaged'), 0)::bigint AS damaged_qty,
+       COALESCE(SUM(ri.quantity) FILTER (WHERE ri.outcome = 'discard'), 0)::bigint AS discarded_qty,
+       SUM(ri.refund_idr)::bigint AS refund_idr
+FROM order_return_items ri
+JOIN order_returns r ON r.id = ri.id_from_return
+JOIN order_items i ON i.id = ri.id_from_order_item
+JOIN products p ON p.id = i.id_from_product
+WHERE r.status = 'completed'
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR r.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR r.created_at < sqlc.narg(created_to))
+GROUP BY p.id, p.product_id, p.product_name
+ORDER BY returned_qty DESC, p.product_id;
+
+-- Margin reports
+-- revenue = line totals of the order items, cogs = cogs_idr from consumed cost layers, falling back to
+-- unit_cost_idr * quantity (unit cost captured at order time).
+-- Cancelled orders are excluded; orders created before cost tracking have no unit cost and count as zero COGS.
+
+-- name: GetOrderMargins :many
+SELECT o.id,
+       o.order_number,
+       o.platform,
+       o.status,
+       o.total_amount,
+       o.total_idr AS revenue_idr,
+       COALESCE(SUM(COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity)), 0)::bigint AS cogs_idr,
+       (o.total_idr - COALESCE(SUM(COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity)), 0))::bigint AS gross_margin_idr,
+       o.created_at
+FROM orders o
+LEFT JOIN order_items i ON i.id_from_order = o.id
+WHERE lower(o.status) <> 'cancelled'
+  AND o.deleted_at IS NULL
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR o.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR o.created_at < sqlc.narg(created_to))
+GROUP BY o.id
+ORDER BY o.id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: GetMarginsByProduct :many
+SELECT i.product_id,
+       p.product_name,
+       SUM(i.quantity)::bigint AS total_sold,
+       SUM(i.line_total_idr)::bigint AS revenue_idr,
+       SUM(COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity))::bigint AS cogs_idr,
+       SUM(i.line_total_idr - COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity))::bigint AS gross_margin_idr
+FROM order_items i
+JOIN orders o ON o.id = i.id_from_order
+JOIN products p ON p.id = i.id_from_product
+WHERE lower(o.status) <> 'cancelled'
+  AND o.deleted_at IS NULL
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR o.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR o.created_at < sqlc.narg(created_to))
+GROUP BY i.product_id, p.product_name
+ORDER BY gross_margin_idr DESC;
+
+-- name: GetMarginsByPlatform :many
+SELECT o.platform,
+       COUNT(DISTINCT o.id) AS order_count,
+       COALESCE(SUM(i.line_total_idr), 0)::bigint AS revenue_idr,
+       COALESCE(SUM(COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity)), 0)::bigint AS cogs_idr,
+       COALESCE(SUM(i.line_total_idr - COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity)), 0)::bigint AS gross_margin_idr
+FROM orders o
+LEFT JOIN order_items i ON i.id_from_order = o.id
+WHERE lower(o.status) <> 'cancelled'
+  AND o.deleted_at IS NULL
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR o.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR o.created_at < sqlc.narg(created_to))
+GROUP BY o.platform
+ORDER BY gross_margin_idr DESC;
+
+-- Supplier analytics
+-- Suppliers are keyed by products.supplier_name (plus names only used on purchase orders).
+-- Only POs that went to the supplier count: lead time runs from sending the PO to its first
+-- receipt, fill rate is received / ordered quantity, and a PO with expected_at is on time when
+-- it was received in full by that date (open POs count once the date has passed).
+
+-- name: GetSupplierPerformance :many
+WITH suppliers AS (
+    SELECT DISTINCT supplier_name FROM products
+    UNION
+    SELECT DISTINCT supplier_name FROM purchase_orders
+),
+po AS (
+    SELECT po.id,
+           po.supplier_name,
+           po.status,
+           po.expected_at,
+           COALESCE(po.sent_at, po.created_at) AS ordered_at,
+           (SELECT MIN(r.received_at) FROM purchase_receipts r WHERE r.id_from_purchase_order = po.id) AS first_received_at,
+           (SELECT MAX(r.received_at) FROM purchase_receipts r WHERE r.id_from_purchase_order = po.id) AS last_received_at,
+           (SELECT COALESCE(SUM(i.quantity), 0) FROM purchase_order_items i WHERE i.id_from_purchase_order = po.id) AS ordered_qty,
+           (SELECT COALESCE(SUM(i.received_qty), 0) FROM purchase_order_items i WHERE i.id_from_purchase_order = po.id) AS received_qty
+    FROM purchase_orders po
+    WHERE po.status IN ('sent', 'partially_received', 'received')
+      AND (sqlc.narg(created_from)::timestamptz IS NULL OR po.created_at >= sqlc.narg(created_from))
+      AND (sqlc.narg(created_to)::timestamptz IS NULL OR po.created_at < sqlc.narg(created_to))
+)
+SELECT s.supplier_name,
+       (SELECT COUNT(*) FROM products p WHERE p.supplier_name = s.supplier_name) AS product_count,
+       COUNT(po.id) AS purchase_order_count,
+       COUNT(po.id) FILTER (WHERE po.status = 'received') AS received_order_count,
+       COALESCE(SUM(po.ordered_qty), 0)::bigint AS ordered_qty,
+       COALESCE(SUM(po.received_qty), 0)::bigint AS received_qty,
+       COALESCE(AVG(EXTRACT(EPOCH FROM po.first_received_at - po.ordered_at) / 86400), 0)::float8 AS avg_lead_time_days,
+       COUNT(po.id) FILTER (WHERE po.expected_at IS NOT NULL AND (po.status = 'received' OR po.expected_at < CURRENT_DATE)) AS due_order_count,
+       COUNT(po.id) FILTER (WHERE po.status = 'received' AND po.last_received_at::date <= po.expected_at) AS on_time_order_count
+FROM suppliers s
+LEFT JOIN po ON po.supplier_name = s.supplier_name
+WHERE (sqlc.narg(supplier_name)::text IS NULL OR s.supplier_name = sqlc.narg(supplier_name))
+GROUP BY s.supplier_name
+ORDER BY s.supplier_name;
+
+-- name: GetSupplierPriceTrend :many
+-- Quantity-weighted purchase cost per product and month.
+SELECT p.id AS id_from_product,
+       p.product_id,
+       p.product_name,
+       date_trunc('month', po.created_at)::timestamptz AS month,
+       SUM(i.quantity)::bigint AS quantity,
+       (SUM(i.quantity::bigint * i.unit_cost_idr) / SUM(i.quantity))::bigint AS avg_unit_cost_idr
+FROM purchase_order_items i
+JOIN purchase_orders po ON po.id = i.id_from_purchase_order
+JOIN products p ON p.id = i.id_from_product
+WHERE po.supplier_name = sqlc.arg(supplier_name)
+  AND po.status IN ('sent', 'partially_received', 'received')
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR po.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR po.created_at < sqlc.narg(created_to))
+GROUP BY p.id, p.product_id, p.product_name, month
+ORDER BY p.product_id, month;
+
 -- Utility queries
 
 -- name: NextProductSequence :one
 -- This is a helper to get a next sequence number for product id generation if you prefer DB-side sequence.
-SELECT nextval('products_id_seq') as seq;
\ No newline at end of file
+SELECT nextval('products_id_seq') as seq;
+
+-- Idempotency Keys
+
+-- name: ClaimIdempotencyKey :execrows
+-- Claims a key for a new request. Affects no row when the key is already taken and not expired,
+-- unless the request that claimed it never finished and its lock ran out.
+INSERT INTO idempotency_keys (idempotency_key, method, path, caller, request_hash, expires_at, locked_until)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (idempotency_key, method, path, caller) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    status_code = NULL,
+    content_type = NULL,
+    response_body = NULL,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at,
+    locked_until = EXCLUDED.locked_until
+WHERE idempotency_keys.expires_at <= now()
+   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= now());
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE idempotency_key = $1 AND method = $2 AND path = $3 AND caller = $4;
+
+-- name: SaveIdempotencyResponse :exec
+UPDATE idempotency_keys
+SET status_code = $5,
+    content_type = $6,
+    response_body = $7,
+    locked_until = NULL
+WHERE idempotency_key = $1 AND method = $2 AND path = $3 AND caller = $4;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = $1 AND method = $2 AND path = $3 AND caller = $4;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at <= now();
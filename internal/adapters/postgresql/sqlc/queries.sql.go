@@ -11,51 +11,428 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const adjustWarehouseStock = `-- name: AdjustWarehouseStock :one
+INSERT INTO warehouse_stock (id_from_warehouse, id_from_product, quantity)
+VALUES ($1, $2, $3)
+ON CONFLICT (id_from_warehouse, id_from_product)
+DO UPDATE SET quantity = warehouse_stock.quantity + EXCLUDED.quantity,
+              updated_at = now()
+RETURNING id_from_warehouse, id_from_product, quantity, updated_at
+`
+
+type AdjustWarehouseStockParams struct {
+	IDFromWarehouse int32 `json:"id_from_warehouse"`
+	IDFromProduct   int32 `json:"id_from_product"`
+	Quantity        int32 `json:"quantity"`
+}
+
+// Adds quantity (may be negative) to the product's balance at the location; the CHECK on
+// warehouse_stock.quantity rejects changes that would go below zero.
+func (q *Queries) AdjustWarehouseStock(ctx context.Context, arg AdjustWarehouseStockParams) (WarehouseStock, error) {
+	row := q.db.QueryRow(ctx, adjustWarehouseStock, arg.IDFromWarehouse, arg.IDFromProduct, arg.Quantity)
+	var i WarehouseStock
+	err := row.Scan(
+		&i.IDFromWarehouse,
+		&i.IDFromProduct,
+		&i.Quantity,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :execrows
+
+INSERT INTO idempotency_keys (idempotency_key, method, path, caller, request_hash, expires_at, locked_until)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (idempotency_key, method, path, caller) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    status_code = NULL,
+    content_type = NULL,
+    response_body = NULL,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at,
+    locked_until = EXCLUDED.locked_until
+WHERE idempotency_keys.expires_at <= now()
+   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= now())
+`
+
+type ClaimIdempotencyKeyParams struct {
+	IdempotencyKey string             `json:"idempotency_key"`
+	Method         string             `json:"method"`
+	Path           string             `json:"path"`
+	Caller         string             `json:"caller"`
+	RequestHash    string             `json:"request_hash"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	LockedUntil    pgtype.Timestamptz `json:"locked_until"`
+}
+
+// Idempotency Keys
+// Claims a key for a new request. Affects no row when the key is already taken and not expired,
+// unless the request that claimed it never finished and its lock ran out.
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimIdempotencyKey,
+		arg.IdempotencyKey,
+		arg.Method,
+		arg.Path,
+		arg.Caller,
+		arg.RequestHash,
+		arg.ExpiresAt,
+		arg.LockedUntil,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const closeOrderReservations = `-- name: CloseOrderReservations :many
+UPDATE stock_reservations
+SET status = $2,
+    updated_at = now()
+WHERE id_from_order = $1 AND status = 'active'
+RETURNING id, id_from_order, id_from_product, id_from_warehouse, quantity, status, created_at, updated_at, id_from_variant
+`
+
+type CloseOrderReservationsParams struct {
+	IDFromOrder int32  `json:"id_from_order"`
+	Status      string `json:"status"`
+}
+
+// Moves the order's active reservations to fulfilled (shipped) or released (cancelled).
+func (q *Queries) CloseOrderReservations(ctx context.Context, arg CloseOrderReservationsParams) ([]StockReservation, error) {
+	rows, err := q.db.Query(ctx, closeOrderReservations, arg.IDFromOrder, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockReservation
+	for rows.Next() {
+		var i StockReservation
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromOrder,
+			&i.IDFromProduct,
+			&i.IDFromWarehouse,
+			&i.Quantity,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IDFromVariant,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const closeOrderReturn = `-- name: CloseOrderReturn :one
+UPDATE order_returns
+SET status = $2,
+    refund_idr = $3,
+    inspected_by = $4,
+    inspected_at = now(),
+    note = COALESCE($5, note)
+WHERE id = $1
+RETURNING id, id_from_order, status, reason, id_from_warehouse, refund_idr, note, requested_by, inspected_by, created_at, inspected_at
+`
+
+type CloseOrderReturnParams struct {
+	ID          int32       `json:"id"`
+	Status      string      `json:"status"`
+	RefundIdr   int64       `json:"refund_idr"`
+	InspectedBy pgtype.Int4 `json:"inspected_by"`
+	Note        pgtype.Text `json:"note"`
+}
+
+func (q *Queries) CloseOrderReturn(ctx context.Context, arg CloseOrderReturnParams) (OrderReturn, error) {
+	row := q.db.QueryRow(ctx, closeOrderReturn,
+		arg.ID,
+		arg.Status,
+		arg.RefundIdr,
+		arg.InspectedBy,
+		arg.Note,
+	)
+	var i OrderReturn
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromOrder,
+		&i.Status,
+		&i.Reason,
+		&i.IDFromWarehouse,
+		&i.RefundIdr,
+		&i.Note,
+		&i.RequestedBy,
+		&i.InspectedBy,
+		&i.CreatedAt,
+		&i.InspectedAt,
+	)
+	return i, err
+}
+
+const completeStockTake = `-- name: CompleteStockTake :one
+UPDATE stock_takes
+SET status = $2,
+    approved_by = $3,
+    completed_at = now()
+WHERE id = $1
+RETURNING id, id_from_warehouse, status, note, created_by, approved_by, created_at, completed_at
+`
+
+type CompleteStockTakeParams struct {
+	ID         int32       `json:"id"`
+	Status     string      `json:"status"`
+	ApprovedBy pgtype.Int4 `json:"approved_by"`
+}
+
+func (q *Queries) CompleteStockTake(ctx context.Context, arg CompleteStockTakeParams) (StockTake, error) {
+	row := q.db.QueryRow(ctx, completeStockTake, arg.ID, arg.Status, arg.ApprovedBy)
+	var i StockTake
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromWarehouse,
+		&i.Status,
+		&i.Note,
+		&i.CreatedBy,
+		&i.ApprovedBy,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const completeStockTransfer = `-- name: CompleteStockTransfer :one
+UPDATE stock_transfers
+SET status = $2,
+    completed_at = now()
+WHERE id = $1
+RETURNING id, id_from_source, id_from_destination, status, note, created_at, completed_at
+`
+
+type CompleteStockTransferParams struct {
+	ID     int32  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) CompleteStockTransfer(ctx context.Context, arg CompleteStockTransferParams) (StockTransfer, error) {
+	row := q.db.QueryRow(ctx, completeStockTransfer, arg.ID, arg.Status)
+	var i StockTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromSource,
+		&i.IDFromDestination,
+		&i.Status,
+		&i.Note,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const consumeCostLayer = `-- name: ConsumeCostLayer :exec
+UPDATE cost_layers
+SET quantity_remaining = quantity_remaining - $2
+WHERE id = $1
+`
+
+type ConsumeCostLayerParams struct {
+	ID                int32 `json:"id"`
+	QuantityRemaining int32 `json:"quantity_remaining"`
+}
+
+func (q *Queries) ConsumeCostLayer(ctx context.Context, arg ConsumeCostLayerParams) error {
+	_, err := q.db.Exec(ctx, consumeCostLayer, arg.ID, arg.QuantityRemaining)
+	return err
+}
+
+const countBundlesWithComponent = `-- name: CountBundlesWithComponent :one
+SELECT COUNT(*) FROM bundle_components
+WHERE id_from_component = $1
+`
+
+func (q *Queries) CountBundlesWithComponent(ctx context.Context, idFromComponent int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countBundlesWithComponent, idFromComponent)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countImagesByProduct = `-- name: CountImagesByProduct :one
+SELECT COUNT(*) FROM product_images
+WHERE id_from_product = $1
+`
+
+func (q *Queries) CountImagesByProduct(ctx context.Context, idFromProduct int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countImagesByProduct, idFromProduct)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createBundleComponent = `-- name: CreateBundleComponent :one
+
+INSERT INTO bundle_components (id_from_bundle, id_from_component, quantity)
+VALUES ($1, $2, $3)
+RETURNING id_from_bundle, id_from_component, quantity
+`
+
+type CreateBundleComponentParams struct {
+	IDFromBundle    int32 `json:"id_from_bundle"`
+	IDFromComponent int32 `json:"id_from_component"`
+	Quantity        int32 `json:"quantity"`
+}
+
+// Bundle Components
+func (q *Queries) CreateBundleComponent(ctx context.Context, arg CreateBundleComponentParams) (BundleComponent, error) {
+	row := q.db.QueryRow(ctx, createBundleComponent, arg.IDFromBundle, arg.IDFromComponent, arg.Quantity)
+	var i BundleComponent
+	err := row.Scan(&i.IDFromBundle, &i.IDFromComponent, &i.Quantity)
+	return i, err
+}
+
+const createCostLayer = `-- name: CreateCostLayer :one
+
+INSERT INTO cost_layers (id_from_product, quantity, quantity_remaining, unit_cost_idr, received_at, reference)
+VALUES ($1, $2, $2, $3, $4, $5)
+RETURNING id, id_from_product, quantity, quantity_remaining, unit_cost_idr, received_at, reference, created_at
+`
+
+type CreateCostLayerParams struct {
+	IDFromProduct int32              `json:"id_from_product"`
+	Quantity      int32              `json:"quantity"`
+	UnitCostIdr   int64              `json:"unit_cost_idr"`
+	ReceivedAt    pgtype.Timestamptz `json:"received_at"`
+	Reference     pgtype.Text        `json:"reference"`
+}
+
+// Cost Layers
+func (q *Queries) CreateCostLayer(ctx context.Context, arg CreateCostLayerParams) (CostLayer, error) {
+	row := q.db.QueryRow(ctx, createCostLayer,
+		arg.IDFromProduct,
+		arg.Quantity,
+		arg.UnitCostIdr,
+		arg.ReceivedAt,
+		arg.Reference,
+	)
+	var i CostLayer
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.Quantity,
+		&i.QuantityRemaining,
+		&i.UnitCostIdr,
+		&i.ReceivedAt,
+		&i.Reference,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createCostLayerConsumption = `-- name: CreateCostLayerConsumption :one
+INSERT INTO cost_layer_consumptions (id_from_layer, id_from_order, quantity, unit_cost_idr, reference)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, id_from_layer, id_from_order, quantity, unit_cost_idr, reference, created_at
+`
+
+type CreateCostLayerConsumptionParams struct {
+	IDFromLayer int32       `json:"id_from_layer"`
+	IDFromOrder pgtype.Int4 `json:"id_from_order"`
+	Quantity    int32       `json:"quantity"`
+	UnitCostIdr int64       `json:"unit_cost_idr"`
+	Reference   pgtype.Text `json:"reference"`
+}
+
+func (q *Queries) CreateCostLayerConsumption(ctx context.Context, arg CreateCostLayerConsumptionParams) (CostLayerConsumption, error) {
+	row := q.db.QueryRow(ctx, createCostLayerConsumption,
+		arg.IDFromLayer,
+		arg.IDFromOrder,
+		arg.Quantity,
+		arg.UnitCostIdr,
+		arg.Reference,
+	)
+	var i CostLayerConsumption
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromLayer,
+		&i.IDFromOrder,
+		&i.Quantity,
+		&i.UnitCostIdr,
+		&i.Reference,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createLotAllocation = `-- name: CreateLotAllocation :one
+INSERT INTO lot_allocations (id_from_lot, id_from_order, quantity)
+VALUES ($1, $2, $3)
+RETURNING id, id_from_lot, id_from_order, quantity, created_at
+`
+
+type CreateLotAllocationParams struct {
+	IDFromLot   int32 `json:"id_from_lot"`
+	IDFromOrder int32 `json:"id_from_order"`
+	Quantity    int32 `json:"quantity"`
+}
+
+func (q *Queries) CreateLotAllocation(ctx context.Context, arg CreateLotAllocationParams) (LotAllocation, error) {
+	row := q.db.QueryRow(ctx, createLotAllocation, arg.IDFromLot, arg.IDFromOrder, arg.Quantity)
+	var i LotAllocation
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromLot,
+		&i.IDFromOrder,
+		&i.Quantity,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createOrder = `-- name: CreateOrder :one
 
 INSERT INTO orders (
     order_number,
-    id_from_product,
-    product_id,
     customer_name,
     platform,
     destination,
     total_amount,
+    total_idr,
     status,
-    price_idr,
-    created_at
+    created_at,
+    id_from_warehouse
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, id_from_product, product_id, price_idr
+RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by
 `
 
 type CreateOrderParams struct {
-	OrderNumber   string             `json:"order_number"`
-	IDFromProduct pgtype.Int4        `json:"id_from_product"`
-	ProductID     pgtype.Text        `json:"product_id"`
-	CustomerName  string             `json:"customer_name"`
-	Platform      string             `json:"platform"`
-	Destination   string             `json:"destination"`
-	TotalAmount   pgtype.Int4        `json:"total_amount"`
-	Status        string             `json:"status"`
-	PriceIdr      pgtype.Int4        `json:"price_idr"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	OrderNumber     string             `json:"order_number"`
+	CustomerName    string             `json:"customer_name"`
+	Platform        string             `json:"platform"`
+	Destination     string             `json:"destination"`
+	TotalAmount     pgtype.Int4        `json:"total_amount"`
+	TotalIdr        int64              `json:"total_idr"`
+	Status          string             `json:"status"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	IDFromWarehouse pgtype.Int4        `json:"id_from_warehouse"`
 }
 
 // Orders
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
 	row := q.db.QueryRow(ctx, createOrder,
 		arg.OrderNumber,
-		arg.IDFromProduct,
-		arg.ProductID,
 		arg.CustomerName,
 		arg.Platform,
 		arg.Destination,
 		arg.TotalAmount,
+		arg.TotalIdr,
 		arg.Status,
-		arg.PriceIdr,
 		arg.CreatedAt,
+		arg.IDFromWarehouse,
 	)
 	var i Order
 	err := row.Scan(
@@ -68,18 +445,189 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.Destination,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const createOrderItem = `-- name: CreateOrderItem :one
+INSERT INTO order_items (
+    id_from_order,
+    id_from_product,
+    id_from_variant,
+    product_id,
+    quantity,
+    unit_price_idr,
+    line_total_idr,
+    unit_cost_idr,
+    cogs_idr
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING id, id_from_order, id_from_product, id_from_variant, product_id, quantity, unit_price_idr, line_total_idr, unit_cost_idr, cogs_idr
+`
+
+type CreateOrderItemParams struct {
+	IDFromOrder   int32       `json:"id_from_order"`
+	IDFromProduct pgtype.Int4 `json:"id_from_product"`
+	IDFromVariant pgtype.Int4 `json:"id_from_variant"`
+	ProductID     string      `json:"product_id"`
+	Quantity      int32       `json:"quantity"`
+	UnitPriceIdr  int64       `json:"unit_price_idr"`
+	LineTotalIdr  int64       `json:"line_total_idr"`
+	UnitCostIdr   int64       `json:"unit_cost_idr"`
+	CogsIdr       pgtype.Int8 `json:"cogs_idr"`
+}
+
+func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error) {
+	row := q.db.QueryRow(ctx, createOrderItem,
+		arg.IDFromOrder,
+		arg.IDFromProduct,
+		arg.IDFromVariant,
+		arg.ProductID,
+		arg.Quantity,
+		arg.UnitPriceIdr,
+		arg.LineTotalIdr,
+		arg.UnitCostIdr,
+		arg.CogsIdr,
+	)
+	var i OrderItem
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromOrder,
 		&i.IDFromProduct,
+		&i.IDFromVariant,
 		&i.ProductID,
-		&i.PriceIdr,
+		&i.Quantity,
+		&i.UnitPriceIdr,
+		&i.LineTotalIdr,
+		&i.UnitCostIdr,
+		&i.CogsIdr,
+	)
+	return i, err
+}
+
+const createOrderReturn = `-- name: CreateOrderReturn :one
+
+INSERT INTO order_returns (id_from_order, reason, id_from_warehouse, note, requested_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, id_from_order, status, reason, id_from_warehouse, refund_idr, note, requested_by, inspected_by, created_at, inspected_at
+`
+
+type CreateOrderReturnParams struct {
+	IDFromOrder     int32       `json:"id_from_order"`
+	Reason          string      `json:"reason"`
+	IDFromWarehouse int32       `json:"id_from_warehouse"`
+	Note            pgtype.Text `json:"note"`
+	RequestedBy     pgtype.Int4 `json:"requested_by"`
+}
+
+// Order returns (RMA)
+func (q *Queries) CreateOrderReturn(ctx context.Context, arg CreateOrderReturnParams) (OrderReturn, error) {
+	row := q.db.QueryRow(ctx, createOrderReturn,
+		arg.IDFromOrder,
+		arg.Reason,
+		arg.IDFromWarehouse,
+		arg.Note,
+		arg.RequestedBy,
+	)
+	var i OrderReturn
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromOrder,
+		&i.Status,
+		&i.Reason,
+		&i.IDFromWarehouse,
+		&i.RefundIdr,
+		&i.Note,
+		&i.RequestedBy,
+		&i.InspectedBy,
+		&i.CreatedAt,
+		&i.InspectedAt,
+	)
+	return i, err
+}
+
+const createOrderReturnItem = `-- name: CreateOrderReturnItem :one
+INSERT INTO order_return_items (id_from_return, id_from_order_item, quantity, serial_numbers)
+VALUES ($1, $2, $3, $4)
+RETURNING id, id_from_return, id_from_order_item, quantity, serial_numbers, outcome, refund_idr, note
+`
+
+type CreateOrderReturnItemParams struct {
+	IDFromReturn    int32    `json:"id_from_return"`
+	IDFromOrderItem int32    `json:"id_from_order_item"`
+	Quantity        int32    `json:"quantity"`
+	SerialNumbers   []string `json:"serial_numbers"`
+}
+
+func (q *Queries) CreateOrderReturnItem(ctx context.Context, arg CreateOrderReturnItemParams) (OrderReturnItem, error) {
+	row := q.db.QueryRow(ctx, createOrderReturnItem,
+		arg.IDFromReturn,
+		arg.IDFromOrderItem,
+		arg.Quantity,
+		arg.SerialNumbers,
+	)
+	var i OrderReturnItem
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromReturn,
+		&i.IDFromOrderItem,
+		&i.Quantity,
+		&i.SerialNumbers,
+		&i.Outcome,
+		&i.RefundIdr,
+		&i.Note,
+	)
+	return i, err
+}
+
+const createOrderStatusHistory = `-- name: CreateOrderStatusHistory :one
+INSERT INTO order_status_history (id_from_order, from_status, to_status, changed_by, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, id_from_order, from_status, to_status, changed_by, note, created_at
+`
+
+type CreateOrderStatusHistoryParams struct {
+	IDFromOrder int32       `json:"id_from_order"`
+	FromStatus  pgtype.Text `json:"from_status"`
+	ToStatus    string      `json:"to_status"`
+	ChangedBy   pgtype.Int4 `json:"changed_by"`
+	Note        pgtype.Text `json:"note"`
+}
+
+func (q *Queries) CreateOrderStatusHistory(ctx context.Context, arg CreateOrderStatusHistoryParams) (OrderStatusHistory, error) {
+	row := q.db.QueryRow(ctx, createOrderStatusHistory,
+		arg.IDFromOrder,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.ChangedBy,
+		arg.Note,
+	)
+	var i OrderStatusHistory
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromOrder,
+		&i.FromStatus,
+		&i.ToStatus,
+		&i.ChangedBy,
+		&i.Note,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const createProduct = `-- name: CreateProduct :one
 
-INSERT INTO products (product_id, product_name, supplier_name, category, price_idr, stock)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at
+INSERT INTO products (product_id, product_name, supplier_name, category, price_idr, stock, cost_idr, track_serials, base_unit)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
 `
 
 type CreateProductParams struct {
@@ -89,6 +637,9 @@ type CreateProductParams struct {
 	Category     string `json:"category"`
 	PriceIdr     int64  `json:"price_idr"`
 	Stock        int32  `json:"stock"`
+	CostIdr      int64  `json:"cost_idr"`
+	TrackSerials bool   `json:"track_serials"`
+	BaseUnit     string `json:"base_unit"`
 }
 
 // Products
@@ -100,6 +651,9 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.Category,
 		arg.PriceIdr,
 		arg.Stock,
+		arg.CostIdr,
+		arg.TrackSerials,
+		arg.BaseUnit,
 	)
 	var i Product
 	err := row.Scan(
@@ -112,352 +666,3887 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
 	)
 	return i, err
 }
 
-const createUser = `-- name: CreateUser :one
-
+const createProductBarcode = `-- name: CreateProductBarcode :one
 
-INSERT INTO users (user_id, username, email, full_name, password_hash, role, permissions)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, user_id, username, email, full_name, role, permissions, created_at, updated_at
+INSERT INTO product_barcodes (id_from_product, barcode)
+VALUES ($1, $2)
+RETURNING id, id_from_product, barcode, created_at
 `
 
-type CreateUserParams struct {
-	UserID       string `json:"user_id"`
-	Username     string `json:"username"`
-	Email        string `json:"email"`
-	FullName     string `json:"full_name"`
-	PasswordHash string `json:"password_hash"`
-	Role         string `json:"role"`
-	Permissions  []byte `json:"permissions"`
-}
-
-type CreateUserRow struct {
-	ID          int32            `json:"id"`
-	UserID      string           `json:"user_id"`
-	Username    string           `json:"username"`
-	Email       string           `json:"email"`
-	FullName    string           `json:"full_name"`
-	Role        string           `json:"role"`
-	Permissions []byte           `json:"permissions"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+type CreateProductBarcodeParams struct {
+	IDFromProduct int32  `json:"id_from_product"`
+	Barcode       string `json:"barcode"`
 }
 
-// internal/adapters/postgresql/sqlc/queries.sql
-// Users
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
-	row := q.db.QueryRow(ctx, createUser,
-		arg.UserID,
-		arg.Username,
-		arg.Email,
-		arg.FullName,
-		arg.PasswordHash,
-		arg.Role,
-		arg.Permissions,
-	)
-	var i CreateUserRow
+// Product Barcodes
+func (q *Queries) CreateProductBarcode(ctx context.Context, arg CreateProductBarcodeParams) (ProductBarcode, error) {
+	row := q.db.QueryRow(ctx, createProductBarcode, arg.IDFromProduct, arg.Barcode)
+	var i ProductBarcode
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
-		&i.Username,
-		&i.Email,
-		&i.FullName,
-		&i.Role,
-		&i.Permissions,
+		&i.IDFromProduct,
+		&i.Barcode,
 		&i.CreatedAt,
-		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const deleteOrder = `-- name: DeleteOrder :exec
-DELETE FROM orders
-WHERE id = $1
+const createProductCost = `-- name: CreateProductCost :one
+
+INSERT INTO product_costs (id_from_product, cost_idr, note)
+VALUES ($1, $2, $3)
+RETURNING id, id_from_product, cost_idr, note, created_at
 `
 
-func (q *Queries) DeleteOrder(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteOrder, id)
-	return err
+type CreateProductCostParams struct {
+	IDFromProduct int32       `json:"id_from_product"`
+	CostIdr       int64       `json:"cost_idr"`
+	Note          pgtype.Text `json:"note"`
 }
 
-const getLastOrderNumber = `-- name: GetLastOrderNumber :one
-SELECT order_number
-FROM orders
-ORDER BY id DESC
-LIMIT 1
+// Product Costs
+func (q *Queries) CreateProductCost(ctx context.Context, arg CreateProductCostParams) (ProductCost, error) {
+	row := q.db.QueryRow(ctx, createProductCost, arg.IDFromProduct, arg.CostIdr, arg.Note)
+	var i ProductCost
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.CostIdr,
+		&i.Note,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createProductImage = `-- name: CreateProductImage :one
+
+INSERT INTO product_images (id_from_product, storage_key, thumbnail_key, content_type, size_bytes, width, height, sort_order)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, id_from_product, storage_key, thumbnail_key, content_type, size_bytes, width, height, sort_order, created_at
 `
 
-func (q *Queries) GetLastOrderNumber(ctx context.Context) (string, error) {
-	row := q.db.QueryRow(ctx, getLastOrderNumber)
-	var order_number string
-	err := row.Scan(&order_number)
-	return order_number, err
+type CreateProductImageParams struct {
+	IDFromProduct int32  `json:"id_from_product"`
+	StorageKey    string `json:"storage_key"`
+	ThumbnailKey  string `json:"thumbnail_key"`
+	ContentType   string `json:"content_type"`
+	SizeBytes     int64  `json:"size_bytes"`
+	Width         int32  `json:"width"`
+	Height        int32  `json:"height"`
+	SortOrder     int32  `json:"sort_order"`
 }
 
-const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, id_from_product, product_id, price_idr FROM orders
-WHERE id = $1
+// Product Images
+func (q *Queries) CreateProductImage(ctx context.Context, arg CreateProductImageParams) (ProductImage, error) {
+	row := q.db.QueryRow(ctx, createProductImage,
+		arg.IDFromProduct,
+		arg.StorageKey,
+		arg.ThumbnailKey,
+		arg.ContentType,
+		arg.SizeBytes,
+		arg.Width,
+		arg.Height,
+		arg.SortOrder,
+	)
+	var i ProductImage
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Width,
+		&i.Height,
+		&i.SortOrder,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createProductPrice = `-- name: CreateProductPrice :one
+
+INSERT INTO product_prices (id_from_product, price_idr, effective_at, applied_at, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, id_from_product, price_idr, effective_at, applied_at, note, created_at
 `
 
-func (q *Queries) GetOrderByID(ctx context.Context, id int32) (Order, error) {
-	row := q.db.QueryRow(ctx, getOrderByID, id)
-	var i Order
+type CreateProductPriceParams struct {
+	IDFromProduct int32              `json:"id_from_product"`
+	PriceIdr      int64              `json:"price_idr"`
+	EffectiveAt   pgtype.Timestamptz `json:"effective_at"`
+	AppliedAt     pgtype.Timestamptz `json:"applied_at"`
+	Note          pgtype.Text        `json:"note"`
+}
+
+// Product Prices
+func (q *Queries) CreateProductPrice(ctx context.Context, arg CreateProductPriceParams) (ProductPrice, error) {
+	row := q.db.QueryRow(ctx, createProductPrice,
+		arg.IDFromProduct,
+		arg.PriceIdr,
+		arg.EffectiveAt,
+		arg.AppliedAt,
+		arg.Note,
+	)
+	var i ProductPrice
 	err := row.Scan(
 		&i.ID,
-		&i.OrderNumber,
-		&i.CustomerName,
-		&i.TotalAmount,
-		&i.Status,
-		&i.Platform,
-		&i.Destination,
-		&i.CreatedAt,
-		&i.UpdatedAt,
 		&i.IDFromProduct,
-		&i.ProductID,
 		&i.PriceIdr,
+		&i.EffectiveAt,
+		&i.AppliedAt,
+		&i.Note,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getPermissionsByID = `-- name: GetPermissionsByID :one
-SELECT permissions
-FROM users
-WHERE id = $1
+const createProductUnit = `-- name: CreateProductUnit :one
+
+INSERT INTO product_units (id_from_product, unit_name, factor)
+VALUES ($1, $2, $3)
+RETURNING id, id_from_product, unit_name, factor, created_at
 `
 
-func (q *Queries) GetPermissionsByID(ctx context.Context, id int32) ([]byte, error) {
-	row := q.db.QueryRow(ctx, getPermissionsByID, id)
-	var permissions []byte
-	err := row.Scan(&permissions)
-	return permissions, err
+type CreateProductUnitParams struct {
+	IDFromProduct int32  `json:"id_from_product"`
+	UnitName      string `json:"unit_name"`
+	Factor        int32  `json:"factor"`
 }
 
-const getProductByID = `-- name: GetProductByID :one
-SELECT
-  id,
-  product_id,
-  product_name,
-  supplier_name,
-  category,
-  price_idr,
-  stock,
-  created_at,
-  updated_at
-FROM products
-WHERE id = $1
-LIMIT 1
+// Product Units
+func (q *Queries) CreateProductUnit(ctx context.Context, arg CreateProductUnitParams) (ProductUnit, error) {
+	row := q.db.QueryRow(ctx, createProductUnit, arg.IDFromProduct, arg.UnitName, arg.Factor)
+	var i ProductUnit
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.UnitName,
+		&i.Factor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createProductVariant = `-- name: CreateProductVariant :one
+
+INSERT INTO product_variants (id_from_product, product_id, variant_name, price_idr, stock, attributes)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, id_from_product, product_id, variant_name, price_idr, stock, attributes, created_at, updated_at
 `
 
-func (q *Queries) GetProductByID(ctx context.Context, id int32) (Product, error) {
-	row := q.db.QueryRow(ctx, getProductByID, id)
-	var i Product
+type CreateProductVariantParams struct {
+	IDFromProduct int32       `json:"id_from_product"`
+	ProductID     string      `json:"product_id"`
+	VariantName   string      `json:"variant_name"`
+	PriceIdr      pgtype.Int8 `json:"price_idr"`
+	Stock         int32       `json:"stock"`
+	Attributes    []byte      `json:"attributes"`
+}
+
+// Product Variants
+func (q *Queries) CreateProductVariant(ctx context.Context, arg CreateProductVariantParams) (ProductVariant, error) {
+	row := q.db.QueryRow(ctx, createProductVariant,
+		arg.IDFromProduct,
+		arg.ProductID,
+		arg.VariantName,
+		arg.PriceIdr,
+		arg.Stock,
+		arg.Attributes,
+	)
+	var i ProductVariant
 	err := row.Scan(
 		&i.ID,
+		&i.IDFromProduct,
 		&i.ProductID,
-		&i.ProductName,
-		&i.SupplierName,
-		&i.Category,
+		&i.VariantName,
 		&i.PriceIdr,
 		&i.Stock,
+		&i.Attributes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getTopProductsFromOrders = `-- name: GetTopProductsFromOrders :many
-SELECT o.product_id,
-       p.product_name,
-       SUM(o.total_amount) AS total_sold
-FROM orders o
-JOIN products p ON o.product_id = p.product_id
-GROUP BY o.product_id, p.product_name
-ORDER BY total_sold DESC
-LIMIT 5
+const createPurchaseOrder = `-- name: CreatePurchaseOrder :one
+
+INSERT INTO purchase_orders (supplier_name, id_from_warehouse, note, expected_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, po_number, supplier_name, id_from_warehouse, status, note, expected_at, sent_at, closed_at, created_at, updated_at
 `
 
-type GetTopProductsFromOrdersRow struct {
-	ProductID   pgtype.Text `json:"product_id"`
-	ProductName string      `json:"product_name"`
-	TotalSold   int64       `json:"total_sold"`
+type CreatePurchaseOrderParams struct {
+	SupplierName    string      `json:"supplier_name"`
+	IDFromWarehouse int32       `json:"id_from_warehouse"`
+	Note            pgtype.Text `json:"note"`
+	ExpectedAt      pgtype.Date `json:"expected_at"`
 }
 
-func (q *Queries) GetTopProductsFromOrders(ctx context.Context) ([]GetTopProductsFromOrdersRow, error) {
-	rows, err := q.db.Query(ctx, getTopProductsFromOrders)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetTopProductsFromOrdersRow
-	for rows.Next() {
-		var i GetTopProductsFromOrdersRow
-		if err := rows.Scan(&i.ProductID, &i.ProductName, &i.TotalSold); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+// Purchase Orders
+func (q *Queries) CreatePurchaseOrder(ctx context.Context, arg CreatePurchaseOrderParams) (PurchaseOrder, error) {
+	row := q.db.QueryRow(ctx, createPurchaseOrder,
+		arg.SupplierName,
+		arg.IDFromWarehouse,
+		arg.Note,
+		arg.ExpectedAt,
+	)
+	var i PurchaseOrder
+	err := row.Scan(
+		&i.ID,
+		&i.PoNumber,
+		&i.SupplierName,
+		&i.IDFromWarehouse,
+		&i.Status,
+		&i.Note,
+		&i.ExpectedAt,
+		&i.SentAt,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-const getUserByUsernameOrEmail = `-- name: GetUserByUsernameOrEmail :one
-SELECT id, user_id, username, email, full_name, password_hash, role, created_at, updated_at
-FROM users
-WHERE username = $1 OR email = $2
-LIMIT 1
+const createPurchaseOrderItem = `-- name: CreatePurchaseOrderItem :one
+INSERT INTO purchase_order_items (id_from_purchase_order, id_from_product, quantity, unit_cost_idr)
+VALUES ($1, $2, $3, $4)
+RETURNING id, id_from_purchase_order, id_from_product, quantity, unit_cost_idr, received_qty
 `
 
-type GetUserByUsernameOrEmailParams struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
+type CreatePurchaseOrderItemParams struct {
+	IDFromPurchaseOrder int32 `json:"id_from_purchase_order"`
+	IDFromProduct       int32 `json:"id_from_product"`
+	Quantity            int32 `json:"quantity"`
+	UnitCostIdr         int64 `json:"unit_cost_idr"`
 }
 
-type GetUserByUsernameOrEmailRow struct {
-	ID           int32            `json:"id"`
-	UserID       string           `json:"user_id"`
-	Username     string           `json:"username"`
-	Email        string           `json:"email"`
-	FullName     string           `json:"full_name"`
-	PasswordHash string           `json:"password_hash"`
-	Role         string           `json:"role"`
-	CreatedAt    pgtype.Timestamp `json:"created_at"`
-	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+func (q *Queries) CreatePurchaseOrderItem(ctx context.Context, arg CreatePurchaseOrderItemParams) (PurchaseOrderItem, error) {
+	row := q.db.QueryRow(ctx, createPurchaseOrderItem,
+		arg.IDFromPurchaseOrder,
+		arg.IDFromProduct,
+		arg.Quantity,
+		arg.UnitCostIdr,
+	)
+	var i PurchaseOrderItem
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromPurchaseOrder,
+		&i.IDFromProduct,
+		&i.Quantity,
+		&i.UnitCostIdr,
+		&i.ReceivedQty,
+	)
+	return i, err
 }
 
-func (q *Queries) GetUserByUsernameOrEmail(ctx context.Context, arg GetUserByUsernameOrEmailParams) (GetUserByUsernameOrEmailRow, error) {
-	row := q.db.QueryRow(ctx, getUserByUsernameOrEmail, arg.Username, arg.Email)
-	var i GetUserByUsernameOrEmailRow
+const createPurchaseReceipt = `-- name: CreatePurchaseReceipt :one
+INSERT INTO purchase_receipts (id_from_purchase_order, reference, received_at)
+VALUES ($1, $2, $3)
+RETURNING id, id_from_purchase_order, reference, received_at
+`
+
+type CreatePurchaseReceiptParams struct {
+	IDFromPurchaseOrder int32              `json:"id_from_purchase_order"`
+	Reference           pgtype.Text        `json:"reference"`
+	ReceivedAt          pgtype.Timestamptz `json:"received_at"`
+}
+
+func (q *Queries) CreatePurchaseReceipt(ctx context.Context, arg CreatePurchaseReceiptParams) (PurchaseReceipt, error) {
+	row := q.db.QueryRow(ctx, createPurchaseReceipt, arg.IDFromPurchaseOrder, arg.Reference, arg.ReceivedAt)
+	var i PurchaseReceipt
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
-		&i.Username,
-		&i.Email,
-		&i.FullName,
-		&i.PasswordHash,
-		&i.Role,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.IDFromPurchaseOrder,
+		&i.Reference,
+		&i.ReceivedAt,
 	)
 	return i, err
 }
 
-const listOrdersWithProduct = `-- name: ListOrdersWithProduct :many
-SELECT
-  o.id,
-  o.order_number,
-  o.id_from_product,       -- FK ke products.id
-  o.product_id,            -- kode produk (text)
-  o.customer_name,
-  o.total_amount,
-  o.status,
-  o.platform,
-  o.destination,
-  o.price_idr,             -- harga produk
-  o.created_at,
-  p.product_name
-FROM orders o
-JOIN products p ON o.id_from_product = p.id
-ORDER BY o.id DESC
-LIMIT $1 OFFSET $2
+const createPurchaseReceiptItem = `-- name: CreatePurchaseReceiptItem :one
+INSERT INTO purchase_receipt_items (id_from_receipt, id_from_purchase_order_item, quantity, lot_number)
+VALUES ($1, $2, $3, $4)
+RETURNING id, id_from_receipt, id_from_purchase_order_item, quantity, lot_number
 `
 
-type ListOrdersWithProductParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type CreatePurchaseReceiptItemParams struct {
+	IDFromReceipt           int32       `json:"id_from_receipt"`
+	IDFromPurchaseOrderItem int32       `json:"id_from_purchase_order_item"`
+	Quantity                int32       `json:"quantity"`
+	LotNumber               pgtype.Text `json:"lot_number"`
 }
 
-type ListOrdersWithProductRow struct {
-	ID            int32              `json:"id"`
-	OrderNumber   string             `json:"order_number"`
-	IDFromProduct pgtype.Int4        `json:"id_from_product"`
-	ProductID     pgtype.Text        `json:"product_id"`
-	CustomerName  string             `json:"customer_name"`
-	TotalAmount   pgtype.Int4        `json:"total_amount"`
-	Status        string             `json:"status"`
-	Platform      string             `json:"platform"`
-	Destination   string             `json:"destination"`
-	PriceIdr      pgtype.Int4        `json:"price_idr"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	ProductName   string             `json:"product_name"`
+func (q *Queries) CreatePurchaseReceiptItem(ctx context.Context, arg CreatePurchaseReceiptItemParams) (PurchaseReceiptItem, error) {
+	row := q.db.QueryRow(ctx, createPurchaseReceiptItem,
+		arg.IDFromReceipt,
+		arg.IDFromPurchaseOrderItem,
+		arg.Quantity,
+		arg.LotNumber,
+	)
+	var i PurchaseReceiptItem
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromReceipt,
+		&i.IDFromPurchaseOrderItem,
+		&i.Quantity,
+		&i.LotNumber,
+	)
+	return i, err
 }
 
-func (q *Queries) ListOrdersWithProduct(ctx context.Context, arg ListOrdersWithProductParams) ([]ListOrdersWithProductRow, error) {
-	rows, err := q.db.Query(ctx, listOrdersWithProduct, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListOrdersWithProductRow
-	for rows.Next() {
-		var i ListOrdersWithProductRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.OrderNumber,
-			&i.IDFromProduct,
-			&i.ProductID,
-			&i.CustomerName,
-			&i.TotalAmount,
-			&i.Status,
-			&i.Platform,
-			&i.Destination,
-			&i.PriceIdr,
-			&i.CreatedAt,
-			&i.ProductName,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+const createSerialEvent = `-- name: CreateSerialEvent :one
+INSERT INTO serial_events (id_from_serial, event, id_from_order, reference)
+VALUES ($1, $2, $3, $4)
+RETURNING id, id_from_serial, event, id_from_order, reference, created_at
+`
+
+type CreateSerialEventParams struct {
+	IDFromSerial int32       `json:"id_from_serial"`
+	Event        string      `json:"event"`
+	IDFromOrder  pgtype.Int4 `json:"id_from_order"`
+	Reference    pgtype.Text `json:"reference"`
 }
 
-const listProducts = `-- name: ListProducts :many
-SELECT
-  id,
-  product_id,
-  product_name,
-  supplier_name,
-  category,
-  price_idr,
-  stock,
-  created_at,
-  updated_at
-FROM products
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+func (q *Queries) CreateSerialEvent(ctx context.Context, arg CreateSerialEventParams) (SerialEvent, error) {
+	row := q.db.QueryRow(ctx, createSerialEvent,
+		arg.IDFromSerial,
+		arg.Event,
+		arg.IDFromOrder,
+		arg.Reference,
+	)
+	var i SerialEvent
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromSerial,
+		&i.Event,
+		&i.IDFromOrder,
+		&i.Reference,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSerialNumber = `-- name: CreateSerialNumber :one
+
+INSERT INTO serial_numbers (id_from_product, serial_number, id_from_warehouse)
+VALUES ($1, $2, $3)
+RETURNING id, id_from_product, serial_number, status, id_from_warehouse, id_from_order, created_at, updated_at
 `
 
-type ListProductsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type CreateSerialNumberParams struct {
+	IDFromProduct   int32       `json:"id_from_product"`
+	SerialNumber    string      `json:"serial_number"`
+	IDFromWarehouse pgtype.Int4 `json:"id_from_warehouse"`
 }
 
-func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error) {
-	rows, err := q.db.Query(ctx, listProducts, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Product
-	for rows.Next() {
-		var i Product
-		if err := rows.Scan(
+// Serial Numbers
+func (q *Queries) CreateSerialNumber(ctx context.Context, arg CreateSerialNumberParams) (SerialNumber, error) {
+	row := q.db.QueryRow(ctx, createSerialNumber, arg.IDFromProduct, arg.SerialNumber, arg.IDFromWarehouse)
+	var i SerialNumber
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.SerialNumber,
+		&i.Status,
+		&i.IDFromWarehouse,
+		&i.IDFromOrder,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createStockMovement = `-- name: CreateStockMovement :one
+
+INSERT INTO stock_movements (id_from_product, quantity, stock_after, reason, reference)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, id_from_product, quantity, stock_after, reason, reference, created_at
+`
+
+type CreateStockMovementParams struct {
+	IDFromProduct int32       `json:"id_from_product"`
+	Quantity      int32       `json:"quantity"`
+	StockAfter    int32       `json:"stock_after"`
+	Reason        string      `json:"reason"`
+	Reference     pgtype.Text `json:"reference"`
+}
+
+// Stock Movements
+func (q *Queries) CreateStockMovement(ctx context.Context, arg CreateStockMovementParams) (StockMovement, error) {
+	row := q.db.QueryRow(ctx, createStockMovement,
+		arg.IDFromProduct,
+		arg.Quantity,
+		arg.StockAfter,
+		arg.Reason,
+		arg.Reference,
+	)
+	var i StockMovement
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.Quantity,
+		&i.StockAfter,
+		&i.Reason,
+		&i.Reference,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createStockReservation = `-- name: CreateStockReservation :one
+
+INSERT INTO stock_reservations (id_from_order, id_from_product, id_from_warehouse, quantity, id_from_variant)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, id_from_order, id_from_product, id_from_warehouse, quantity, status, created_at, updated_at, id_from_variant
+`
+
+type CreateStockReservationParams struct {
+	IDFromOrder     int32       `json:"id_from_order"`
+	IDFromProduct   int32       `json:"id_from_product"`
+	IDFromWarehouse int32       `json:"id_from_warehouse"`
+	Quantity        int32       `json:"quantity"`
+	IDFromVariant   pgtype.Int4 `json:"id_from_variant"`
+}
+
+// Stock Reservations
+func (q *Queries) CreateStockReservation(ctx context.Context, arg CreateStockReservationParams) (StockReservation, error) {
+	row := q.db.QueryRow(ctx, createStockReservation,
+		arg.IDFromOrder,
+		arg.IDFromProduct,
+		arg.IDFromWarehouse,
+		arg.Quantity,
+		arg.IDFromVariant,
+	)
+	var i StockReservation
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromOrder,
+		&i.IDFromProduct,
+		&i.IDFromWarehouse,
+		&i.Quantity,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IDFromVariant,
+	)
+	return i, err
+}
+
+const createStockTake = `-- name: CreateStockTake :one
+
+INSERT INTO stock_takes (id_from_warehouse, note, created_by)
+VALUES ($1, $2, $3)
+RETURNING id, id_from_warehouse, status, note, created_by, approved_by, created_at, completed_at
+`
+
+type CreateStockTakeParams struct {
+	IDFromWarehouse int32       `json:"id_from_warehouse"`
+	Note            pgtype.Text `json:"note"`
+	CreatedBy       pgtype.Int4 `json:"created_by"`
+}
+
+// Stock Takes
+func (q *Queries) CreateStockTake(ctx context.Context, arg CreateStockTakeParams) (StockTake, error) {
+	row := q.db.QueryRow(ctx, createStockTake, arg.IDFromWarehouse, arg.Note, arg.CreatedBy)
+	var i StockTake
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromWarehouse,
+		&i.Status,
+		&i.Note,
+		&i.CreatedBy,
+		&i.ApprovedBy,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const createStockTransfer = `-- name: CreateStockTransfer :one
+
+INSERT INTO stock_transfers (id_from_source, id_from_destination, note)
+VALUES ($1, $2, $3)
+RETURNING id, id_from_source, id_from_destination, status, note, created_at, completed_at
+`
+
+type CreateStockTransferParams struct {
+	IDFromSource      int32       `json:"id_from_source"`
+	IDFromDestination int32       `json:"id_from_destination"`
+	Note              pgtype.Text `json:"note"`
+}
+
+// Stock Transfers
+func (q *Queries) CreateStockTransfer(ctx context.Context, arg CreateStockTransferParams) (StockTransfer, error) {
+	row := q.db.QueryRow(ctx, createStockTransfer, arg.IDFromSource, arg.IDFromDestination, arg.Note)
+	var i StockTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromSource,
+		&i.IDFromDestination,
+		&i.Status,
+		&i.Note,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const createStockTransferItem = `-- name: CreateStockTransferItem :one
+INSERT INTO stock_transfer_items (id_from_transfer, id_from_product, quantity, lot_number, expiry_date, serial_numbers)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, id_from_transfer, id_from_product, quantity, lot_number, expiry_date, serial_numbers
+`
+
+type CreateStockTransferItemParams struct {
+	IDFromTransfer int32       `json:"id_from_transfer"`
+	IDFromProduct  int32       `json:"id_from_product"`
+	Quantity       int32       `json:"quantity"`
+	LotNumber      pgtype.Text `json:"lot_number"`
+	ExpiryDate     pgtype.Date `json:"expiry_date"`
+	SerialNumbers  []string    `json:"serial_numbers"`
+}
+
+func (q *Queries) CreateStockTransferItem(ctx context.Context, arg CreateStockTransferItemParams) (StockTransferItem, error) {
+	row := q.db.QueryRow(ctx, createStockTransferItem,
+		arg.IDFromTransfer,
+		arg.IDFromProduct,
+		arg.Quantity,
+		arg.LotNumber,
+		arg.ExpiryDate,
+		arg.SerialNumbers,
+	)
+	var i StockTransferItem
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromTransfer,
+		&i.IDFromProduct,
+		&i.Quantity,
+		&i.LotNumber,
+		&i.ExpiryDate,
+		&i.SerialNumbers,
+	)
+	return i, err
+}
+
+const createUser = `-- name: CreateUser :one
+
+
+INSERT INTO users (user_id, username, email, full_name, password_hash, role, permissions)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, username, email, full_name, role, permissions, created_at, updated_at
+`
+
+type CreateUserParams struct {
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	FullName     string `json:"full_name"`
+	PasswordHash string `json:"password_hash"`
+	Role         string `json:"role"`
+	Permissions  []byte `json:"permissions"`
+}
+
+type CreateUserRow struct {
+	ID          int32            `json:"id"`
+	UserID      string           `json:"user_id"`
+	Username    string           `json:"username"`
+	Email       string           `json:"email"`
+	FullName    string           `json:"full_name"`
+	Role        string           `json:"role"`
+	Permissions []byte           `json:"permissions"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+}
+
+// internal/adapters/postgresql/sqlc/queries.sql
+// Users
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
+	row := q.db.QueryRow(ctx, createUser,
+		arg.UserID,
+		arg.Username,
+		arg.Email,
+		arg.FullName,
+		arg.PasswordHash,
+		arg.Role,
+		arg.Permissions,
+	)
+	var i CreateUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Username,
+		&i.Email,
+		&i.FullName,
+		&i.Role,
+		&i.Permissions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createWarehouse = `-- name: CreateWarehouse :one
+
+INSERT INTO warehouses (code, name, address)
+VALUES ($1, $2, $3)
+RETURNING id, code, name, address, is_default, created_at
+`
+
+type CreateWarehouseParams struct {
+	Code    string      `json:"code"`
+	Name    string      `json:"name"`
+	Address pgtype.Text `json:"address"`
+}
+
+// Warehouses
+func (q *Queries) CreateWarehouse(ctx context.Context, arg CreateWarehouseParams) (Warehouse, error) {
+	row := q.db.QueryRow(ctx, createWarehouse, arg.Code, arg.Name, arg.Address)
+	var i Warehouse
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Address,
+		&i.IsDefault,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteBundleComponents = `-- name: DeleteBundleComponents :exec
+DELETE FROM bundle_components
+WHERE id_from_bundle = $1
+`
+
+func (q *Queries) DeleteBundleComponents(ctx context.Context, idFromBundle int32) error {
+	_, err := q.db.Exec(ctx, deleteBundleComponents, idFromBundle)
+	return err
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredIdempotencyKeys)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE idempotency_key = $1 AND method = $2 AND path = $3 AND caller = $4
+`
+
+type DeleteIdempotencyKeyParams struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	Caller         string `json:"caller"`
+}
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, deleteIdempotencyKey,
+		arg.IdempotencyKey,
+		arg.Method,
+		arg.Path,
+		arg.Caller,
+	)
+	return err
+}
+
+const deleteProductBarcode = `-- name: DeleteProductBarcode :execrows
+DELETE FROM product_barcodes
+WHERE id = $1 AND id_from_product = $2
+`
+
+type DeleteProductBarcodeParams struct {
+	ID            int32 `json:"id"`
+	IDFromProduct int32 `json:"id_from_product"`
+}
+
+func (q *Queries) DeleteProductBarcode(ctx context.Context, arg DeleteProductBarcodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteProductBarcode, arg.ID, arg.IDFromProduct)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteProductImage = `-- name: DeleteProductImage :exec
+DELETE FROM product_images
+WHERE id = $1
+`
+
+func (q *Queries) DeleteProductImage(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteProductImage, id)
+	return err
+}
+
+const deleteProductUnit = `-- name: DeleteProductUnit :execrows
+DELETE FROM product_units
+WHERE id = $1 AND id_from_product = $2
+`
+
+type DeleteProductUnitParams struct {
+	ID            int32 `json:"id"`
+	IDFromProduct int32 `json:"id_from_product"`
+}
+
+func (q *Queries) DeleteProductUnit(ctx context.Context, arg DeleteProductUnitParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteProductUnit, arg.ID, arg.IDFromProduct)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePurchaseOrderItems = `-- name: DeletePurchaseOrderItems :exec
+DELETE FROM purchase_order_items
+WHERE id_from_purchase_order = $1
+`
+
+func (q *Queries) DeletePurchaseOrderItems(ctx context.Context, idFromPurchaseOrder int32) error {
+	_, err := q.db.Exec(ctx, deletePurchaseOrderItems, idFromPurchaseOrder)
+	return err
+}
+
+const deleteScheduledPrice = `-- name: DeleteScheduledPrice :execrows
+DELETE FROM product_prices
+WHERE id = $1
+  AND id_from_product = $2
+  AND applied_at IS NULL
+`
+
+type DeleteScheduledPriceParams struct {
+	ID            int32 `json:"id"`
+	IDFromProduct int32 `json:"id_from_product"`
+}
+
+func (q *Queries) DeleteScheduledPrice(ctx context.Context, arg DeleteScheduledPriceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteScheduledPrice, arg.ID, arg.IDFromProduct)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAvailableStock = `-- name: GetAvailableStock :one
+SELECT COALESCE((SELECT ws.quantity FROM warehouse_stock ws
+                 WHERE ws.id_from_product = $1
+                   AND ws.id_from_warehouse = $2), 0)::bigint AS on_hand,
+       COALESCE((SELECT SUM(r.quantity) FROM stock_reservations r
+                 WHERE r.id_from_product = $1
+                   AND r.id_from_warehouse = $2
+                   AND r.status = 'active'), 0)::bigint AS reserved
+`
+
+type GetAvailableStockParams struct {
+	IDFromProduct   int32 `json:"id_from_product"`
+	IDFromWarehouse int32 `json:"id_from_warehouse"`
+}
+
+type GetAvailableStockRow struct {
+	OnHand   int64 `json:"on_hand"`
+	Reserved int64 `json:"reserved"`
+}
+
+// On-hand quantity at a location and how much of it active reservations hold.
+func (q *Queries) GetAvailableStock(ctx context.Context, arg GetAvailableStockParams) (GetAvailableStockRow, error) {
+	row := q.db.QueryRow(ctx, getAvailableStock, arg.IDFromProduct, arg.IDFromWarehouse)
+	var i GetAvailableStockRow
+	err := row.Scan(&i.OnHand, &i.Reserved)
+	return i, err
+}
+
+const getDefaultWarehouse = `-- name: GetDefaultWarehouse :one
+SELECT id, code, name, address, is_default, created_at FROM warehouses
+WHERE is_default
+LIMIT 1
+`
+
+func (q *Queries) GetDefaultWarehouse(ctx context.Context) (Warehouse, error) {
+	row := q.db.QueryRow(ctx, getDefaultWarehouse)
+	var i Warehouse
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Address,
+		&i.IsDefault,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT idempotency_key,
+       method,
+       path,
+       request_hash,
+       status_code,
+       content_type,
+       response_body,
+       created_at,
+       expires_at,
+       locked_until,
+       caller
+FROM idempotency_keys
+WHERE idempotency_key = $1 AND method = $2 AND path = $3 AND caller = $4
+`
+
+type GetIdempotencyKeyParams struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	Caller         string `json:"caller"`
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey,
+		arg.IdempotencyKey,
+		arg.Method,
+		arg.Path,
+		arg.Caller,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.IdempotencyKey,
+		&i.Method,
+		&i.Path,
+		&i.RequestHash,
+		&i.StatusCode,
+		&i.ContentType,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LockedUntil,
+		&i.Caller,
+	)
+	return i, err
+}
+
+const getInventoryValuation = `-- name: GetInventoryValuation :many
+SELECT p.id,
+       p.product_id,
+       p.product_name,
+       (COALESCE(r.quantity, 0) - COALESCE(c.quantity, 0))::bigint AS quantity,
+       (COALESCE(r.value_idr, 0) - COALESCE(c.value_idr, 0))::bigint AS value_idr
+FROM products p
+LEFT JOIN (
+  SELECT id_from_product,
+         SUM(quantity) AS quantity,
+         SUM(quantity::bigint * unit_cost_idr) AS value_idr
+  FROM cost_layers
+  WHERE received_at <= $1::timestamptz
+  GROUP BY id_from_product
+) r ON r.id_from_product = p.id
+LEFT JOIN (
+  SELECT l.id_from_product,
+         SUM(cc.quantity) AS quantity,
+         SUM(cc.quantity::bigint * cc.unit_cost_idr) AS value_idr
+  FROM cost_layer_consumptions cc
+  JOIN cost_layers l ON l.id = cc.id_from_layer
+  WHERE cc.created_at <= $1::timestamptz
+  GROUP BY l.id_from_product
+) c ON c.id_from_product = p.id
+WHERE COALESCE(r.quantity, 0) - COALESCE(c.quantity, 0) <> 0
+ORDER BY p.product_id
+`
+
+type GetInventoryValuationRow struct {
+	ID          int32  `json:"id"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int64  `json:"quantity"`
+	ValueIdr    int64  `json:"value_idr"`
+}
+
+// Stock quantity and value per product as of a point in time, rebuilt from layers received and
+// consumptions recorded up to that moment. Works for FIFO and weighted average alike because each
+// consumption stores the unit cost it was charged at.
+func (q *Queries) GetInventoryValuation(ctx context.Context, asOf pgtype.Timestamptz) ([]GetInventoryValuationRow, error) {
+	rows, err := q.db.Query(ctx, getInventoryValuation, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInventoryValuationRow
+	for rows.Next() {
+		var i GetInventoryValuationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Quantity,
+			&i.ValueIdr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMarginsByPlatform = `-- name: GetMarginsByPlatform :many
+SELECT o.platform,
+       COUNT(DISTINCT o.id) AS order_count,
+       COALESCE(SUM(i.line_total_idr), 0)::bigint AS revenue_idr,
+       COALESCE(SUM(COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity)), 0)::bigint AS cogs_idr,
+       COALESCE(SUM(i.line_total_idr - COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity)), 0)::bigint AS gross_margin_idr
+FROM orders o
+LEFT JOIN order_items i ON i.id_from_order = o.id
+WHERE lower(o.status) <> 'cancelled'
+  AND o.deleted_at IS NULL
+  AND ($1::timestamptz IS NULL OR o.created_at >= $1)
+  AND ($2::timestamptz IS NULL OR o.created_at < $2)
+GROUP BY o.platform
+ORDER BY gross_margin_idr DESC
+`
+
+type GetMarginsByPlatformParams struct {
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+type GetMarginsByPlatformRow struct {
+	Platform       string `json:"platform"`
+	OrderCount     int64  `json:"order_count"`
+	RevenueIdr     int64  `json:"revenue_idr"`
+	CogsIdr        int64  `json:"cogs_idr"`
+	GrossMarginIdr int64  `json:"gross_margin_idr"`
+}
+
+func (q *Queries) GetMarginsByPlatform(ctx context.Context, arg GetMarginsByPlatformParams) ([]GetMarginsByPlatformRow, error) {
+	rows, err := q.db.Query(ctx, getMarginsByPlatform, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMarginsByPlatformRow
+	for rows.Next() {
+		var i GetMarginsByPlatformRow
+		if err := rows.Scan(
+			&i.Platform,
+			&i.OrderCount,
+			&i.RevenueIdr,
+			&i.CogsIdr,
+			&i.GrossMarginIdr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMarginsByProduct = `-- name: GetMarginsByProduct :many
+SELECT i.product_id,
+       p.product_name,
+       SUM(i.quantity)::bigint AS total_sold,
+       SUM(i.line_total_idr)::bigint AS revenue_idr,
+       SUM(COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity))::bigint AS cogs_idr,
+       SUM(i.line_total_idr - COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity))::bigint AS gross_margin_idr
+FROM order_items i
+JOIN orders o ON o.id = i.id_from_order
+JOIN products p ON p.id = i.id_from_product
+WHERE lower(o.status) <> 'cancelled'
+  AND o.deleted_at IS NULL
+  AND ($1::timestamptz IS NULL OR o.created_at >= $1)
+  AND ($2::timestamptz IS NULL OR o.created_at < $2)
+GROUP BY i.product_id, p.product_name
+ORDER BY gross_margin_idr DESC
+`
+
+type GetMarginsByProductParams struct {
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+type GetMarginsByProductRow struct {
+	ProductID      string `json:"product_id"`
+	ProductName    string `json:"product_name"`
+	TotalSold      int64  `json:"total_sold"`
+	RevenueIdr     int64  `json:"revenue_idr"`
+	CogsIdr        int64  `json:"cogs_idr"`
+	GrossMarginIdr int64  `json:"gross_margin_idr"`
+}
+
+func (q *Queries) GetMarginsByProduct(ctx context.Context, arg GetMarginsByProductParams) ([]GetMarginsByProductRow, error) {
+	rows, err := q.db.Query(ctx, getMarginsByProduct, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMarginsByProductRow
+	for rows.Next() {
+		var i GetMarginsByProductRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.ProductName,
+			&i.TotalSold,
+			&i.RevenueIdr,
+			&i.CogsIdr,
+			&i.GrossMarginIdr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderByID = `-- name: GetOrderByID :one
+SELECT id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by FROM orders
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetOrderByID(ctx context.Context, id int32) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderByID, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerName,
+		&i.TotalAmount,
+		&i.Status,
+		&i.Platform,
+		&i.Destination,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const getOrderForUpdate = `-- name: GetOrderForUpdate :one
+SELECT id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by FROM orders
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetOrderForUpdate(ctx context.Context, id int32) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerName,
+		&i.TotalAmount,
+		&i.Status,
+		&i.Platform,
+		&i.Destination,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const getOrderMargins = `-- name: GetOrderMargins :many
+
+SELECT o.id,
+       o.order_number,
+       o.platform,
+       o.status,
+       o.total_amount,
+       o.total_idr AS revenue_idr,
+       COALESCE(SUM(COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity)), 0)::bigint AS cogs_idr,
+       (o.total_idr - COALESCE(SUM(COALESCE(i.cogs_idr, i.unit_cost_idr * i.quantity)), 0))::bigint AS gross_margin_idr,
+       o.created_at
+FROM orders o
+LEFT JOIN order_items i ON i.id_from_order = o.id
+WHERE lower(o.status) <> 'cancelled'
+  AND o.deleted_at IS NULL
+  AND ($1::timestamptz IS NULL OR o.created_at >= $1)
+  AND ($2::timestamptz IS NULL OR o.created_at < $2)
+GROUP BY o.id
+ORDER BY o.id DESC
+LIMIT $3 OFFSET $4
+`
+
+type GetOrderMarginsParams struct {
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+	RowLimit    int32              `json:"row_limit"`
+	RowOffset   int32              `json:"row_offset"`
+}
+
+type GetOrderMarginsRow struct {
+	ID             int32              `json:"id"`
+	OrderNumber    string             `json:"order_number"`
+	Platform       string             `json:"platform"`
+	Status         string             `json:"status"`
+	TotalAmount    pgtype.Int4        `json:"total_amount"`
+	RevenueIdr     int64              `json:"revenue_idr"`
+	CogsIdr        int64              `json:"cogs_idr"`
+	GrossMarginIdr int64              `json:"gross_margin_idr"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+// Margin reports
+// revenue = line totals of the order items, cogs = cogs_idr from consumed cost layers, falling back to
+// unit_cost_idr * quantity (unit cost captured at order time).
+// Cancelled orders are excluded; orders created before cost tracking have no unit cost and count as zero COGS.
+func (q *Queries) GetOrderMargins(ctx context.Context, arg GetOrderMarginsParams) ([]GetOrderMarginsRow, error) {
+	rows, err := q.db.Query(ctx, getOrderMargins,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOrderMarginsRow
+	for rows.Next() {
+		var i GetOrderMarginsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderNumber,
+			&i.Platform,
+			&i.Status,
+			&i.TotalAmount,
+			&i.RevenueIdr,
+			&i.CogsIdr,
+			&i.GrossMarginIdr,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderReturnForUpdate = `-- name: GetOrderReturnForUpdate :one
+SELECT id, id_from_order, status, reason, id_from_warehouse, refund_idr, note, requested_by, inspected_by, created_at, inspected_at FROM order_returns
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetOrderReturnForUpdate(ctx context.Context, id int32) (OrderReturn, error) {
+	row := q.db.QueryRow(ctx, getOrderReturnForUpdate, id)
+	var i OrderReturn
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromOrder,
+		&i.Status,
+		&i.Reason,
+		&i.IDFromWarehouse,
+		&i.RefundIdr,
+		&i.Note,
+		&i.RequestedBy,
+		&i.InspectedBy,
+		&i.CreatedAt,
+		&i.InspectedAt,
+	)
+	return i, err
+}
+
+const getOrderTotals = `-- name: GetOrderTotals :one
+SELECT COUNT(*) AS order_count,
+       COALESCE(SUM(total_amount), 0)::bigint AS total_quantity,
+       COALESCE(SUM(total_idr), 0)::bigint AS total_idr
+FROM orders
+WHERE deleted_at IS NULL
+  AND ($1::text IS NULL
+       OR (CASE lower(status) WHEN 'shipped' THEN 'shipping' ELSE lower(status) END) = $1)
+  AND ($2::text IS NULL OR platform ILIKE $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+  AND ($5::text IS NULL OR customer_name ILIKE '%' || $5 || '%')
+  AND ($6::text IS NULL OR order_number ILIKE '%' || $6 || '%')
+`
+
+type GetOrderTotalsParams struct {
+	Status      pgtype.Text        `json:"status"`
+	Platform    pgtype.Text        `json:"platform"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+	Customer    pgtype.Text        `json:"customer"`
+	Search      pgtype.Text        `json:"search"`
+}
+
+type GetOrderTotalsRow struct {
+	OrderCount    int64 `json:"order_count"`
+	TotalQuantity int64 `json:"total_quantity"`
+	TotalIdr      int64 `json:"total_idr"`
+}
+
+// Count and sums of the orders ListOrders matches, ignoring the page.
+func (q *Queries) GetOrderTotals(ctx context.Context, arg GetOrderTotalsParams) (GetOrderTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getOrderTotals,
+		arg.Status,
+		arg.Platform,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Customer,
+		arg.Search,
+	)
+	var i GetOrderTotalsRow
+	err := row.Scan(&i.OrderCount, &i.TotalQuantity, &i.TotalIdr)
+	return i, err
+}
+
+const getPermissionsByID = `-- name: GetPermissionsByID :one
+SELECT permissions
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetPermissionsByID(ctx context.Context, id int32) ([]byte, error) {
+	row := q.db.QueryRow(ctx, getPermissionsByID, id)
+	var permissions []byte
+	err := row.Scan(&permissions)
+	return permissions, err
+}
+
+const getProductByCode = `-- name: GetProductByCode :one
+SELECT
+  id,
+  product_id,
+  product_name,
+  supplier_name,
+  category,
+  price_idr,
+  stock,
+  created_at,
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
+FROM products
+WHERE product_id = $1
+   OR id IN (SELECT id_from_product FROM product_barcodes WHERE barcode = $1)
+LIMIT 1
+`
+
+// Resolves a scanned code: either the product's own product_id or one of its registered barcodes.
+func (q *Queries) GetProductByCode(ctx context.Context, code string) (Product, error) {
+	row := q.db.QueryRow(ctx, getProductByCode, code)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.SupplierName,
+		&i.Category,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const getProductByID = `-- name: GetProductByID :one
+SELECT
+  id,
+  product_id,
+  product_name,
+  supplier_name,
+  category,
+  price_idr,
+  stock,
+  created_at,
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
+FROM products
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetProductByID(ctx context.Context, id int32) (Product, error) {
+	row := q.db.QueryRow(ctx, getProductByID, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.SupplierName,
+		&i.Category,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const getProductForUpdate = `-- name: GetProductForUpdate :one
+SELECT
+  id,
+  product_id,
+  product_name,
+  supplier_name,
+  category,
+  price_idr,
+  stock,
+  created_at,
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
+FROM products
+WHERE id = $1
+FOR UPDATE
+`
+
+// Same as GetProductByID but locks the row until the surrounding transaction ends.
+func (q *Queries) GetProductForUpdate(ctx context.Context, id int32) (Product, error) {
+	row := q.db.QueryRow(ctx, getProductForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.SupplierName,
+		&i.Category,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const getProductImageByID = `-- name: GetProductImageByID :one
+SELECT id, id_from_product, storage_key, thumbnail_key, content_type, size_bytes, width, height, sort_order, created_at FROM product_images
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetProductImageByID(ctx context.Context, id int32) (ProductImage, error) {
+	row := q.db.QueryRow(ctx, getProductImageByID, id)
+	var i ProductImage
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Width,
+		&i.Height,
+		&i.SortOrder,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProductInventoryValue = `-- name: GetProductInventoryValue :one
+SELECT
+  (SELECT COALESCE(SUM(l.quantity_remaining), 0)
+     FROM cost_layers l
+    WHERE l.id_from_product = $1)::bigint AS quantity,
+  ((SELECT COALESCE(SUM(l.quantity::bigint * l.unit_cost_idr), 0)
+      FROM cost_layers l
+     WHERE l.id_from_product = $1)
+   - (SELECT COALESCE(SUM(c.quantity::bigint * c.unit_cost_idr), 0)
+        FROM cost_layer_consumptions c
+        JOIN cost_layers l ON l.id = c.id_from_layer
+       WHERE l.id_from_product = $1))::bigint AS value_idr
+`
+
+type GetProductInventoryValueRow struct {
+	Quantity int64 `json:"quantity"`
+	ValueIdr int64 `json:"value_idr"`
+}
+
+// Units left in the product's layers and their book value (received value minus consumed value).
+func (q *Queries) GetProductInventoryValue(ctx context.Context, idFromProduct int32) (GetProductInventoryValueRow, error) {
+	row := q.db.QueryRow(ctx, getProductInventoryValue, idFromProduct)
+	var i GetProductInventoryValueRow
+	err := row.Scan(&i.Quantity, &i.ValueIdr)
+	return i, err
+}
+
+const getProductUnitByName = `-- name: GetProductUnitByName :one
+SELECT id, id_from_product, unit_name, factor, created_at FROM product_units
+WHERE id_from_product = $1 AND lower(unit_name) = lower($2::text)
+LIMIT 1
+`
+
+type GetProductUnitByNameParams struct {
+	IDFromProduct int32  `json:"id_from_product"`
+	UnitName      string `json:"unit_name"`
+}
+
+func (q *Queries) GetProductUnitByName(ctx context.Context, arg GetProductUnitByNameParams) (ProductUnit, error) {
+	row := q.db.QueryRow(ctx, getProductUnitByName, arg.IDFromProduct, arg.UnitName)
+	var i ProductUnit
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.UnitName,
+		&i.Factor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProductVariantByID = `-- name: GetProductVariantByID :one
+SELECT id, id_from_product, product_id, variant_name, price_idr, stock, attributes, created_at, updated_at FROM product_variants
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetProductVariantByID(ctx context.Context, id int32) (ProductVariant, error) {
+	row := q.db.QueryRow(ctx, getProductVariantByID, id)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.ProductID,
+		&i.VariantName,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.Attributes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getProductVariantForUpdate = `-- name: GetProductVariantForUpdate :one
+SELECT id, id_from_product, product_id, variant_name, price_idr, stock, attributes, created_at, updated_at FROM product_variants
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetProductVariantForUpdate(ctx context.Context, id int32) (ProductVariant, error) {
+	row := q.db.QueryRow(ctx, getProductVariantForUpdate, id)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.ProductID,
+		&i.VariantName,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.Attributes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getProductsByIDs = `-- name: GetProductsByIDs :many
+SELECT
+  id,
+  product_id,
+  product_name,
+  supplier_name,
+  category,
+  price_idr,
+  stock,
+  created_at,
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
+FROM products
+WHERE id = ANY($1::int[])
+ORDER BY id
+`
+
+func (q *Queries) GetProductsByIDs(ctx context.Context, ids []int32) ([]Product, error) {
+	rows, err := q.db.Query(ctx, getProductsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.SupplierName,
+			&i.Category,
+			&i.PriceIdr,
+			&i.Stock,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CostIdr,
+			&i.TrackSerials,
+			&i.IsBundle,
+			&i.BaseUnit,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPurchaseOrderByID = `-- name: GetPurchaseOrderByID :one
+SELECT id, po_number, supplier_name, id_from_warehouse, status, note, expected_at, sent_at, closed_at, created_at, updated_at FROM purchase_orders
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetPurchaseOrderByID(ctx context.Context, id int32) (PurchaseOrder, error) {
+	row := q.db.QueryRow(ctx, getPurchaseOrderByID, id)
+	var i PurchaseOrder
+	err := row.Scan(
+		&i.ID,
+		&i.PoNumber,
+		&i.SupplierName,
+		&i.IDFromWarehouse,
+		&i.Status,
+		&i.Note,
+		&i.ExpectedAt,
+		&i.SentAt,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPurchaseOrderForUpdate = `-- name: GetPurchaseOrderForUpdate :one
+SELECT id, po_number, supplier_name, id_from_warehouse, status, note, expected_at, sent_at, closed_at, created_at, updated_at FROM purchase_orders
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetPurchaseOrderForUpdate(ctx context.Context, id int32) (PurchaseOrder, error) {
+	row := q.db.QueryRow(ctx, getPurchaseOrderForUpdate, id)
+	var i PurchaseOrder
+	err := row.Scan(
+		&i.ID,
+		&i.PoNumber,
+		&i.SupplierName,
+		&i.IDFromWarehouse,
+		&i.Status,
+		&i.Note,
+		&i.ExpectedAt,
+		&i.SentAt,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getReservedVariantStock = `-- name: GetReservedVariantStock :one
+SELECT COALESCE(SUM(quantity), 0)::bigint AS reserved
+FROM stock_reservations
+WHERE id_from_variant = $1 AND status = 'active'
+`
+
+// How much of a variant's stock active reservations hold.
+func (q *Queries) GetReservedVariantStock(ctx context.Context, idFromVariant pgtype.Int4) (int64, error) {
+	row := q.db.QueryRow(ctx, getReservedVariantStock, idFromVariant)
+	var reserved int64
+	err := row.Scan(&reserved)
+	return reserved, err
+}
+
+const getReturnsReport = `-- name: GetReturnsReport :many
+SELECT p.id AS id_from_product,
+       p.product_id,
+       p.product_name,
+       COUNT(DISTINCT r.id) AS return_count,
+       SUM(ri.quantity)::bigint AS returned_qty,
+       COALESCE(SUM(ri.quantity) FILTER (WHERE ri.outcome = 'restock'), 0)::bigint AS restocked_qty,
+       COALESCE(SUM(ri.quantity) FILTER (WHERE ri.outcome = 'damaged'), 0)::bigint AS damaged_qty,
+       COALESCE(SUM(ri.quantity) FILTER (WHERE ri.outcome = 'discard'), 0)::bigint AS discarded_qty,
+       SUM(ri.refund_idr)::bigint AS refund_idr
+FROM order_return_items ri
+JOIN order_returns r ON r.id = ri.id_from_return
+JOIN order_items i ON i.id = ri.id_from_order_item
+JOIN products p ON p.id = i.id_from_product
+WHERE r.status = 'completed'
+  AND ($1::timestamptz IS NULL OR r.created_at >= $1)
+  AND ($2::timestamptz IS NULL OR r.created_at < $2)
+GROUP BY p.id, p.product_id, p.product_name
+ORDER BY returned_qty DESC, p.product_id
+`
+
+type GetReturnsReportParams struct {
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+type GetReturnsReportRow struct {
+	IDFromProduct int32  `json:"id_from_product"`
+	ProductID     string `json:"product_id"`
+	ProductName   string `json:"product_name"`
+	ReturnCount   int64  `json:"return_count"`
+	ReturnedQty   int64  `json:"returned_qty"`
+	RestockedQty  int64  `json:"restocked_qty"`
+	DamagedQty    int64  `json:"damaged_qty"`
+	DiscardedQty  int64  `json:"discarded_qty"`
+	RefundIdr     int64  `json:"refund_idr"`
+}
+
+// Completed returns per product: returned quantity by inspection outcome and the refunds paid.
+func (q *Queries) GetReturnsReport(ctx context.Context, arg GetReturnsReportParams) ([]GetReturnsReportRow, error) {
+	rows, err := q.db.Query(ctx, getReturnsReport, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReturnsReportRow
+	for rows.Next() {
+		var i GetReturnsReportRow
+		if err := rows.Scan(
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.ProductName,
+			&i.ReturnCount,
+			&i.ReturnedQty,
+			&i.RestockedQty,
+			&i.DamagedQty,
+			&i.DiscardedQty,
+			&i.RefundIdr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSerialForUpdate = `-- name: GetSerialForUpdate :one
+SELECT id, id_from_product, serial_number, status, id_from_warehouse, id_from_order, created_at, updated_at FROM serial_numbers
+WHERE id_from_product = $1 AND serial_number = $2
+FOR UPDATE
+`
+
+type GetSerialForUpdateParams struct {
+	IDFromProduct int32  `json:"id_from_product"`
+	SerialNumber  string `json:"serial_number"`
+}
+
+func (q *Queries) GetSerialForUpdate(ctx context.Context, arg GetSerialForUpdateParams) (SerialNumber, error) {
+	row := q.db.QueryRow(ctx, getSerialForUpdate, arg.IDFromProduct, arg.SerialNumber)
+	var i SerialNumber
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.SerialNumber,
+		&i.Status,
+		&i.IDFromWarehouse,
+		&i.IDFromOrder,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getStockTakeByID = `-- name: GetStockTakeByID :one
+SELECT id, id_from_warehouse, status, note, created_by, approved_by, created_at, completed_at FROM stock_takes
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetStockTakeByID(ctx context.Context, id int32) (StockTake, error) {
+	row := q.db.QueryRow(ctx, getStockTakeByID, id)
+	var i StockTake
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromWarehouse,
+		&i.Status,
+		&i.Note,
+		&i.CreatedBy,
+		&i.ApprovedBy,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getStockTakeForUpdate = `-- name: GetStockTakeForUpdate :one
+SELECT id, id_from_warehouse, status, note, created_by, approved_by, created_at, completed_at FROM stock_takes
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetStockTakeForUpdate(ctx context.Context, id int32) (StockTake, error) {
+	row := q.db.QueryRow(ctx, getStockTakeForUpdate, id)
+	var i StockTake
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromWarehouse,
+		&i.Status,
+		&i.Note,
+		&i.CreatedBy,
+		&i.ApprovedBy,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getStockTransferByID = `-- name: GetStockTransferByID :one
+SELECT id, id_from_source, id_from_destination, status, note, created_at, completed_at FROM stock_transfers
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetStockTransferByID(ctx context.Context, id int32) (StockTransfer, error) {
+	row := q.db.QueryRow(ctx, getStockTransferByID, id)
+	var i StockTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromSource,
+		&i.IDFromDestination,
+		&i.Status,
+		&i.Note,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getStockTransferForUpdate = `-- name: GetStockTransferForUpdate :one
+SELECT id, id_from_source, id_from_destination, status, note, created_at, completed_at FROM stock_transfers
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetStockTransferForUpdate(ctx context.Context, id int32) (StockTransfer, error) {
+	row := q.db.QueryRow(ctx, getStockTransferForUpdate, id)
+	var i StockTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromSource,
+		&i.IDFromDestination,
+		&i.Status,
+		&i.Note,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getSupplierPerformance = `-- name: GetSupplierPerformance :many
+
+WITH suppliers AS (
+    SELECT DISTINCT supplier_name FROM products
+    UNION
+    SELECT DISTINCT supplier_name FROM purchase_orders
+),
+po AS (
+    SELECT po.id,
+           po.supplier_name,
+           po.status,
+           po.expected_at,
+           COALESCE(po.sent_at, po.created_at) AS ordered_at,
+           (SELECT MIN(r.received_at) FROM purchase_receipts r WHERE r.id_from_purchase_order = po.id) AS first_received_at,
+           (SELECT MAX(r.received_at) FROM purchase_receipts r WHERE r.id_from_purchase_order = po.id) AS last_received_at,
+           (SELECT COALESCE(SUM(i.quantity), 0) FROM purchase_order_items i WHERE i.id_from_purchase_order = po.id) AS ordered_qty,
+           (SELECT COALESCE(SUM(i.received_qty), 0) FROM purchase_order_items i WHERE i.id_from_purchase_order = po.id) AS received_qty
+    FROM purchase_orders po
+    WHERE po.status IN ('sent', 'partially_received', 'received')
+      AND ($1::timestamptz IS NULL OR po.created_at >= $1)
+      AND ($2::timestamptz IS NULL OR po.created_at < $2)
+)
+SELECT s.supplier_name,
+       (SELECT COUNT(*) FROM products p WHERE p.supplier_name = s.supplier_name) AS product_count,
+       COUNT(po.id) AS purchase_order_count,
+       COUNT(po.id) FILTER (WHERE po.status = 'received') AS received_order_count,
+       COALESCE(SUM(po.ordered_qty), 0)::bigint AS ordered_qty,
+       COALESCE(SUM(po.received_qty), 0)::bigint AS received_qty,
+       COALESCE(AVG(EXTRACT(EPOCH FROM po.first_received_at - po.ordered_at) / 86400), 0)::float8 AS avg_lead_time_days,
+       COUNT(po.id) FILTER (WHERE po.expected_at IS NOT NULL AND (po.status = 'received' OR po.expected_at < CURRENT_DATE)) AS due_order_count,
+       COUNT(po.id) FILTER (WHERE po.status = 'received' AND po.last_received_at::date <= po.expected_at) AS on_time_order_count
+FROM suppliers s
+LEFT JOIN po ON po.supplier_name = s.supplier_name
+WHERE ($3::text IS NULL OR s.supplier_name = $3)
+GROUP BY s.supplier_name
+ORDER BY s.supplier_name
+`
+
+type GetSupplierPerformanceParams struct {
+	CreatedFrom  pgtype.Timestamptz `json:"created_from"`
+	CreatedTo    pgtype.Timestamptz `json:"created_to"`
+	SupplierName pgtype.Text        `json:"supplier_name"`
+}
+
+type GetSupplierPerformanceRow struct {
+	SupplierName       string  `json:"supplier_name"`
+	ProductCount       int64   `json:"product_count"`
+	PurchaseOrderCount int64   `json:"purchase_order_count"`
+	ReceivedOrderCount int64   `json:"received_order_count"`
+	OrderedQty         int64   `json:"ordered_qty"`
+	ReceivedQty        int64   `json:"received_qty"`
+	AvgLeadTimeDays    float64 `json:"avg_lead_time_days"`
+	DueOrderCount      int64   `json:"due_order_count"`
+	OnTimeOrderCount   int64   `json:"on_time_order_count"`
+}
+
+// Supplier analytics
+// Suppliers are keyed by products.supplier_name (plus names only used on purchase orders).
+// Only POs that went to the supplier count: lead time runs from sending the PO to its first
+// receipt, fill rate is received / ordered quantity, and a PO with expected_at is on time when
+// it was received in full by that date (open POs count once the date has passed).
+func (q *Queries) GetSupplierPerformance(ctx context.Context, arg GetSupplierPerformanceParams) ([]GetSupplierPerformanceRow, error) {
+	rows, err := q.db.Query(ctx, getSupplierPerformance, arg.CreatedFrom, arg.CreatedTo, arg.SupplierName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSupplierPerformanceRow
+	for rows.Next() {
+		var i GetSupplierPerformanceRow
+		if err := rows.Scan(
+			&i.SupplierName,
+			&i.ProductCount,
+			&i.PurchaseOrderCount,
+			&i.ReceivedOrderCount,
+			&i.OrderedQty,
+			&i.ReceivedQty,
+			&i.AvgLeadTimeDays,
+			&i.DueOrderCount,
+			&i.OnTimeOrderCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSupplierPriceTrend = `-- name: GetSupplierPriceTrend :many
+SELECT p.id AS id_from_product,
+       p.product_id,
+       p.product_name,
+       date_trunc('month', po.created_at)::timestamptz AS month,
+       SUM(i.quantity)::bigint AS quantity,
+       (SUM(i.quantity::bigint * i.unit_cost_idr) / SUM(i.quantity))::bigint AS avg_unit_cost_idr
+FROM purchase_order_items i
+JOIN purchase_orders po ON po.id = i.id_from_purchase_order
+JOIN products p ON p.id = i.id_from_product
+WHERE po.supplier_name = $1
+  AND po.status IN ('sent', 'partially_received', 'received')
+  AND ($2::timestamptz IS NULL OR po.created_at >= $2)
+  AND ($3::timestamptz IS NULL OR po.created_at < $3)
+GROUP BY p.id, p.product_id, p.product_name, month
+ORDER BY p.product_id, month
+`
+
+type GetSupplierPriceTrendParams struct {
+	SupplierName string             `json:"supplier_name"`
+	CreatedFrom  pgtype.Timestamptz `json:"created_from"`
+	CreatedTo    pgtype.Timestamptz `json:"created_to"`
+}
+
+type GetSupplierPriceTrendRow struct {
+	IDFromProduct  int32              `json:"id_from_product"`
+	ProductID      string             `json:"product_id"`
+	ProductName    string             `json:"product_name"`
+	Month          pgtype.Timestamptz `json:"month"`
+	Quantity       int64              `json:"quantity"`
+	AvgUnitCostIdr int64              `json:"avg_unit_cost_idr"`
+}
+
+// Quantity-weighted purchase cost per product and month.
+func (q *Queries) GetSupplierPriceTrend(ctx context.Context, arg GetSupplierPriceTrendParams) ([]GetSupplierPriceTrendRow, error) {
+	rows, err := q.db.Query(ctx, getSupplierPriceTrend, arg.SupplierName, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSupplierPriceTrendRow
+	for rows.Next() {
+		var i GetSupplierPriceTrendRow
+		if err := rows.Scan(
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Month,
+			&i.Quantity,
+			&i.AvgUnitCostIdr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTopProductsFromOrders = `-- name: GetTopProductsFromOrders :many
+SELECT i.product_id,
+       p.product_name,
+       SUM(i.quantity) AS total_sold
+FROM order_items i
+JOIN orders o ON o.id = i.id_from_order
+JOIN products p ON p.id = i.id_from_product
+WHERE o.deleted_at IS NULL
+GROUP BY i.product_id, p.product_name
+ORDER BY total_sold DESC
+LIMIT 5
+`
+
+type GetTopProductsFromOrdersRow struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	TotalSold   int64  `json:"total_sold"`
+}
+
+func (q *Queries) GetTopProductsFromOrders(ctx context.Context) ([]GetTopProductsFromOrdersRow, error) {
+	rows, err := q.db.Query(ctx, getTopProductsFromOrders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopProductsFromOrdersRow
+	for rows.Next() {
+		var i GetTopProductsFromOrdersRow
+		if err := rows.Scan(&i.ProductID, &i.ProductName, &i.TotalSold); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByUsernameOrEmail = `-- name: GetUserByUsernameOrEmail :one
+SELECT id, user_id, username, email, full_name, password_hash, role, created_at, updated_at
+FROM users
+WHERE username = $1 OR email = $2
+LIMIT 1
+`
+
+type GetUserByUsernameOrEmailParams struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type GetUserByUsernameOrEmailRow struct {
+	ID           int32            `json:"id"`
+	UserID       string           `json:"user_id"`
+	Username     string           `json:"username"`
+	Email        string           `json:"email"`
+	FullName     string           `json:"full_name"`
+	PasswordHash string           `json:"password_hash"`
+	Role         string           `json:"role"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+}
+
+func (q *Queries) GetUserByUsernameOrEmail(ctx context.Context, arg GetUserByUsernameOrEmailParams) (GetUserByUsernameOrEmailRow, error) {
+	row := q.db.QueryRow(ctx, getUserByUsernameOrEmail, arg.Username, arg.Email)
+	var i GetUserByUsernameOrEmailRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Username,
+		&i.Email,
+		&i.FullName,
+		&i.PasswordHash,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWarehouseByID = `-- name: GetWarehouseByID :one
+SELECT id, code, name, address, is_default, created_at FROM warehouses
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetWarehouseByID(ctx context.Context, id int32) (Warehouse, error) {
+	row := q.db.QueryRow(ctx, getWarehouseByID, id)
+	var i Warehouse
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Address,
+		&i.IsDefault,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listActiveReservationsByOrderID = `-- name: ListActiveReservationsByOrderID :many
+SELECT id, id_from_order, id_from_product, id_from_warehouse, quantity, status, created_at, updated_at, id_from_variant FROM stock_reservations
+WHERE id_from_order = $1 AND status = 'active'
+ORDER BY id
+`
+
+func (q *Queries) ListActiveReservationsByOrderID(ctx context.Context, idFromOrder int32) ([]StockReservation, error) {
+	rows, err := q.db.Query(ctx, listActiveReservationsByOrderID, idFromOrder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockReservation
+	for rows.Next() {
+		var i StockReservation
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromOrder,
+			&i.IDFromProduct,
+			&i.IDFromWarehouse,
+			&i.Quantity,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IDFromVariant,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBarcodesByProductIDs = `-- name: ListBarcodesByProductIDs :many
+SELECT id, id_from_product, barcode, created_at FROM product_barcodes
+WHERE id_from_product = ANY($1::int[])
+ORDER BY id_from_product, id
+`
+
+func (q *Queries) ListBarcodesByProductIDs(ctx context.Context, productIds []int32) ([]ProductBarcode, error) {
+	rows, err := q.db.Query(ctx, listBarcodesByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductBarcode
+	for rows.Next() {
+		var i ProductBarcode
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.Barcode,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBundleComponentsByBundleIDs = `-- name: ListBundleComponentsByBundleIDs :many
+SELECT c.id_from_bundle,
+       c.id_from_component,
+       p.product_id,
+       p.product_name,
+       p.stock,
+       p.cost_idr,
+       c.quantity
+FROM bundle_components c
+JOIN products p ON p.id = c.id_from_component
+WHERE c.id_from_bundle = ANY($1::int[])
+ORDER BY c.id_from_bundle, p.product_id
+`
+
+type ListBundleComponentsByBundleIDsRow struct {
+	IDFromBundle    int32  `json:"id_from_bundle"`
+	IDFromComponent int32  `json:"id_from_component"`
+	ProductID       string `json:"product_id"`
+	ProductName     string `json:"product_name"`
+	Stock           int32  `json:"stock"`
+	CostIdr         int64  `json:"cost_idr"`
+	Quantity        int32  `json:"quantity"`
+}
+
+func (q *Queries) ListBundleComponentsByBundleIDs(ctx context.Context, bundleIds []int32) ([]ListBundleComponentsByBundleIDsRow, error) {
+	rows, err := q.db.Query(ctx, listBundleComponentsByBundleIDs, bundleIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBundleComponentsByBundleIDsRow
+	for rows.Next() {
+		var i ListBundleComponentsByBundleIDsRow
+		if err := rows.Scan(
+			&i.IDFromBundle,
+			&i.IDFromComponent,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Stock,
+			&i.CostIdr,
+			&i.Quantity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCostLayersByProduct = `-- name: ListCostLayersByProduct :many
+SELECT id, id_from_product, quantity, quantity_remaining, unit_cost_idr, received_at, reference, created_at FROM cost_layers
+WHERE id_from_product = $1
+ORDER BY received_at DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListCostLayersByProductParams struct {
+	IDFromProduct int32 `json:"id_from_product"`
+	Limit         int32 `json:"limit"`
+	Offset        int32 `json:"offset"`
+}
+
+func (q *Queries) ListCostLayersByProduct(ctx context.Context, arg ListCostLayersByProductParams) ([]CostLayer, error) {
+	rows, err := q.db.Query(ctx, listCostLayersByProduct, arg.IDFromProduct, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CostLayer
+	for rows.Next() {
+		var i CostLayer
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.Quantity,
+			&i.QuantityRemaining,
+			&i.UnitCostIdr,
+			&i.ReceivedAt,
+			&i.Reference,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCostsByProductIDs = `-- name: ListCostsByProductIDs :many
+SELECT id, id_from_product, cost_idr, note, created_at FROM product_costs
+WHERE id_from_product = ANY($1::int[])
+ORDER BY id_from_product, created_at DESC, id DESC
+`
+
+func (q *Queries) ListCostsByProductIDs(ctx context.Context, productIds []int32) ([]ProductCost, error) {
+	rows, err := q.db.Query(ctx, listCostsByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductCost
+	for rows.Next() {
+		var i ProductCost
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.CostIdr,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedOrders = `-- name: ListDeletedOrders :many
+SELECT id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by FROM orders
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListDeletedOrdersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListDeletedOrders(ctx context.Context, arg ListDeletedOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listDeletedOrders, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderNumber,
+			&i.CustomerName,
+			&i.TotalAmount,
+			&i.Status,
+			&i.Platform,
+			&i.Destination,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CogsIdr,
+			&i.IDFromWarehouse,
+			&i.TotalIdr,
+			&i.CancelReason,
+			&i.CancelledAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDuePrices = `-- name: ListDuePrices :many
+SELECT id, id_from_product, price_idr, effective_at, applied_at, note, created_at FROM product_prices
+WHERE applied_at IS NULL
+  AND effective_at <= now()
+ORDER BY effective_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+// Scheduled prices whose time has come, oldest first; locked so concurrent schedulers skip them.
+func (q *Queries) ListDuePrices(ctx context.Context, limit int32) ([]ProductPrice, error) {
+	rows, err := q.db.Query(ctx, listDuePrices, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductPrice
+	for rows.Next() {
+		var i ProductPrice
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.PriceIdr,
+			&i.EffectiveAt,
+			&i.AppliedAt,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiringLots = `-- name: ListExpiringLots :many
+SELECT l.id,
+       l.id_from_product,
+       p.product_id,
+       p.product_name,
+       l.id_from_warehouse,
+       w.code AS warehouse_code,
+       l.lot_number,
+       l.expiry_date,
+       l.quantity,
+       (l.expiry_date - CURRENT_DATE)::int AS days_left
+FROM stock_lots l
+JOIN products p ON p.id = l.id_from_product
+JOIN warehouses w ON w.id = l.id_from_warehouse
+WHERE l.quantity > 0
+  AND l.expiry_date IS NOT NULL
+  AND l.expiry_date <= CURRENT_DATE + $1::int
+  AND ($2::int IS NULL OR l.id_from_warehouse = $2)
+ORDER BY l.expiry_date, p.product_id, l.lot_number
+`
+
+type ListExpiringLotsParams struct {
+	Days        int32       `json:"days"`
+	WarehouseID pgtype.Int4 `json:"warehouse_id"`
+}
+
+type ListExpiringLotsRow struct {
+	ID              int32       `json:"id"`
+	IDFromProduct   int32       `json:"id_from_product"`
+	ProductID       string      `json:"product_id"`
+	ProductName     string      `json:"product_name"`
+	IDFromWarehouse int32       `json:"id_from_warehouse"`
+	WarehouseCode   string      `json:"warehouse_code"`
+	LotNumber       string      `json:"lot_number"`
+	ExpiryDate      pgtype.Date `json:"expiry_date"`
+	Quantity        int32       `json:"quantity"`
+	DaysLeft        int32       `json:"days_left"`
+}
+
+// Lots with stock that expire within the given number of days, already expired ones included.
+func (q *Queries) ListExpiringLots(ctx context.Context, arg ListExpiringLotsParams) ([]ListExpiringLotsRow, error) {
+	rows, err := q.db.Query(ctx, listExpiringLots, arg.Days, arg.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpiringLotsRow
+	for rows.Next() {
+		var i ListExpiringLotsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.ProductName,
+			&i.IDFromWarehouse,
+			&i.WarehouseCode,
+			&i.LotNumber,
+			&i.ExpiryDate,
+			&i.Quantity,
+			&i.DaysLeft,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImagesByProductIDs = `-- name: ListImagesByProductIDs :many
+SELECT id, id_from_product, storage_key, thumbnail_key, content_type, size_bytes, width, height, sort_order, created_at FROM product_images
+WHERE id_from_product = ANY($1::int[])
+ORDER BY id_from_product, sort_order, id
+`
+
+func (q *Queries) ListImagesByProductIDs(ctx context.Context, productIds []int32) ([]ProductImage, error) {
+	rows, err := q.db.Query(ctx, listImagesByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductImage
+	for rows.Next() {
+		var i ProductImage
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.StorageKey,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.SizeBytes,
+			&i.Width,
+			&i.Height,
+			&i.SortOrder,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInTransitByProductIDs = `-- name: ListInTransitByProductIDs :many
+SELECT i.id_from_product,
+       SUM(i.quantity)::bigint AS quantity
+FROM stock_transfer_items i
+JOIN stock_transfers t ON t.id = i.id_from_transfer
+WHERE t.status = 'in_transit'
+  AND i.id_from_product = ANY($1::int[])
+GROUP BY i.id_from_product
+`
+
+type ListInTransitByProductIDsRow struct {
+	IDFromProduct int32 `json:"id_from_product"`
+	Quantity      int64 `json:"quantity"`
+}
+
+func (q *Queries) ListInTransitByProductIDs(ctx context.Context, productIds []int32) ([]ListInTransitByProductIDsRow, error) {
+	rows, err := q.db.Query(ctx, listInTransitByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInTransitByProductIDsRow
+	for rows.Next() {
+		var i ListInTransitByProductIDsRow
+		if err := rows.Scan(&i.IDFromProduct, &i.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLotsByProductIDs = `-- name: ListLotsByProductIDs :many
+SELECT id, id_from_product, id_from_warehouse, lot_number, expiry_date, quantity, created_at, updated_at FROM stock_lots
+WHERE id_from_product = ANY($1::int[])
+  AND quantity > 0
+ORDER BY id_from_product, expiry_date NULLS LAST, id
+`
+
+func (q *Queries) ListLotsByProductIDs(ctx context.Context, productIds []int32) ([]StockLot, error) {
+	rows, err := q.db.Query(ctx, listLotsByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockLot
+	for rows.Next() {
+		var i StockLot
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.IDFromWarehouse,
+			&i.LotNumber,
+			&i.ExpiryDate,
+			&i.Quantity,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenCostLayers = `-- name: ListOpenCostLayers :many
+SELECT id, id_from_product, quantity, quantity_remaining, unit_cost_idr, received_at, reference, created_at FROM cost_layers
+WHERE id_from_product = $1
+  AND quantity_remaining > 0
+ORDER BY received_at, id
+FOR UPDATE
+`
+
+// Layers with units left, oldest first; locked so concurrent sales never consume the same unit twice.
+func (q *Queries) ListOpenCostLayers(ctx context.Context, idFromProduct int32) ([]CostLayer, error) {
+	rows, err := q.db.Query(ctx, listOpenCostLayers, idFromProduct)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CostLayer
+	for rows.Next() {
+		var i CostLayer
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.Quantity,
+			&i.QuantityRemaining,
+			&i.UnitCostIdr,
+			&i.ReceivedAt,
+			&i.Reference,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenLots = `-- name: ListOpenLots :many
+SELECT id, id_from_product, id_from_warehouse, lot_number, expiry_date, quantity, created_at, updated_at FROM stock_lots
+WHERE id_from_product = $1
+  AND id_from_warehouse = $2
+  AND quantity > 0
+  AND ($3::bool OR expiry_date IS NULL OR expiry_date >= CURRENT_DATE)
+ORDER BY expiry_date NULLS LAST, id
+FOR UPDATE
+`
+
+type ListOpenLotsParams struct {
+	IDFromProduct   int32 `json:"id_from_product"`
+	IDFromWarehouse int32 `json:"id_from_warehouse"`
+	IncludeExpired  bool  `json:"include_expired"`
+}
+
+// Lots with stock at a location in first-expired-first-out order, locked for the allocation.
+// Expired lots are left out unless include_expired is set (e.g. for write-offs).
+func (q *Queries) ListOpenLots(ctx context.Context, arg ListOpenLotsParams) ([]StockLot, error) {
+	rows, err := q.db.Query(ctx, listOpenLots, arg.IDFromProduct, arg.IDFromWarehouse, arg.IncludeExpired)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockLot
+	for rows.Next() {
+		var i StockLot
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.IDFromWarehouse,
+			&i.LotNumber,
+			&i.ExpiryDate,
+			&i.Quantity,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderItemsByOrderIDs = `-- name: ListOrderItemsByOrderIDs :many
+SELECT i.id,
+       i.id_from_order,
+       i.id_from_product,
+       i.id_from_variant,
+       i.product_id,
+       p.product_name,
+       v.variant_name,
+       i.quantity,
+       i.unit_price_idr,
+       i.line_total_idr,
+       i.unit_cost_idr,
+       i.cogs_idr
+FROM order_items i
+LEFT JOIN products p ON p.id = i.id_from_product
+LEFT JOIN product_variants v ON v.id = i.id_from_variant
+WHERE i.id_from_order = ANY($1::int[])
+ORDER BY i.id_from_order, i.id
+`
+
+type ListOrderItemsByOrderIDsRow struct {
+	ID            int32       `json:"id"`
+	IDFromOrder   int32       `json:"id_from_order"`
+	IDFromProduct pgtype.Int4 `json:"id_from_product"`
+	IDFromVariant pgtype.Int4 `json:"id_from_variant"`
+	ProductID     string      `json:"product_id"`
+	ProductName   pgtype.Text `json:"product_name"`
+	VariantName   pgtype.Text `json:"variant_name"`
+	Quantity      int32       `json:"quantity"`
+	UnitPriceIdr  int64       `json:"unit_price_idr"`
+	LineTotalIdr  int64       `json:"line_total_idr"`
+	UnitCostIdr   int64       `json:"unit_cost_idr"`
+	CogsIdr       pgtype.Int8 `json:"cogs_idr"`
+}
+
+func (q *Queries) ListOrderItemsByOrderIDs(ctx context.Context, orderIds []int32) ([]ListOrderItemsByOrderIDsRow, error) {
+	rows, err := q.db.Query(ctx, listOrderItemsByOrderIDs, orderIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderItemsByOrderIDsRow
+	for rows.Next() {
+		var i ListOrderItemsByOrderIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromOrder,
+			&i.IDFromProduct,
+			&i.IDFromVariant,
+			&i.ProductID,
+			&i.ProductName,
+			&i.VariantName,
+			&i.Quantity,
+			&i.UnitPriceIdr,
+			&i.LineTotalIdr,
+			&i.UnitCostIdr,
+			&i.CogsIdr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderReturnItemsByReturnIDs = `-- name: ListOrderReturnItemsByReturnIDs :many
+SELECT ri.id, ri.id_from_return, ri.id_from_order_item, ri.quantity, ri.serial_numbers, ri.outcome, ri.refund_idr, ri.note,
+       p.id AS id_from_product,
+       i.id_from_variant,
+       i.product_id,
+       p.product_name,
+       p.track_serials,
+       i.unit_price_idr,
+       i.unit_cost_idr,
+       i.quantity AS ordered_qty,
+       i.cogs_idr
+FROM order_return_items ri
+JOIN order_items i ON i.id = ri.id_from_order_item
+JOIN products p ON p.id = i.id_from_product
+WHERE ri.id_from_return = ANY($1::int[])
+ORDER BY ri.id_from_return, ri.id
+`
+
+type ListOrderReturnItemsByReturnIDsRow struct {
+	ID              int32       `json:"id"`
+	IDFromReturn    int32       `json:"id_from_return"`
+	IDFromOrderItem int32       `json:"id_from_order_item"`
+	Quantity        int32       `json:"quantity"`
+	SerialNumbers   []string    `json:"serial_numbers"`
+	Outcome         pgtype.Text `json:"outcome"`
+	RefundIdr       int64       `json:"refund_idr"`
+	Note            pgtype.Text `json:"note"`
+	IDFromProduct   int32       `json:"id_from_product"`
+	IDFromVariant   pgtype.Int4 `json:"id_from_variant"`
+	ProductID       string      `json:"product_id"`
+	ProductName     string      `json:"product_name"`
+	TrackSerials    bool        `json:"track_serials"`
+	UnitPriceIdr    int64       `json:"unit_price_idr"`
+	UnitCostIdr     int64       `json:"unit_cost_idr"`
+	OrderedQty      int32       `json:"ordered_qty"`
+	CogsIdr         pgtype.Int8 `json:"cogs_idr"`
+}
+
+func (q *Queries) ListOrderReturnItemsByReturnIDs(ctx context.Context, returnIds []int32) ([]ListOrderReturnItemsByReturnIDsRow, error) {
+	rows, err := q.db.Query(ctx, listOrderReturnItemsByReturnIDs, returnIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderReturnItemsByReturnIDsRow
+	for rows.Next() {
+		var i ListOrderReturnItemsByReturnIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromReturn,
+			&i.IDFromOrderItem,
+			&i.Quantity,
+			&i.SerialNumbers,
+			&i.Outcome,
+			&i.RefundIdr,
+			&i.Note,
+			&i.IDFromProduct,
+			&i.IDFromVariant,
+			&i.ProductID,
+			&i.ProductName,
+			&i.TrackSerials,
+			&i.UnitPriceIdr,
+			&i.UnitCostIdr,
+			&i.OrderedQty,
+			&i.CogsIdr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderReturns = `-- name: ListOrderReturns :many
+SELECT r.id, r.id_from_order, r.status, r.reason, r.id_from_warehouse, r.refund_idr, r.note, r.requested_by, r.inspected_by, r.created_at, r.inspected_at,
+       o.order_number
+FROM order_returns r
+JOIN orders o ON o.id = r.id_from_order
+WHERE ($1::int IS NULL OR r.id = $1)
+  AND ($2::int IS NULL OR r.id_from_order = $2)
+  AND ($3::text IS NULL OR r.status = $3)
+  AND ($4::timestamptz IS NULL OR r.created_at >= $4)
+  AND ($5::timestamptz IS NULL OR r.created_at < $5)
+ORDER BY r.id DESC
+LIMIT $6 OFFSET $7
+`
+
+type ListOrderReturnsParams struct {
+	ReturnID    pgtype.Int4        `json:"return_id"`
+	OrderID     pgtype.Int4        `json:"order_id"`
+	Status      pgtype.Text        `json:"status"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+	RowLimit    int32              `json:"row_limit"`
+	RowOffset   int32              `json:"row_offset"`
+}
+
+type ListOrderReturnsRow struct {
+	ID              int32              `json:"id"`
+	IDFromOrder     int32              `json:"id_from_order"`
+	Status          string             `json:"status"`
+	Reason          string             `json:"reason"`
+	IDFromWarehouse int32              `json:"id_from_warehouse"`
+	RefundIdr       int64              `json:"refund_idr"`
+	Note            pgtype.Text        `json:"note"`
+	RequestedBy     pgtype.Int4        `json:"requested_by"`
+	InspectedBy     pgtype.Int4        `json:"inspected_by"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	InspectedAt     pgtype.Timestamptz `json:"inspected_at"`
+	OrderNumber     string             `json:"order_number"`
+}
+
+func (q *Queries) ListOrderReturns(ctx context.Context, arg ListOrderReturnsParams) ([]ListOrderReturnsRow, error) {
+	rows, err := q.db.Query(ctx, listOrderReturns,
+		arg.ReturnID,
+		arg.OrderID,
+		arg.Status,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderReturnsRow
+	for rows.Next() {
+		var i ListOrderReturnsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromOrder,
+			&i.Status,
+			&i.Reason,
+			&i.IDFromWarehouse,
+			&i.RefundIdr,
+			&i.Note,
+			&i.RequestedBy,
+			&i.InspectedBy,
+			&i.CreatedAt,
+			&i.InspectedAt,
+			&i.OrderNumber,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderStatusHistory = `-- name: ListOrderStatusHistory :many
+SELECT h.id,
+       h.id_from_order,
+       h.from_status,
+       h.to_status,
+       h.changed_by,
+       u.full_name AS changed_by_name,
+       h.note,
+       h.created_at
+FROM order_status_history h
+LEFT JOIN users u ON u.id = h.changed_by
+WHERE h.id_from_order = $1
+ORDER BY h.created_at, h.id
+`
+
+type ListOrderStatusHistoryRow struct {
+	ID            int32              `json:"id"`
+	IDFromOrder   int32              `json:"id_from_order"`
+	FromStatus    pgtype.Text        `json:"from_status"`
+	ToStatus      string             `json:"to_status"`
+	ChangedBy     pgtype.Int4        `json:"changed_by"`
+	ChangedByName pgtype.Text        `json:"changed_by_name"`
+	Note          pgtype.Text        `json:"note"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) ListOrderStatusHistory(ctx context.Context, idFromOrder int32) ([]ListOrderStatusHistoryRow, error) {
+	rows, err := q.db.Query(ctx, listOrderStatusHistory, idFromOrder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderStatusHistoryRow
+	for rows.Next() {
+		var i ListOrderStatusHistoryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromOrder,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.ChangedBy,
+			&i.ChangedByName,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrders = `-- name: ListOrders :many
+SELECT id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by FROM orders
+WHERE deleted_at IS NULL
+  AND ($1::text IS NULL
+       OR (CASE lower(status) WHEN 'shipped' THEN 'shipping' ELSE lower(status) END) = $1)
+  AND ($2::text IS NULL OR platform ILIKE $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+  AND ($5::text IS NULL OR customer_name ILIKE '%' || $5 || '%')
+  AND ($6::text IS NULL OR order_number ILIKE '%' || $6 || '%')
+ORDER BY id DESC
+LIMIT $7 OFFSET $8
+`
+
+type ListOrdersParams struct {
+	Status      pgtype.Text        `json:"status"`
+	Platform    pgtype.Text        `json:"platform"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+	Customer    pgtype.Text        `json:"customer"`
+	Search      pgtype.Text        `json:"search"`
+	RowLimit    int32              `json:"row_limit"`
+	RowOffset   int32              `json:"row_offset"`
+}
+
+// Filters are optional; status matches the normalized status (legacy "shipped" is shipping),
+// customer and search (order number) match substrings.
+func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrders,
+		arg.Status,
+		arg.Platform,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Customer,
+		arg.Search,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderNumber,
+			&i.CustomerName,
+			&i.TotalAmount,
+			&i.Status,
+			&i.Platform,
+			&i.Destination,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CogsIdr,
+			&i.IDFromWarehouse,
+			&i.TotalIdr,
+			&i.CancelReason,
+			&i.CancelledAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPricesByProductIDs = `-- name: ListPricesByProductIDs :many
+SELECT id, id_from_product, price_idr, effective_at, applied_at, note, created_at FROM product_prices
+WHERE id_from_product = ANY($1::int[])
+ORDER BY id_from_product, effective_at DESC, id DESC
+`
+
+func (q *Queries) ListPricesByProductIDs(ctx context.Context, productIds []int32) ([]ProductPrice, error) {
+	rows, err := q.db.Query(ctx, listPricesByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductPrice
+	for rows.Next() {
+		var i ProductPrice
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.PriceIdr,
+			&i.EffectiveAt,
+			&i.AppliedAt,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProducts = `-- name: ListProducts :many
+SELECT
+  id,
+  product_id,
+  product_name,
+  supplier_name,
+  category,
+  price_idr,
+  stock,
+  created_at,
+  updated_at,
+  cost_idr,
+  track_serials,
+  is_bundle,
+  base_unit
+FROM products
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListProductsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error) {
+	rows, err := q.db.Query(ctx, listProducts, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.SupplierName,
+			&i.Category,
+			&i.PriceIdr,
+			&i.Stock,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CostIdr,
+			&i.TrackSerials,
+			&i.IsBundle,
+			&i.BaseUnit,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurchaseOrderItems = `-- name: ListPurchaseOrderItems :many
+SELECT i.id,
+       i.id_from_purchase_order,
+       i.id_from_product,
+       p.product_id,
+       p.product_name,
+       p.base_unit,
+       i.quantity,
+       i.unit_cost_idr,
+       i.received_qty
+FROM purchase_order_items i
+JOIN products p ON p.id = i.id_from_product
+WHERE i.id_from_purchase_order = $1
+ORDER BY i.id
+`
+
+type ListPurchaseOrderItemsRow struct {
+	ID                  int32  `json:"id"`
+	IDFromPurchaseOrder int32  `json:"id_from_purchase_order"`
+	IDFromProduct       int32  `json:"id_from_product"`
+	ProductID           string `json:"product_id"`
+	ProductName         string `json:"product_name"`
+	BaseUnit            string `json:"base_unit"`
+	Quantity            int32  `json:"quantity"`
+	UnitCostIdr         int64  `json:"unit_cost_idr"`
+	ReceivedQty         int32  `json:"received_qty"`
+}
+
+func (q *Queries) ListPurchaseOrderItems(ctx context.Context, idFromPurchaseOrder int32) ([]ListPurchaseOrderItemsRow, error) {
+	rows, err := q.db.Query(ctx, listPurchaseOrderItems, idFromPurchaseOrder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPurchaseOrderItemsRow
+	for rows.Next() {
+		var i ListPurchaseOrderItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromPurchaseOrder,
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.ProductName,
+			&i.BaseUnit,
+			&i.Quantity,
+			&i.UnitCostIdr,
+			&i.ReceivedQty,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurchaseOrders = `-- name: ListPurchaseOrders :many
+SELECT id, po_number, supplier_name, id_from_warehouse, status, note, expected_at, sent_at, closed_at, created_at, updated_at FROM purchase_orders
+WHERE ($1::text IS NULL OR status = $1)
+  AND ($2::text IS NULL OR supplier_name = $2)
+ORDER BY id DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListPurchaseOrdersParams struct {
+	Status       pgtype.Text `json:"status"`
+	SupplierName pgtype.Text `json:"supplier_name"`
+	RowLimit     int32       `json:"row_limit"`
+	RowOffset    int32       `json:"row_offset"`
+}
+
+func (q *Queries) ListPurchaseOrders(ctx context.Context, arg ListPurchaseOrdersParams) ([]PurchaseOrder, error) {
+	rows, err := q.db.Query(ctx, listPurchaseOrders,
+		arg.Status,
+		arg.SupplierName,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PurchaseOrder
+	for rows.Next() {
+		var i PurchaseOrder
+		if err := rows.Scan(
+			&i.ID,
+			&i.PoNumber,
+			&i.SupplierName,
+			&i.IDFromWarehouse,
+			&i.Status,
+			&i.Note,
+			&i.ExpectedAt,
+			&i.SentAt,
+			&i.ClosedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurchaseReceipts = `-- name: ListPurchaseReceipts :many
+SELECT id, id_from_purchase_order, reference, received_at FROM purchase_receipts
+WHERE id_from_purchase_order = $1
+ORDER BY received_at, id
+`
+
+func (q *Queries) ListPurchaseReceipts(ctx context.Context, idFromPurchaseOrder int32) ([]PurchaseReceipt, error) {
+	rows, err := q.db.Query(ctx, listPurchaseReceipts, idFromPurchaseOrder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PurchaseReceipt
+	for rows.Next() {
+		var i PurchaseReceipt
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromPurchaseOrder,
+			&i.Reference,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReservedByProductIDs = `-- name: ListReservedByProductIDs :many
+SELECT id_from_product,
+       id_from_warehouse,
+       SUM(quantity)::bigint AS quantity
+FROM stock_reservations
+WHERE status = 'active'
+  AND id_from_product = ANY($1::int[])
+GROUP BY id_from_product, id_from_warehouse
+`
+
+type ListReservedByProductIDsRow struct {
+	IDFromProduct   int32 `json:"id_from_product"`
+	IDFromWarehouse int32 `json:"id_from_warehouse"`
+	Quantity        int64 `json:"quantity"`
+}
+
+func (q *Queries) ListReservedByProductIDs(ctx context.Context, productIds []int32) ([]ListReservedByProductIDsRow, error) {
+	rows, err := q.db.Query(ctx, listReservedByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReservedByProductIDsRow
+	for rows.Next() {
+		var i ListReservedByProductIDsRow
+		if err := rows.Scan(&i.IDFromProduct, &i.IDFromWarehouse, &i.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReturnedQuantitiesByOrderID = `-- name: ListReturnedQuantitiesByOrderID :many
+SELECT ri.id_from_order_item,
+       SUM(ri.quantity)::bigint AS returned_qty
+FROM order_return_items ri
+JOIN order_returns r ON r.id = ri.id_from_return
+WHERE r.id_from_order = $1
+  AND r.status <> 'rejected'
+GROUP BY ri.id_from_order_item
+`
+
+type ListReturnedQuantitiesByOrderIDRow struct {
+	IDFromOrderItem int32 `json:"id_from_order_item"`
+	ReturnedQty     int64 `json:"returned_qty"`
+}
+
+// Quantity per order line already in returns that were not rejected.
+func (q *Queries) ListReturnedQuantitiesByOrderID(ctx context.Context, idFromOrder int32) ([]ListReturnedQuantitiesByOrderIDRow, error) {
+	rows, err := q.db.Query(ctx, listReturnedQuantitiesByOrderID, idFromOrder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReturnedQuantitiesByOrderIDRow
+	for rows.Next() {
+		var i ListReturnedQuantitiesByOrderIDRow
+		if err := rows.Scan(&i.IDFromOrderItem, &i.ReturnedQty); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSerialEventsBySerialIDs = `-- name: ListSerialEventsBySerialIDs :many
+SELECT e.id,
+       e.id_from_serial,
+       e.event,
+       e.id_from_order,
+       o.order_number,
+       o.customer_name,
+       e.reference,
+       e.created_at
+FROM serial_events e
+LEFT JOIN orders o ON o.id = e.id_from_order
+WHERE e.id_from_serial = ANY($1::int[])
+ORDER BY e.id_from_serial, e.created_at, e.id
+`
+
+type ListSerialEventsBySerialIDsRow struct {
+	ID           int32              `json:"id"`
+	IDFromSerial int32              `json:"id_from_serial"`
+	Event        string             `json:"event"`
+	IDFromOrder  pgtype.Int4        `json:"id_from_order"`
+	OrderNumber  pgtype.Text        `json:"order_number"`
+	CustomerName pgtype.Text        `json:"customer_name"`
+	Reference    pgtype.Text        `json:"reference"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) ListSerialEventsBySerialIDs(ctx context.Context, serialIds []int32) ([]ListSerialEventsBySerialIDsRow, error) {
+	rows, err := q.db.Query(ctx, listSerialEventsBySerialIDs, serialIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSerialEventsBySerialIDsRow
+	for rows.Next() {
+		var i ListSerialEventsBySerialIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromSerial,
+			&i.Event,
+			&i.IDFromOrder,
+			&i.OrderNumber,
+			&i.CustomerName,
+			&i.Reference,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSerialsByNumber = `-- name: ListSerialsByNumber :many
+SELECT s.id,
+       s.id_from_product,
+       p.product_id,
+       p.product_name,
+       s.serial_number,
+       s.status,
+       s.id_from_warehouse,
+       s.id_from_order,
+       s.created_at,
+       s.updated_at
+FROM serial_numbers s
+JOIN products p ON p.id = s.id_from_product
+WHERE s.serial_number = $1
+ORDER BY s.id
+`
+
+type ListSerialsByNumberRow struct {
+	ID              int32              `json:"id"`
+	IDFromProduct   int32              `json:"id_from_product"`
+	ProductID       string             `json:"product_id"`
+	ProductName     string             `json:"product_name"`
+	SerialNumber    string             `json:"serial_number"`
+	Status          string             `json:"status"`
+	IDFromWarehouse pgtype.Int4        `json:"id_from_warehouse"`
+	IDFromOrder     pgtype.Int4        `json:"id_from_order"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) ListSerialsByNumber(ctx context.Context, serialNumber string) ([]ListSerialsByNumberRow, error) {
+	rows, err := q.db.Query(ctx, listSerialsByNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSerialsByNumberRow
+	for rows.Next() {
+		var i ListSerialsByNumberRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.ProductName,
+			&i.SerialNumber,
+			&i.Status,
+			&i.IDFromWarehouse,
+			&i.IDFromOrder,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSerialsByProduct = `-- name: ListSerialsByProduct :many
+SELECT id, id_from_product, serial_number, status, id_from_warehouse, id_from_order, created_at, updated_at FROM serial_numbers
+WHERE id_from_product = $1
+  AND ($2::text IS NULL OR status = $2)
+ORDER BY serial_number
+LIMIT $3 OFFSET $4
+`
+
+type ListSerialsByProductParams struct {
+	IDFromProduct int32       `json:"id_from_product"`
+	Status        pgtype.Text `json:"status"`
+	RowLimit      int32       `json:"row_limit"`
+	RowOffset     int32       `json:"row_offset"`
+}
+
+func (q *Queries) ListSerialsByProduct(ctx context.Context, arg ListSerialsByProductParams) ([]SerialNumber, error) {
+	rows, err := q.db.Query(ctx, listSerialsByProduct,
+		arg.IDFromProduct,
+		arg.Status,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SerialNumber
+	for rows.Next() {
+		var i SerialNumber
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.SerialNumber,
+			&i.Status,
+			&i.IDFromWarehouse,
+			&i.IDFromOrder,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStockMovementsByProduct = `-- name: ListStockMovementsByProduct :many
+SELECT id, id_from_product, quantity, stock_after, reason, reference, created_at FROM stock_movements
+WHERE id_from_product = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListStockMovementsByProductParams struct {
+	IDFromProduct int32 `json:"id_from_product"`
+	Limit         int32 `json:"limit"`
+	Offset        int32 `json:"offset"`
+}
+
+func (q *Queries) ListStockMovementsByProduct(ctx context.Context, arg ListStockMovementsByProductParams) ([]StockMovement, error) {
+	rows, err := q.db.Query(ctx, listStockMovementsByProduct, arg.IDFromProduct, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockMovement
+	for rows.Next() {
+		var i StockMovement
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.Quantity,
+			&i.StockAfter,
+			&i.Reason,
+			&i.Reference,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStockTakeItems = `-- name: ListStockTakeItems :many
+SELECT i.id,
+       i.id_from_stock_take,
+       i.id_from_product,
+       p.product_id,
+       p.product_name,
+       p.cost_idr,
+       i.expected_qty,
+       i.counted_qty,
+       i.counted_at
+FROM stock_take_items i
+JOIN products p ON p.id = i.id_from_product
+WHERE i.id_from_stock_take = $1
+ORDER BY p.product_id
+`
+
+type ListStockTakeItemsRow struct {
+	ID              int32              `json:"id"`
+	IDFromStockTake int32              `json:"id_from_stock_take"`
+	IDFromProduct   int32              `json:"id_from_product"`
+	ProductID       string             `json:"product_id"`
+	ProductName     string             `json:"product_name"`
+	CostIdr         int64              `json:"cost_idr"`
+	ExpectedQty     int32              `json:"expected_qty"`
+	CountedQty      pgtype.Int4        `json:"counted_qty"`
+	CountedAt       pgtype.Timestamptz `json:"counted_at"`
+}
+
+func (q *Queries) ListStockTakeItems(ctx context.Context, idFromStockTake int32) ([]ListStockTakeItemsRow, error) {
+	rows, err := q.db.Query(ctx, listStockTakeItems, idFromStockTake)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStockTakeItemsRow
+	for rows.Next() {
+		var i ListStockTakeItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromStockTake,
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.ProductName,
+			&i.CostIdr,
+			&i.ExpectedQty,
+			&i.CountedQty,
+			&i.CountedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStockTakes = `-- name: ListStockTakes :many
+SELECT id, id_from_warehouse, status, note, created_by, approved_by, created_at, completed_at FROM stock_takes
+WHERE ($1::text IS NULL OR status = $1)
+ORDER BY id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListStockTakesParams struct {
+	Status    pgtype.Text `json:"status"`
+	RowLimit  int32       `json:"row_limit"`
+	RowOffset int32       `json:"row_offset"`
+}
+
+func (q *Queries) ListStockTakes(ctx context.Context, arg ListStockTakesParams) ([]StockTake, error) {
+	rows, err := q.db.Query(ctx, listStockTakes, arg.Status, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockTake
+	for rows.Next() {
+		var i StockTake
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromWarehouse,
+			&i.Status,
+			&i.Note,
+			&i.CreatedBy,
+			&i.ApprovedBy,
+			&i.CreatedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStockTransferItems = `-- name: ListStockTransferItems :many
+SELECT i.id,
+       i.id_from_transfer,
+       i.id_from_product,
+       p.product_id,
+       p.product_name,
+       i.quantity,
+       i.lot_number,
+       i.expiry_date,
+       i.serial_numbers
+FROM stock_transfer_items i
+JOIN products p ON p.id = i.id_from_product
+WHERE i.id_from_transfer = $1
+ORDER BY i.id
+`
+
+type ListStockTransferItemsRow struct {
+	ID             int32       `json:"id"`
+	IDFromTransfer int32       `json:"id_from_transfer"`
+	IDFromProduct  int32       `json:"id_from_product"`
+	ProductID      string      `json:"product_id"`
+	ProductName    string      `json:"product_name"`
+	Quantity       int32       `json:"quantity"`
+	LotNumber      pgtype.Text `json:"lot_number"`
+	ExpiryDate     pgtype.Date `json:"expiry_date"`
+	SerialNumbers  []string    `json:"serial_numbers"`
+}
+
+func (q *Queries) ListStockTransferItems(ctx context.Context, idFromTransfer int32) ([]ListStockTransferItemsRow, error) {
+	rows, err := q.db.Query(ctx, listStockTransferItems, idFromTransfer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStockTransferItemsRow
+	for rows.Next() {
+		var i ListStockTransferItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromTransfer,
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Quantity,
+			&i.LotNumber,
+			&i.ExpiryDate,
+			&i.SerialNumbers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStockTransfers = `-- name: ListStockTransfers :many
+SELECT id, id_from_source, id_from_destination, status, note, created_at, completed_at FROM stock_transfers
+WHERE ($1::text IS NULL OR status = $1)
+ORDER BY id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListStockTransfersParams struct {
+	Status    pgtype.Text `json:"status"`
+	RowLimit  int32       `json:"row_limit"`
+	RowOffset int32       `json:"row_offset"`
+}
+
+func (q *Queries) ListStockTransfers(ctx context.Context, arg ListStockTransfersParams) ([]StockTransfer, error) {
+	rows, err := q.db.Query(ctx, listStockTransfers, arg.Status, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockTransfer
+	for rows.Next() {
+		var i StockTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromSource,
+			&i.IDFromDestination,
+			&i.Status,
+			&i.Note,
+			&i.CreatedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnitsByProductIDs = `-- name: ListUnitsByProductIDs :many
+SELECT id, id_from_product, unit_name, factor, created_at FROM product_units
+WHERE id_from_product = ANY($1::int[])
+ORDER BY id_from_product, factor DESC
+`
+
+func (q *Queries) ListUnitsByProductIDs(ctx context.Context, productIds []int32) ([]ProductUnit, error) {
+	rows, err := q.db.Query(ctx, listUnitsByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductUnit
+	for rows.Next() {
+		var i ProductUnit
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.UnitName,
+			&i.Factor,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT 
+  id,
+  user_id,
+  username, 
+  email, 
+  full_name, 
+  role, 
+  permissions,
+  created_at, 
+  updated_at
+FROM users
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListUsersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListUsersRow struct {
+	ID          int32            `json:"id"`
+	UserID      string           `json:"user_id"`
+	Username    string           `json:"username"`
+	Email       string           `json:"email"`
+	FullName    string           `json:"full_name"`
+	Role        string           `json:"role"`
+	Permissions []byte           `json:"permissions"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	rows, err := q.db.Query(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Username,
+			&i.Email,
+			&i.FullName,
+			&i.Role,
+			&i.Permissions,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVariantsByProduct = `-- name: ListVariantsByProduct :many
+SELECT id, id_from_product, product_id, variant_name, price_idr, stock, attributes, created_at, updated_at FROM product_variants
+WHERE id_from_product = $1
+ORDER BY id
+`
+
+func (q *Queries) ListVariantsByProduct(ctx context.Context, idFromProduct int32) ([]ProductVariant, error) {
+	rows, err := q.db.Query(ctx, listVariantsByProduct, idFromProduct)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductVariant
+	for rows.Next() {
+		var i ProductVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.VariantName,
+			&i.PriceIdr,
+			&i.Stock,
+			&i.Attributes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVariantsByProductIDs = `-- name: ListVariantsByProductIDs :many
+SELECT id, id_from_product, product_id, variant_name, price_idr, stock, attributes, created_at, updated_at FROM product_variants
+WHERE id_from_product = ANY($1::int[])
+ORDER BY id_from_product, id
+`
+
+func (q *Queries) ListVariantsByProductIDs(ctx context.Context, productIds []int32) ([]ProductVariant, error) {
+	rows, err := q.db.Query(ctx, listVariantsByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductVariant
+	for rows.Next() {
+		var i ProductVariant
+		if err := rows.Scan(
 			&i.ID,
+			&i.IDFromProduct,
+			&i.ProductID,
+			&i.VariantName,
+			&i.PriceIdr,
+			&i.Stock,
+			&i.Attributes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWarehouseStock = `-- name: ListWarehouseStock :many
+SELECT s.id_from_product,
+       p.product_id,
+       p.product_name,
+       s.quantity,
+       s.updated_at
+FROM warehouse_stock s
+JOIN products p ON p.id = s.id_from_product
+WHERE s.id_from_warehouse = $1
+  AND s.quantity > 0
+ORDER BY p.product_id
+`
+
+type ListWarehouseStockRow struct {
+	IDFromProduct int32              `json:"id_from_product"`
+	ProductID     string             `json:"product_id"`
+	ProductName   string             `json:"product_name"`
+	Quantity      int32              `json:"quantity"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) ListWarehouseStock(ctx context.Context, idFromWarehouse int32) ([]ListWarehouseStockRow, error) {
+	rows, err := q.db.Query(ctx, listWarehouseStock, idFromWarehouse)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWarehouseStockRow
+	for rows.Next() {
+		var i ListWarehouseStockRow
+		if err := rows.Scan(
+			&i.IDFromProduct,
 			&i.ProductID,
 			&i.ProductName,
-			&i.SupplierName,
-			&i.Category,
-			&i.PriceIdr,
-			&i.Stock,
-			&i.CreatedAt,
+			&i.Quantity,
 			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -470,58 +4559,73 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 	return items, nil
 }
 
-const listUsers = `-- name: ListUsers :many
-SELECT 
-  id,
-  user_id,
-  username, 
-  email, 
-  full_name, 
-  role, 
-  permissions,
-  created_at, 
-  updated_at
-FROM users
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+const listWarehouseStockByProductIDs = `-- name: ListWarehouseStockByProductIDs :many
+SELECT s.id_from_product,
+       s.id_from_warehouse,
+       w.code,
+       w.name,
+       s.quantity
+FROM warehouse_stock s
+JOIN warehouses w ON w.id = s.id_from_warehouse
+WHERE s.id_from_product = ANY($1::int[])
+ORDER BY s.id_from_product, w.is_default DESC, w.code
 `
 
-type ListUsersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListWarehouseStockByProductIDsRow struct {
+	IDFromProduct   int32  `json:"id_from_product"`
+	IDFromWarehouse int32  `json:"id_from_warehouse"`
+	Code            string `json:"code"`
+	Name            string `json:"name"`
+	Quantity        int32  `json:"quantity"`
 }
 
-type ListUsersRow struct {
-	ID          int32            `json:"id"`
-	UserID      string           `json:"user_id"`
-	Username    string           `json:"username"`
-	Email       string           `json:"email"`
-	FullName    string           `json:"full_name"`
-	Role        string           `json:"role"`
-	Permissions []byte           `json:"permissions"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+func (q *Queries) ListWarehouseStockByProductIDs(ctx context.Context, productIds []int32) ([]ListWarehouseStockByProductIDsRow, error) {
+	rows, err := q.db.Query(ctx, listWarehouseStockByProductIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWarehouseStockByProductIDsRow
+	for rows.Next() {
+		var i ListWarehouseStockByProductIDsRow
+		if err := rows.Scan(
+			&i.IDFromProduct,
+			&i.IDFromWarehouse,
+			&i.Code,
+			&i.Name,
+			&i.Quantity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
-	rows, err := q.db.Query(ctx, listUsers, arg.Limit, arg.Offset)
+const listWarehouses = `-- name: ListWarehouses :many
+SELECT id, code, name, address, is_default, created_at FROM warehouses
+ORDER BY is_default DESC, code
+`
+
+func (q *Queries) ListWarehouses(ctx context.Context) ([]Warehouse, error) {
+	rows, err := q.db.Query(ctx, listWarehouses)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListUsersRow
+	var items []Warehouse
 	for rows.Next() {
-		var i ListUsersRow
+		var i Warehouse
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
-			&i.Username,
-			&i.Email,
-			&i.FullName,
-			&i.Role,
-			&i.Permissions,
+			&i.Code,
+			&i.Name,
+			&i.Address,
+			&i.IsDefault,
 			&i.CreatedAt,
-			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -533,25 +4637,497 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUse
 	return items, nil
 }
 
-const nextProductSequence = `-- name: NextProductSequence :one
+const markPriceApplied = `-- name: MarkPriceApplied :exec
+UPDATE product_prices
+SET applied_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkPriceApplied(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, markPriceApplied, id)
+	return err
+}
+
+const nextOrderNumberSequence = `-- name: NextOrderNumberSequence :one
+INSERT INTO order_number_counters (period, last_value)
+VALUES ($1, 1)
+ON CONFLICT (period) DO UPDATE
+SET last_value = order_number_counters.last_value + 1,
+    updated_at = now()
+RETURNING last_value
+`
+
+// Takes the next order number of a period; the row lock serializes concurrent orders.
+func (q *Queries) NextOrderNumberSequence(ctx context.Context, period string) (int64, error) {
+	row := q.db.QueryRow(ctx, nextOrderNumberSequence, period)
+	var last_value int64
+	err := row.Scan(&last_value)
+	return last_value, err
+}
+
+const nextProductSequence = `-- name: NextProductSequence :one
+
+SELECT nextval('products_id_seq') as seq
+`
+
+// Utility queries
+// This is a helper to get a next sequence number for product id generation if you prefer DB-side sequence.
+func (q *Queries) NextProductSequence(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, nextProductSequence)
+	var seq int64
+	err := row.Scan(&seq)
+	return seq, err
+}
+
+const peekOrderNumberSequence = `-- name: PeekOrderNumberSequence :one
+SELECT (COALESCE(MAX(last_value), 0) + 1)::bigint AS next_value
+FROM order_number_counters
+WHERE period = $1
+`
+
+// The number the next order of a period would get, without taking it.
+func (q *Queries) PeekOrderNumberSequence(ctx context.Context, period string) (int64, error) {
+	row := q.db.QueryRow(ctx, peekOrderNumberSequence, period)
+	var next_value int64
+	err := row.Scan(&next_value)
+	return next_value, err
+}
+
+const purgeDeletedOrders = `-- name: PurgeDeletedOrders :execrows
+DELETE FROM orders
+WHERE deleted_at < $1
+`
+
+// Removes soft-deleted orders for good; their lines, reservations and history cascade.
+func (q *Queries) PurgeDeletedOrders(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedOrders, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const receivePurchaseOrderItem = `-- name: ReceivePurchaseOrderItem :one
+UPDATE purchase_order_items
+SET received_qty = received_qty + $2
+WHERE id = $1
+RETURNING id, id_from_purchase_order, id_from_product, quantity, unit_cost_idr, received_qty
+`
+
+type ReceivePurchaseOrderItemParams struct {
+	ID          int32 `json:"id"`
+	ReceivedQty int32 `json:"received_qty"`
+}
+
+// Adds to the received quantity; the CHECK rejects receiving more than was ordered.
+func (q *Queries) ReceivePurchaseOrderItem(ctx context.Context, arg ReceivePurchaseOrderItemParams) (PurchaseOrderItem, error) {
+	row := q.db.QueryRow(ctx, receivePurchaseOrderItem, arg.ID, arg.ReceivedQty)
+	var i PurchaseOrderItem
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromPurchaseOrder,
+		&i.IDFromProduct,
+		&i.Quantity,
+		&i.UnitCostIdr,
+		&i.ReceivedQty,
+	)
+	return i, err
+}
+
+const recordStockTakeCount = `-- name: RecordStockTakeCount :one
+INSERT INTO stock_take_items (id_from_stock_take, id_from_product, expected_qty, counted_qty, counted_at)
+VALUES (
+  $1,
+  $2,
+  COALESCE((SELECT ws.quantity FROM warehouse_stock ws
+            WHERE ws.id_from_warehouse = $3
+              AND ws.id_from_product = $2), 0),
+  $4,
+  now()
+)
+ON CONFLICT (id_from_stock_take, id_from_product) DO UPDATE
+SET counted_qty = CASE WHEN $5::boolean
+                       THEN COALESCE(stock_take_items.counted_qty, 0) + EXCLUDED.counted_qty
+                       ELSE EXCLUDED.counted_qty END,
+    counted_at = now()
+RETURNING id, id_from_stock_take, id_from_product, expected_qty, counted_qty, counted_at
+`
+
+type RecordStockTakeCountParams struct {
+	IDFromStockTake int32       `json:"id_from_stock_take"`
+	IDFromProduct   int32       `json:"id_from_product"`
+	IDFromWarehouse int32       `json:"id_from_warehouse"`
+	CountedQty      pgtype.Int4 `json:"counted_qty"`
+	AddToCount      bool        `json:"add_to_count"`
+}
+
+// products found on the shelf but missing from the snapshot are added with the current
+// location balance as expected quantity; add_to_count sums scans instead of overwriting
+func (q *Queries) RecordStockTakeCount(ctx context.Context, arg RecordStockTakeCountParams) (StockTakeItem, error) {
+	row := q.db.QueryRow(ctx, recordStockTakeCount,
+		arg.IDFromStockTake,
+		arg.IDFromProduct,
+		arg.IDFromWarehouse,
+		arg.CountedQty,
+		arg.AddToCount,
+	)
+	var i StockTakeItem
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromStockTake,
+		&i.IDFromProduct,
+		&i.ExpectedQty,
+		&i.CountedQty,
+		&i.CountedAt,
+	)
+	return i, err
+}
+
+const releaseCostLayerConsumptions = `-- name: ReleaseCostLayerConsumptions :exec
+WITH released AS (
+  DELETE FROM cost_layer_consumptions
+  WHERE id_from_order = $1
+  RETURNING id_from_layer, quantity
+)
+UPDATE cost_layers l
+SET quantity_remaining = l.quantity_remaining + r.quantity
+FROM (SELECT id_from_layer, SUM(quantity)::int AS quantity FROM released GROUP BY id_from_layer) r
+WHERE l.id = r.id_from_layer
+`
+
+// Puts the units an unshipped order consumed back on their layers and drops the consumptions.
+func (q *Queries) ReleaseCostLayerConsumptions(ctx context.Context, idFromOrder pgtype.Int4) error {
+	_, err := q.db.Exec(ctx, releaseCostLayerConsumptions, idFromOrder)
+	return err
+}
+
+const releaseLotAllocations = `-- name: ReleaseLotAllocations :exec
+WITH released AS (
+  DELETE FROM lot_allocations
+  WHERE id_from_order = $1
+  RETURNING id_from_lot, quantity
+)
+UPDATE stock_lots l
+SET quantity = l.quantity + r.quantity,
+    updated_at = now()
+FROM (SELECT id_from_lot, SUM(quantity)::int AS quantity FROM released GROUP BY id_from_lot) r
+WHERE l.id = r.id_from_lot
+`
+
+// Puts the units allocated to an unshipped order back into their lots and drops the allocations.
+func (q *Queries) ReleaseLotAllocations(ctx context.Context, idFromOrder int32) error {
+	_, err := q.db.Exec(ctx, releaseLotAllocations, idFromOrder)
+	return err
+}
+
+const restoreOrder = `-- name: RestoreOrder :one
+UPDATE orders
+SET deleted_at = NULL,
+    deleted_by = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by
+`
+
+func (q *Queries) RestoreOrder(ctx context.Context, id int32) (Order, error) {
+	row := q.db.QueryRow(ctx, restoreOrder, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerName,
+		&i.TotalAmount,
+		&i.Status,
+		&i.Platform,
+		&i.Destination,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const saveIdempotencyResponse = `-- name: SaveIdempotencyResponse :exec
+UPDATE idempotency_keys
+SET status_code = $5,
+    content_type = $6,
+    response_body = $7,
+    locked_until = NULL
+WHERE idempotency_key = $1 AND method = $2 AND path = $3 AND caller = $4
+`
+
+type SaveIdempotencyResponseParams struct {
+	IdempotencyKey string      `json:"idempotency_key"`
+	Method         string      `json:"method"`
+	Path           string      `json:"path"`
+	Caller         string      `json:"caller"`
+	StatusCode     pgtype.Int4 `json:"status_code"`
+	ContentType    pgtype.Text `json:"content_type"`
+	ResponseBody   []byte      `json:"response_body"`
+}
+
+func (q *Queries) SaveIdempotencyResponse(ctx context.Context, arg SaveIdempotencyResponseParams) error {
+	_, err := q.db.Exec(ctx, saveIdempotencyResponse,
+		arg.IdempotencyKey,
+		arg.Method,
+		arg.Path,
+		arg.Caller,
+		arg.StatusCode,
+		arg.ContentType,
+		arg.ResponseBody,
+	)
+	return err
+}
+
+const setOrderCancellation = `-- name: SetOrderCancellation :one
+UPDATE orders
+SET cancel_reason = $1,
+    cancelled_at = now()
+WHERE id = $2
+RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by
+`
+
+type SetOrderCancellationParams struct {
+	CancelReason pgtype.Text `json:"cancel_reason"`
+	ID           int32       `json:"id"`
+}
+
+func (q *Queries) SetOrderCancellation(ctx context.Context, arg SetOrderCancellationParams) (Order, error) {
+	row := q.db.QueryRow(ctx, setOrderCancellation, arg.CancelReason, arg.ID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerName,
+		&i.TotalAmount,
+		&i.Status,
+		&i.Platform,
+		&i.Destination,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const setOrderReturnItemOutcome = `-- name: SetOrderReturnItemOutcome :exec
+UPDATE order_return_items
+SET outcome = $2,
+    refund_idr = $3,
+    note = $4
+WHERE id = $1
+`
+
+type SetOrderReturnItemOutcomeParams struct {
+	ID        int32       `json:"id"`
+	Outcome   pgtype.Text `json:"outcome"`
+	RefundIdr int64       `json:"refund_idr"`
+	Note      pgtype.Text `json:"note"`
+}
+
+func (q *Queries) SetOrderReturnItemOutcome(ctx context.Context, arg SetOrderReturnItemOutcomeParams) error {
+	_, err := q.db.Exec(ctx, setOrderReturnItemOutcome,
+		arg.ID,
+		arg.Outcome,
+		arg.RefundIdr,
+		arg.Note,
+	)
+	return err
+}
+
+const snapshotStockTakeItems = `-- name: SnapshotStockTakeItems :execrows
+INSERT INTO stock_take_items (id_from_stock_take, id_from_product, expected_qty)
+SELECT $1::int, ws.id_from_product, ws.quantity
+FROM warehouse_stock ws
+JOIN products p ON p.id = ws.id_from_product
+WHERE ws.id_from_warehouse = $2
+  AND ($3::text IS NULL OR p.category = $3)
+`
+
+type SnapshotStockTakeItemsParams struct {
+	IDFromStockTake int32       `json:"id_from_stock_take"`
+	IDFromWarehouse int32       `json:"id_from_warehouse"`
+	Category        pgtype.Text `json:"category"`
+}
+
+// expected quantities are the location balances at the moment the session opens
+func (q *Queries) SnapshotStockTakeItems(ctx context.Context, arg SnapshotStockTakeItemsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, snapshotStockTakeItems, arg.IDFromStockTake, arg.IDFromWarehouse, arg.Category)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const softDeleteOrder = `-- name: SoftDeleteOrder :one
+UPDATE orders
+SET deleted_at = now(),
+    deleted_by = $2
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by
+`
+
+type SoftDeleteOrderParams struct {
+	ID        int32       `json:"id"`
+	DeletedBy pgtype.Int4 `json:"deleted_by"`
+}
+
+func (q *Queries) SoftDeleteOrder(ctx context.Context, arg SoftDeleteOrderParams) (Order, error) {
+	row := q.db.QueryRow(ctx, softDeleteOrder, arg.ID, arg.DeletedBy)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerName,
+		&i.TotalAmount,
+		&i.Status,
+		&i.Platform,
+		&i.Destination,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const takeFromLot = `-- name: TakeFromLot :exec
+UPDATE stock_lots
+SET quantity = quantity - $2,
+    updated_at = now()
+WHERE id = $1
+`
+
+type TakeFromLotParams struct {
+	ID       int32 `json:"id"`
+	Quantity int32 `json:"quantity"`
+}
+
+func (q *Queries) TakeFromLot(ctx context.Context, arg TakeFromLotParams) error {
+	_, err := q.db.Exec(ctx, takeFromLot, arg.ID, arg.Quantity)
+	return err
+}
+
+const updateOrderCogs = `-- name: UpdateOrderCogs :one
+UPDATE orders
+SET cogs_idr = $2
+WHERE id = $1
+RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by
+`
+
+type UpdateOrderCogsParams struct {
+	ID      int32       `json:"id"`
+	CogsIdr pgtype.Int8 `json:"cogs_idr"`
+}
+
+func (q *Queries) UpdateOrderCogs(ctx context.Context, arg UpdateOrderCogsParams) (Order, error) {
+	row := q.db.QueryRow(ctx, updateOrderCogs, arg.ID, arg.CogsIdr)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerName,
+		&i.TotalAmount,
+		&i.Status,
+		&i.Platform,
+		&i.Destination,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const updateOrderDetails = `-- name: UpdateOrderDetails :one
+UPDATE orders
+SET customer_name = COALESCE($1, customer_name),
+    destination = COALESCE($2, destination),
+    platform = COALESCE($3, platform),
+    updated_at = now()
+WHERE id = $4
+RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by
+`
 
-SELECT nextval('products_id_seq') as seq
+type UpdateOrderDetailsParams struct {
+	CustomerName pgtype.Text `json:"customer_name"`
+	Destination  pgtype.Text `json:"destination"`
+	Platform     pgtype.Text `json:"platform"`
+	ID           int32       `json:"id"`
+}
+
+func (q *Queries) UpdateOrderDetails(ctx context.Context, arg UpdateOrderDetailsParams) (Order, error) {
+	row := q.db.QueryRow(ctx, updateOrderDetails,
+		arg.CustomerName,
+		arg.Destination,
+		arg.Platform,
+		arg.ID,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerName,
+		&i.TotalAmount,
+		&i.Status,
+		&i.Platform,
+		&i.Destination,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const updateOrderItemCogs = `-- name: UpdateOrderItemCogs :exec
+UPDATE order_items
+SET cogs_idr = $2
+WHERE id = $1
 `
 
-// Utility queries
-// This is a helper to get a next sequence number for product id generation if you prefer DB-side sequence.
-func (q *Queries) NextProductSequence(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, nextProductSequence)
-	var seq int64
-	err := row.Scan(&seq)
-	return seq, err
+type UpdateOrderItemCogsParams struct {
+	ID      int32       `json:"id"`
+	CogsIdr pgtype.Int8 `json:"cogs_idr"`
+}
+
+func (q *Queries) UpdateOrderItemCogs(ctx context.Context, arg UpdateOrderItemCogsParams) error {
+	_, err := q.db.Exec(ctx, updateOrderItemCogs, arg.ID, arg.CogsIdr)
+	return err
 }
 
 const updateOrderStatus = `-- name: UpdateOrderStatus :one
 UPDATE orders
-SET status = $2
+SET status = $2,
+    updated_at = now()
 WHERE id = $1
-RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, id_from_product, product_id, price_idr
+RETURNING id, order_number, customer_name, total_amount, status, platform, destination, created_at, updated_at, cogs_idr, id_from_warehouse, total_idr, cancel_reason, cancelled_at, deleted_at, deleted_by
 `
 
 type UpdateOrderStatusParams struct {
@@ -572,9 +5148,13 @@ func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusPa
 		&i.Destination,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.IDFromProduct,
-		&i.ProductID,
-		&i.PriceIdr,
+		&i.CogsIdr,
+		&i.IDFromWarehouse,
+		&i.TotalIdr,
+		&i.CancelReason,
+		&i.CancelledAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
 	)
 	return i, err
 }
@@ -589,7 +5169,7 @@ SET product_id    = $2,
     stock         = $7,
     updated_at    = now()
 WHERE id = $1
-RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
 `
 
 type UpdateProductParams struct {
@@ -623,6 +5203,180 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const updateProductBaseUnit = `-- name: UpdateProductBaseUnit :one
+UPDATE products
+SET base_unit = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
+`
+
+type UpdateProductBaseUnitParams struct {
+	ID       int32  `json:"id"`
+	BaseUnit string `json:"base_unit"`
+}
+
+func (q *Queries) UpdateProductBaseUnit(ctx context.Context, arg UpdateProductBaseUnitParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductBaseUnit, arg.ID, arg.BaseUnit)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.SupplierName,
+		&i.Category,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const updateProductCost = `-- name: UpdateProductCost :one
+UPDATE products
+SET cost_idr = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
+`
+
+type UpdateProductCostParams struct {
+	ID      int32 `json:"id"`
+	CostIdr int64 `json:"cost_idr"`
+}
+
+func (q *Queries) UpdateProductCost(ctx context.Context, arg UpdateProductCostParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductCost, arg.ID, arg.CostIdr)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.SupplierName,
+		&i.Category,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const updateProductIsBundle = `-- name: UpdateProductIsBundle :one
+UPDATE products
+SET is_bundle = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
+`
+
+type UpdateProductIsBundleParams struct {
+	ID       int32 `json:"id"`
+	IsBundle bool  `json:"is_bundle"`
+}
+
+func (q *Queries) UpdateProductIsBundle(ctx context.Context, arg UpdateProductIsBundleParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductIsBundle, arg.ID, arg.IsBundle)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.SupplierName,
+		&i.Category,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const updateProductPrice = `-- name: UpdateProductPrice :one
+UPDATE products
+SET price_idr = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
+`
+
+type UpdateProductPriceParams struct {
+	ID       int32 `json:"id"`
+	PriceIdr int64 `json:"price_idr"`
+}
+
+func (q *Queries) UpdateProductPrice(ctx context.Context, arg UpdateProductPriceParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductPrice, arg.ID, arg.PriceIdr)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.SupplierName,
+		&i.Category,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const updateProductSerialTracking = `-- name: UpdateProductSerialTracking :one
+UPDATE products
+SET track_serials = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
+`
+
+type UpdateProductSerialTrackingParams struct {
+	ID           int32 `json:"id"`
+	TrackSerials bool  `json:"track_serials"`
+}
+
+func (q *Queries) UpdateProductSerialTracking(ctx context.Context, arg UpdateProductSerialTrackingParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductSerialTracking, arg.ID, arg.TrackSerials)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.SupplierName,
+		&i.Category,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
 	)
 	return i, err
 }
@@ -632,7 +5386,7 @@ UPDATE products
 SET stock = $2,
     updated_at = now()
 WHERE id = $1
-RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
 `
 
 type UpdateProductStockParams struct {
@@ -653,6 +5407,10 @@ func (q *Queries) UpdateProductStock(ctx context.Context, arg UpdateProductStock
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
 	)
 	return i, err
 }
@@ -663,7 +5421,7 @@ SET stock = stock + $2,
     updated_at = now()
 WHERE id = $1
   AND (stock + $2) >= 0
-RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at
+RETURNING id, product_id, product_name, supplier_name, category, price_idr, stock, created_at, updated_at, cost_idr, track_serials, is_bundle, base_unit
 `
 
 type UpdateProductStockByDeltaParams struct {
@@ -684,6 +5442,145 @@ func (q *Queries) UpdateProductStockByDelta(ctx context.Context, arg UpdateProdu
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CostIdr,
+		&i.TrackSerials,
+		&i.IsBundle,
+		&i.BaseUnit,
+	)
+	return i, err
+}
+
+const updateProductVariantStock = `-- name: UpdateProductVariantStock :one
+UPDATE product_variants
+SET stock = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, id_from_product, product_id, variant_name, price_idr, stock, attributes, created_at, updated_at
+`
+
+type UpdateProductVariantStockParams struct {
+	ID    int32 `json:"id"`
+	Stock int32 `json:"stock"`
+}
+
+func (q *Queries) UpdateProductVariantStock(ctx context.Context, arg UpdateProductVariantStockParams) (ProductVariant, error) {
+	row := q.db.QueryRow(ctx, updateProductVariantStock, arg.ID, arg.Stock)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.ProductID,
+		&i.VariantName,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.Attributes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateProductVariantStockByDelta = `-- name: UpdateProductVariantStockByDelta :one
+UPDATE product_variants
+SET stock = stock + $2,
+    updated_at = now()
+WHERE id = $1
+  AND (stock + $2) >= 0
+RETURNING id, id_from_product, product_id, variant_name, price_idr, stock, attributes, created_at, updated_at
+`
+
+type UpdateProductVariantStockByDeltaParams struct {
+	ID    int32 `json:"id"`
+	Stock int32 `json:"stock"`
+}
+
+func (q *Queries) UpdateProductVariantStockByDelta(ctx context.Context, arg UpdateProductVariantStockByDeltaParams) (ProductVariant, error) {
+	row := q.db.QueryRow(ctx, updateProductVariantStockByDelta, arg.ID, arg.Stock)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.ProductID,
+		&i.VariantName,
+		&i.PriceIdr,
+		&i.Stock,
+		&i.Attributes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updatePurchaseOrderStatus = `-- name: UpdatePurchaseOrderStatus :one
+UPDATE purchase_orders
+SET status = $1,
+    sent_at = CASE WHEN $1 = 'sent' THEN now() ELSE sent_at END,
+    closed_at = CASE WHEN $1 IN ('received', 'cancelled') THEN now() ELSE closed_at END,
+    updated_at = now()
+WHERE id = $2
+RETURNING id, po_number, supplier_name, id_from_warehouse, status, note, expected_at, sent_at, closed_at, created_at, updated_at
+`
+
+type UpdatePurchaseOrderStatusParams struct {
+	Status string `json:"status"`
+	ID     int32  `json:"id"`
+}
+
+// sent_at is stamped when the PO goes to the supplier, closed_at when it is received in full
+// or cancelled.
+func (q *Queries) UpdatePurchaseOrderStatus(ctx context.Context, arg UpdatePurchaseOrderStatusParams) (PurchaseOrder, error) {
+	row := q.db.QueryRow(ctx, updatePurchaseOrderStatus, arg.Status, arg.ID)
+	var i PurchaseOrder
+	err := row.Scan(
+		&i.ID,
+		&i.PoNumber,
+		&i.SupplierName,
+		&i.IDFromWarehouse,
+		&i.Status,
+		&i.Note,
+		&i.ExpectedAt,
+		&i.SentAt,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateSerialStatus = `-- name: UpdateSerialStatus :one
+UPDATE serial_numbers
+SET status = $2,
+    id_from_warehouse = $3,
+    id_from_order = $4,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, id_from_product, serial_number, status, id_from_warehouse, id_from_order, created_at, updated_at
+`
+
+type UpdateSerialStatusParams struct {
+	ID              int32       `json:"id"`
+	Status          string      `json:"status"`
+	IDFromWarehouse pgtype.Int4 `json:"id_from_warehouse"`
+	IDFromOrder     pgtype.Int4 `json:"id_from_order"`
+}
+
+func (q *Queries) UpdateSerialStatus(ctx context.Context, arg UpdateSerialStatusParams) (SerialNumber, error) {
+	row := q.db.QueryRow(ctx, updateSerialStatus,
+		arg.ID,
+		arg.Status,
+		arg.IDFromWarehouse,
+		arg.IDFromOrder,
+	)
+	var i SerialNumber
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.SerialNumber,
+		&i.Status,
+		&i.IDFromWarehouse,
+		&i.IDFromOrder,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -766,6 +5663,50 @@ func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams)
 	return err
 }
 
+const upsertStockLot = `-- name: UpsertStockLot :one
+
+INSERT INTO stock_lots (id_from_product, id_from_warehouse, lot_number, expiry_date, quantity)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id_from_product, id_from_warehouse, lot_number)
+DO UPDATE SET quantity = stock_lots.quantity + EXCLUDED.quantity,
+              expiry_date = COALESCE(EXCLUDED.expiry_date, stock_lots.expiry_date),
+              updated_at = now()
+RETURNING id, id_from_product, id_from_warehouse, lot_number, expiry_date, quantity, created_at, updated_at
+`
+
+type UpsertStockLotParams struct {
+	IDFromProduct   int32       `json:"id_from_product"`
+	IDFromWarehouse int32       `json:"id_from_warehouse"`
+	LotNumber       string      `json:"lot_number"`
+	ExpiryDate      pgtype.Date `json:"expiry_date"`
+	Quantity        int32       `json:"quantity"`
+}
+
+// Stock Lots
+// Adds quantity (may be negative) to a lot at a location, creating the lot on first receipt.
+// The CHECK on stock_lots.quantity rejects taking more than the lot holds.
+func (q *Queries) UpsertStockLot(ctx context.Context, arg UpsertStockLotParams) (StockLot, error) {
+	row := q.db.QueryRow(ctx, upsertStockLot,
+		arg.IDFromProduct,
+		arg.IDFromWarehouse,
+		arg.LotNumber,
+		arg.ExpiryDate,
+		arg.Quantity,
+	)
+	var i StockLot
+	err := row.Scan(
+		&i.ID,
+		&i.IDFromProduct,
+		&i.IDFromWarehouse,
+		&i.LotNumber,
+		&i.ExpiryDate,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const userByID = `-- name: UserByID :one
 SELECT id, user_id, username, email, full_name, password_hash, role, created_at, updated_at
 FROM users
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when a key does not exist in the store
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores binary objects (product images, thumbnails, ...) under a slash-separated key.
+// The local filesystem implementation is LocalStore; an S3-compatible store can implement
+// the same interface without touching the handlers.
+type BlobStore interface {
+	// Put writes the content of r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get opens the object stored under key; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL the frontend can use to fetch the object
+	URL(key string) string
+}
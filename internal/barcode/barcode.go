@@ -0,0 +1,158 @@
+// Package barcode encodes product codes as EAN-13, Code 128 and QR symbols
+// and renders them as PNG or SVG. Only the symbologies the warehouse labels
+// need are implemented, using the standard library alone.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// ErrInvalidData is returned when the input cannot be encoded in the requested symbology
+var ErrInvalidData = errors.New("barcode: invalid data")
+
+// Bars is a linear barcode as a sequence of modules, true = dark bar
+type Bars []bool
+
+// Matrix is a two-dimensional code, Matrix[y][x] true = dark module
+type Matrix [][]bool
+
+const (
+	linearQuietZone = 11 // modules; EAN-13 needs 11 on the left, Code 128 needs 10
+	matrixQuietZone = 4
+)
+
+// widthsToBars expands alternating bar/space widths (starting with a bar) into modules
+func widthsToBars(dst Bars, widths string) Bars {
+	dark := true
+	for _, c := range widths {
+		for i := 0; i < int(c-'0'); i++ {
+			dst = append(dst, dark)
+		}
+		dark = !dark
+	}
+	return dst
+}
+
+// PNG renders the bars with moduleWidth pixels per module and the given height, including quiet zones
+func (b Bars) PNG(w io.Writer, moduleWidth, height int) error {
+	if moduleWidth < 1 || height < 1 {
+		return fmt.Errorf("barcode: invalid size %dx%d", moduleWidth, height)
+	}
+	total := len(b) + 2*linearQuietZone
+	img := image.NewGray(image.Rect(0, 0, total*moduleWidth, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for i, dark := range b {
+		if !dark {
+			continue
+		}
+		x0 := (linearQuietZone + i) * moduleWidth
+		for y := 0; y < height; y++ {
+			for x := x0; x < x0+moduleWidth; x++ {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// SVG renders the bars as an SVG document; caption (if not empty) is printed under the bars
+func (b Bars) SVG(w io.Writer, moduleWidth, height int, caption string) error {
+	if moduleWidth < 1 || height < 1 {
+		return fmt.Errorf("barcode: invalid size %dx%d", moduleWidth, height)
+	}
+	width := (len(b) + 2*linearQuietZone) * moduleWidth
+	textHeight := 0
+	if caption != "" {
+		textHeight = 14
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		width, height+textHeight, width, height+textHeight)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="#fff"/>`)
+	for i := 0; i < len(b); {
+		if !b[i] {
+			i++
+			continue
+		}
+		// merge adjacent dark modules into one rect
+		j := i
+		for j < len(b) && b[j] {
+			j++
+		}
+		fmt.Fprintf(&sb, `<rect x="%d" y="0" width="%d" height="%d" fill="#000"/>`,
+			(linearQuietZone+i)*moduleWidth, (j-i)*moduleWidth, height)
+		i = j
+	}
+	if caption != "" {
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-family="monospace" font-size="12" text-anchor="middle">%s</text>`,
+			width/2, height+12, escapeXML(caption))
+	}
+	sb.WriteString("</svg>")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// PNG renders the matrix with moduleSize pixels per module, including the quiet zone
+func (m Matrix) PNG(w io.Writer, moduleSize int) error {
+	if moduleSize < 1 {
+		return fmt.Errorf("barcode: invalid module size %d", moduleSize)
+	}
+	total := (len(m) + 2*matrixQuietZone) * moduleSize
+	img := image.NewGray(image.Rect(0, 0, total, total))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y, row := range m {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			x0 := (matrixQuietZone + x) * moduleSize
+			y0 := (matrixQuietZone + y) * moduleSize
+			for py := y0; py < y0+moduleSize; py++ {
+				for px := x0; px < x0+moduleSize; px++ {
+					img.SetGray(px, py, color.Gray{Y: 0})
+				}
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// SVG renders the matrix as an SVG document in module units, scaled to moduleSize pixels per module
+func (m Matrix) SVG(w io.Writer, moduleSize int) error {
+	if moduleSize < 1 {
+		return fmt.Errorf("barcode: invalid module size %d", moduleSize)
+	}
+	units := len(m) + 2*matrixQuietZone
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		units*moduleSize, units*moduleSize, units, units)
+	sb.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range m {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&sb, "M%d,%dh1v1h-1z", x+matrixQuietZone, y+matrixQuietZone)
+			}
+		}
+	}
+	sb.WriteString(`"/></svg>`)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func escapeXML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
@@ -0,0 +1,70 @@
+package barcode
+
+import "fmt"
+
+var (
+	ean13L = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	ean13G = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	ean13R = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+
+	// parity of the left half, selected by the first digit
+	ean13Parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// EAN13CheckDigit computes the check digit for the first 12 digits of an EAN-13 code
+func EAN13CheckDigit(digits string) (byte, error) {
+	if len(digits) < 12 {
+		return 0, fmt.Errorf("%w: EAN-13 needs 12 digits", ErrInvalidData)
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := digits[i]
+		if d < '0' || d > '9' {
+			return 0, fmt.Errorf("%w: EAN-13 accepts digits only", ErrInvalidData)
+		}
+		if i%2 == 0 {
+			sum += int(d - '0')
+		} else {
+			sum += 3 * int(d-'0')
+		}
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// EAN13 encodes a 12-digit code (check digit appended) or a 13-digit code (check digit verified).
+// It returns the encoded bars and the full 13-digit code.
+func EAN13(code string) (Bars, string, error) {
+	if len(code) != 12 && len(code) != 13 {
+		return nil, "", fmt.Errorf("%w: EAN-13 needs 12 or 13 digits, got %d characters", ErrInvalidData, len(code))
+	}
+	check, err := EAN13CheckDigit(code)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(code) == 13 && code[12] != check {
+		return nil, "", fmt.Errorf("%w: EAN-13 check digit mismatch", ErrInvalidData)
+	}
+	full := code[:12] + string(check)
+
+	bits := "101"
+	parity := ean13Parity[full[0]-'0']
+	for i := 1; i <= 6; i++ {
+		d := full[i] - '0'
+		if parity[i-1] == 'L' {
+			bits += ean13L[d]
+		} else {
+			bits += ean13G[d]
+		}
+	}
+	bits += "01010"
+	for i := 7; i <= 12; i++ {
+		bits += ean13R[full[i]-'0']
+	}
+	bits += "101"
+
+	bars := make(Bars, len(bits))
+	for i := range bits {
+		bars[i] = bits[i] == '1'
+	}
+	return bars, full, nil
+}
@@ -0,0 +1,441 @@
+package barcode
+
+import "fmt"
+
+// QR codes are generated in byte mode with error correction level M,
+// versions 1-10 (up to 213 bytes), which covers product codes and short URLs.
+
+const qrMaxVersion = 10
+
+var (
+	// error correction codewords per block and number of blocks, level M, index = version
+	qrECCPerBlock = [qrMaxVersion + 1]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26}
+	qrNumBlocks   = [qrMaxVersion + 1]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5}
+
+	// alignment pattern centre coordinates, index = version
+	qrAlignment = [qrMaxVersion + 1][]int{
+		nil, nil,
+		{6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+		{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+	}
+)
+
+const qrFormatBitsM = 0 // level M in the format information
+
+type qrBuilder struct {
+	size     int
+	modules  [][]bool
+	function [][]bool // true where a module belongs to a function pattern
+}
+
+// QR encodes data as a QR code matrix
+func QR(data string) (Matrix, error) {
+	if data == "" {
+		return nil, fmt.Errorf("%w: empty QR data", ErrInvalidData)
+	}
+
+	version := 0
+	for v := 1; v <= qrMaxVersion; v++ {
+		if qrHeaderBits(v)+8*len(data) <= 8*qrDataCodewords(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%w: QR data too long (%d bytes)", ErrInvalidData, len(data))
+	}
+
+	codewords := qrAddECC(qrEncodeData(data, version), version)
+
+	b := newQRBuilder(version)
+	b.drawFunctionPatterns(version)
+	b.drawCodewords(codewords)
+
+	// pick the mask with the lowest penalty
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		b.applyMask(mask)
+		b.drawFormatBits(mask)
+		if p := b.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		b.applyMask(mask) // masks are XOR, applying twice undoes it
+	}
+	b.applyMask(best)
+	b.drawFormatBits(best)
+
+	return Matrix(b.modules), nil
+}
+
+func qrHeaderBits(version int) int {
+	if version < 10 {
+		return 4 + 8
+	}
+	return 4 + 16
+}
+
+// qrRawCodewords is the number of codewords (data + ECC) a symbol of this version holds
+func qrRawCodewords(version int) int {
+	modules := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		modules -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			modules -= 36
+		}
+	}
+	return modules / 8
+}
+
+func qrDataCodewords(version int) int {
+	return qrRawCodewords(version) - qrECCPerBlock[version]*qrNumBlocks[version]
+}
+
+// qrEncodeData builds the data codewords: mode, length, payload, terminator and padding
+func qrEncodeData(data string, version int) []byte {
+	var bits []bool
+	appendBits := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (v>>i)&1 == 1)
+		}
+	}
+
+	appendBits(0x4, 4) // byte mode
+	appendBits(len(data), qrHeaderBits(version)-4)
+	for i := 0; i < len(data); i++ {
+		appendBits(int(data[i]), 8)
+	}
+
+	capacity := 8 * qrDataCodewords(version)
+	appendBits(0, min(4, capacity-len(bits)))
+	appendBits(0, (8-len(bits)%8)%8)
+
+	out := make([]byte, 0, capacity/8)
+	for i := 0; i < len(bits); i += 8 {
+		var c byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				c |= 1 << (7 - j)
+			}
+		}
+		out = append(out, c)
+	}
+	for pad := byte(0xEC); len(out) < capacity/8; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// qrAddECC splits data into blocks, appends Reed-Solomon ECC to each and interleaves them
+func qrAddECC(data []byte, version int) []byte {
+	numBlocks := qrNumBlocks[version]
+	eccLen := qrECCPerBlock[version]
+	raw := qrRawCodewords(version)
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		dat := data[k : k+n]
+		k += n
+		block := append([]byte{}, dat...)
+		if i < numShort {
+			block = append(block, 0) // placeholder so all blocks have equal length while interleaving
+		}
+		block = append(block, rsRemainder(dat, divisor)...)
+		blocks[i] = block
+	}
+
+	out := make([]byte, 0, raw)
+	for i := 0; i < len(blocks[0]); i++ {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+func rsMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			result[j] = rsMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = rsMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= rsMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+func newQRBuilder(version int) *qrBuilder {
+	size := version*4 + 17
+	b := &qrBuilder{size: size}
+	b.modules = make([][]bool, size)
+	b.function = make([][]bool, size)
+	for i := range b.modules {
+		b.modules[i] = make([]bool, size)
+		b.function[i] = make([]bool, size)
+	}
+	return b
+}
+
+func (b *qrBuilder) set(x, y int, dark bool) {
+	b.modules[y][x] = dark
+	b.function[y][x] = true
+}
+
+func (b *qrBuilder) drawFunctionPatterns(version int) {
+	// timing patterns
+	for i := 0; i < b.size; i++ {
+		b.set(6, i, i%2 == 0)
+		b.set(i, 6, i%2 == 0)
+	}
+
+	// finder patterns with their separators
+	for _, c := range [][2]int{{3, 3}, {b.size - 4, 3}, {3, b.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || x >= b.size || y < 0 || y >= b.size {
+					continue
+				}
+				dist := max(abs(dx), abs(dy))
+				b.set(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	// alignment patterns, except where they would overlap the finders
+	pos := qrAlignment[version]
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					b.set(pos[i]+dx, pos[j]+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas (real bits are drawn after masking)
+	b.drawFormatBits(0)
+
+	// version information (version 7 and up)
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, c := b.size-11+i%3, i/3
+			b.set(a, c, dark)
+			b.set(c, a, dark)
+		}
+	}
+}
+
+func (b *qrBuilder) drawFormatBits(mask int) {
+	data := qrFormatBitsM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	// first copy, around the top-left finder
+	for i := 0; i <= 5; i++ {
+		b.set(8, i, bit(i))
+	}
+	b.set(8, 7, bit(6))
+	b.set(8, 8, bit(7))
+	b.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		b.set(14-i, 8, bit(i))
+	}
+
+	// second copy, split between the other two finders
+	for i := 0; i < 8; i++ {
+		b.set(b.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		b.set(8, b.size-15+i, bit(i))
+	}
+	b.set(8, b.size-8, true) // always-dark module
+}
+
+// drawCodewords places the codeword bits in the zigzag order, skipping function modules
+func (b *qrBuilder) drawCodewords(data []byte) {
+	i := 0
+	for right := b.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < b.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = b.size - 1 - vert // upward column pair
+				}
+				if b.function[y][x] {
+					continue
+				}
+				if i < len(data)*8 {
+					b.modules[y][x] = (data[i>>3]>>(7-i&7))&1 == 1
+					i++
+				}
+				// remaining (remainder) modules stay light
+			}
+		}
+	}
+}
+
+func (b *qrBuilder) applyMask(mask int) {
+	for y := 0; y < b.size; y++ {
+		for x := 0; x < b.size; x++ {
+			if b.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				b.modules[y][x] = !b.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four rules of ISO/IEC 18004; lower is better
+func (b *qrBuilder) penalty() int {
+	n := b.size
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return b.modules[x][y]
+		}
+		return b.modules[y][x]
+	}
+
+	score := 0
+	finderA := []bool{true, false, true, true, true, false, true, false, false, false, false}
+	finderB := []bool{false, false, false, false, true, false, true, true, true, false, true}
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			// rule 1: runs of five or more same-colored modules
+			run := 1
+			for x := 1; x < n; x++ {
+				if at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				score += 3 + run - 5
+			}
+
+			// rule 3: finder-like patterns
+			for x := 0; x+len(finderA) <= n; x++ {
+				matchA, matchB := true, true
+				for k := range finderA {
+					v := at(x+k, y, transpose)
+					matchA = matchA && v == finderA[k]
+					matchB = matchB && v == finderB[k]
+				}
+				if matchA {
+					score += 40
+				}
+				if matchB {
+					score += 40
+				}
+			}
+		}
+	}
+
+	// rule 2: 2x2 blocks of one color
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			c := b.modules[y][x]
+			if c {
+				dark++
+			}
+			if x+1 < n && y+1 < n && c == b.modules[y][x+1] && c == b.modules[y+1][x] && c == b.modules[y+1][x+1] {
+				score += 3
+			}
+		}
+	}
+
+	// rule 4: balance of dark and light modules
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	score += k * 10
+
+	return score
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
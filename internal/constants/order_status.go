@@ -0,0 +1,69 @@
+package constants
+
+import "strings"
+
+// Order statuses
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipping   = "shipping"
+	OrderStatusCompleted  = "completed"
+	OrderStatusCancelled  = "cancelled"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Orders can only be cancelled before they ship; completed and cancelled are final.
+var orderTransitions = map[string][]string{
+	OrderStatusPending:    {OrderStatusProcessing, OrderStatusCancelled},
+	OrderStatusProcessing: {OrderStatusShipping, OrderStatusCancelled},
+	OrderStatusShipping:   {OrderStatusCompleted},
+	OrderStatusCompleted:  {},
+	OrderStatusCancelled:  {},
+}
+
+// OrderStatuses returns every order status in lifecycle order
+func OrderStatuses() []string {
+	return []string{
+		OrderStatusPending,
+		OrderStatusProcessing,
+		OrderStatusShipping,
+		OrderStatusCompleted,
+		OrderStatusCancelled,
+	}
+}
+
+// NormalizeOrderStatus lowercases a status and maps the legacy "shipped" to "shipping"
+func NormalizeOrderStatus(status string) string {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "shipped" {
+		return OrderStatusShipping
+	}
+	return status
+}
+
+// IsOrderStatus reports whether status (already normalized) is a known order status
+func IsOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// CanTransitionOrder reports whether an order may move from one status to another
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOrderStatuses returns the statuses an order in status may move to
+func NextOrderStatuses(status string) []string {
+	return append([]string(nil), orderTransitions[status]...)
+}
+
+// OrderEditable reports whether the details of an order in status (already normalized) may
+// still be changed; once it ships the customer and destination are fixed
+func OrderEditable(status string) bool {
+	return status == OrderStatusPending || status == OrderStatusProcessing
+}
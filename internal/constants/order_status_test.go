@@ -0,0 +1,86 @@
+package constants
+
+import "testing"
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{OrderStatusPending, OrderStatusProcessing, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusProcessing, OrderStatusShipping, true},
+		{OrderStatusProcessing, OrderStatusCancelled, true},
+		{OrderStatusShipping, OrderStatusCompleted, true},
+
+		// no skipping ahead or going back
+		{OrderStatusPending, OrderStatusShipping, false},
+		{OrderStatusPending, OrderStatusCompleted, false},
+		{OrderStatusProcessing, OrderStatusPending, false},
+		{OrderStatusShipping, OrderStatusProcessing, false},
+		// cancelling only before shipping
+		{OrderStatusShipping, OrderStatusCancelled, false},
+		// final statuses
+		{OrderStatusCompleted, OrderStatusCancelled, false},
+		{OrderStatusCompleted, OrderStatusPending, false},
+		{OrderStatusCancelled, OrderStatusPending, false},
+		// same status and unknown statuses
+		{OrderStatusPending, OrderStatusPending, false},
+		{"shipped", OrderStatusCompleted, false},
+		{OrderStatusPending, "unknown", false},
+	}
+	for _, tt := range tests {
+		if got := CanTransitionOrder(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionOrder(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestNextOrderStatusesMatchesCanTransition(t *testing.T) {
+	for _, from := range OrderStatuses() {
+		next := NextOrderStatuses(from)
+		for _, to := range OrderStatuses() {
+			allowed := false
+			for _, n := range next {
+				allowed = allowed || n == to
+			}
+			if allowed != CanTransitionOrder(from, to) {
+				t.Errorf("NextOrderStatuses(%q) and CanTransitionOrder disagree on %q", from, to)
+			}
+		}
+	}
+
+	// the returned slice is a copy
+	next := NextOrderStatuses(OrderStatusPending)
+	next[0] = OrderStatusCompleted
+	if CanTransitionOrder(OrderStatusPending, OrderStatusCompleted) {
+		t.Error("changing the result of NextOrderStatuses changed the transitions")
+	}
+}
+
+func TestNormalizeOrderStatus(t *testing.T) {
+	tests := []struct {
+		in, want string
+		known    bool
+	}{
+		{"pending", OrderStatusPending, true},
+		{"  Processing ", OrderStatusProcessing, true},
+		{"SHIPPING", OrderStatusShipping, true},
+		{"shipped", OrderStatusShipping, true},
+		{" Shipped", OrderStatusShipping, true},
+		{"Completed", OrderStatusCompleted, true},
+		{"cancelled", OrderStatusCancelled, true},
+		{"canceled", "canceled", false},
+		{"", "", false},
+		{"unknown", "unknown", false},
+	}
+	for _, tt := range tests {
+		got := NormalizeOrderStatus(tt.in)
+		if got != tt.want {
+			t.Errorf("NormalizeOrderStatus(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if IsOrderStatus(got) != tt.known {
+			t.Errorf("IsOrderStatus(%q) = %v, want %v", got, !tt.known, tt.known)
+		}
+	}
+}
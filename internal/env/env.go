@@ -2,6 +2,7 @@ package env
 
 import (
 	"os"
+	"time"
 )
 
 // Config holds environment configuration used across the app.
@@ -40,3 +41,14 @@ func GetString(key, fallback string) string {
 	}
 	return fallback
 }
+
+// GetDuration returns the environment variable parsed as a time.Duration (e.g. "30s", "5m"),
+// or fallback if it is empty or invalid.
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,455 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// stock movement reasons of kit operations
+const (
+	movementReasonAssembly    = "kit assembly"
+	movementReasonDisassembly = "kit disassembly"
+)
+
+// errNotBundle is returned for kit operations on a product without components
+var errNotBundle = errors.New("product is not a bundle")
+
+// SetBundleComponentsRequest is the body of PUT /products/{id}/components; an empty list turns
+// the bundle back into a plain product
+type SetBundleComponentsRequest struct {
+	Components []BundleComponentRequest `json:"components"`
+}
+
+// BundleComponentRequest is one component product and how many of it go into one bundle
+type BundleComponentRequest struct {
+	IdFromProduct int32 `json:"id_from_product"`
+	Quantity      int32 `json:"quantity"`
+}
+
+// BundleComponentLine is a component with the stock that can still be sold
+type BundleComponentLine struct {
+	repo.ListBundleComponentsByBundleIDsRow
+	Available int64 `json:"available"`
+}
+
+// BundleResponse is a bundle with its components. Available counts pre-built kits plus the kits
+// the component stock can still make.
+type BundleResponse struct {
+	Product    repo.Product          `json:"product"`
+	Components []BundleComponentLine `json:"components"`
+	Available  int64                 `json:"available"`
+}
+
+// KitRequest is the body of POST /products/{id}/assemble and /disassemble
+type KitRequest struct {
+	Quantity    int32  `json:"quantity"`               // number of kits
+	WarehouseID int32  `json:"warehouse_id,omitempty"` // default warehouse when omitted
+	Reference   string `json:"reference,omitempty"`
+}
+
+// orderLine is the stock one order takes from one product
+type orderLine struct {
+	ProductID int32
+	Quantity  int32
+	CostIdr   int64 // fallback unit cost when no cost layer covers the units
+}
+
+// orderLines splits an ordered quantity into the products whose stock it takes. Plain products
+// take their own stock; bundles take pre-built kits first and make up the rest from components.
+func orderLines(ctx context.Context, q *repo.Queries, product repo.Product, warehouseID, qty int32) ([]orderLine, error) {
+	if !product.IsBundle {
+		return []orderLine{{product.ID, qty, product.CostIdr}}, nil
+	}
+
+	if _, err := q.GetProductForUpdate(ctx, product.ID); err != nil {
+		return nil, err
+	}
+	stock, err := q.GetAvailableStock(ctx, repo.GetAvailableStockParams{
+		IDFromProduct:   product.ID,
+		IDFromWarehouse: warehouseID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var lines []orderLine
+	kits := int32(min(max(stock.OnHand-stock.Reserved, 0), int64(qty)))
+	if kits > 0 {
+		lines = append(lines, orderLine{product.ID, kits, product.CostIdr})
+	}
+	if rest := qty - kits; rest > 0 {
+		components, err := q.ListBundleComponentsByBundleIDs(ctx, []int32{product.ID})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range components {
+			lines = append(lines, orderLine{c.IDFromComponent, rest * c.Quantity, c.CostIdr})
+		}
+	}
+	return lines, nil
+}
+
+// issueStock takes units out of a location outside of an order: the on-hand balances, the lots
+// (first-expired-first-out) and the cost layers. It returns what the units cost.
+func (s *Server) issueStock(ctx context.Context, q *repo.Queries, c stockChange, fallbackCost int64) (int64, error) {
+	c.Allocated = true
+	if _, _, err := s.applyStockChange(ctx, q, c); err != nil {
+		return 0, err
+	}
+	if err := changeLots(ctx, q, c, c.WarehouseID); err != nil {
+		return 0, err
+	}
+	return s.consumeCostLayers(ctx, q, c.ProductID, -c.Delta, pgtype.Int4{}, c.Reference, fallbackCost)
+}
+
+// splitKitCost spreads the cost of disassembled kits over their components by cost_idr (by
+// units when no component has a cost) and returns each component's share. Shares are rounded
+// down and the last component takes the remainder, so they add up to cost exactly.
+func splitKitCost(cost int64, components []repo.ListBundleComponentsByBundleIDsRow) []int64 {
+	var kitWeight, kitUnits int64
+	for _, c := range components {
+		kitWeight += c.CostIdr * int64(c.Quantity)
+		kitUnits += int64(c.Quantity)
+	}
+	totals := make([]int64, len(components))
+	left := cost
+	for i, c := range components {
+		if i == len(components)-1 {
+			totals[i] = left
+			break
+		}
+		if kitWeight > 0 {
+			totals[i] = cost * c.CostIdr * int64(c.Quantity) / kitWeight
+		} else {
+			totals[i] = cost * int64(c.Quantity) / kitUnits
+		}
+		left -= totals[i]
+	}
+	return totals
+}
+
+// requireUnreserved fails with errInsufficientStock unless qty units at the location are not held
+// by open orders; the product row is locked so the answer holds for the rest of the transaction
+func requireUnreserved(ctx context.Context, q *repo.Queries, productID, warehouseID, qty int32) error {
+	if _, err := q.GetProductForUpdate(ctx, productID); err != nil {
+		return err
+	}
+	stock, err := q.GetAvailableStock(ctx, repo.GetAvailableStockParams{
+		IDFromProduct:   productID,
+		IDFromWarehouse: warehouseID,
+	})
+	if err != nil {
+		return err
+	}
+	if available := stock.OnHand - stock.Reserved; available < int64(qty) {
+		return fmt.Errorf("%w: product %d has %d available at the location, %d needed", errInsufficientStock, productID, max(available, 0), qty)
+	}
+	return nil
+}
+
+// bundleResponse loads the components of a bundle and what can still be sold of it
+func (s *Server) bundleResponse(ctx context.Context, product repo.Product) (BundleResponse, error) {
+	resp := BundleResponse{Product: product, Components: []BundleComponentLine{}}
+	components, err := s.Repo.ListBundleComponentsByBundleIDs(ctx, []int32{product.ID})
+	if err != nil {
+		return resp, err
+	}
+	ids := []int32{product.ID}
+	for _, c := range components {
+		ids = append(ids, c.IDFromComponent)
+	}
+	availability, err := s.loadAvailability(ctx, ids)
+	if err != nil {
+		return resp, err
+	}
+	for _, c := range components {
+		resp.Components = append(resp.Components, BundleComponentLine{c, availability[c.IDFromComponent].available(c.Stock)})
+	}
+	resp.Available = availability[product.ID].available(product.Stock)
+	return resp, nil
+}
+
+// GetBundleComponents handles GET /products/{id}/components
+func (s *Server) GetBundleComponents(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+
+	resp, err := s.bundleResponse(r.Context(), product)
+	if err != nil {
+		http.Error(w, "failed to fetch bundle components: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SetBundleComponents handles PUT /products/{id}/components: replaces what the bundle is made of.
+// Bundles cannot be nested, so components must be plain products and a bundle cannot itself be
+// a component.
+func (s *Server) SetBundleComponents(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req SetBundleComponentsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids := make([]int32, 0, len(req.Components))
+	for _, c := range req.Components {
+		if c.Quantity <= 0 {
+			http.Error(w, "component quantity must be positive", http.StatusBadRequest)
+			return
+		}
+		if c.IdFromProduct == id {
+			http.Error(w, "a bundle cannot contain itself", http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, c.IdFromProduct)
+	}
+	if len(uniqueIDs(ids)) != len(ids) {
+		http.Error(w, "components must not repeat a product", http.StatusBadRequest)
+		return
+	}
+
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+	if len(ids) > 0 {
+		components, err := s.Repo.GetProductsByIDs(r.Context(), ids)
+		if err != nil {
+			http.Error(w, "failed to fetch products: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(components) != len(ids) {
+			http.Error(w, "one or more components not found", http.StatusBadRequest)
+			return
+		}
+		for _, c := range components {
+			if c.IsBundle {
+				http.Error(w, "component "+c.ProductID+" is a bundle itself", http.StatusBadRequest)
+				return
+			}
+		}
+		used, err := s.Repo.CountBundlesWithComponent(r.Context(), id)
+		if err != nil {
+			http.Error(w, "failed to check bundle usage: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if used > 0 {
+			http.Error(w, "product is a component of another bundle", http.StatusConflict)
+			return
+		}
+	}
+
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		if err := q.DeleteBundleComponents(r.Context(), id); err != nil {
+			return err
+		}
+		for _, c := range req.Components {
+			if _, err := q.CreateBundleComponent(r.Context(), repo.CreateBundleComponentParams{
+				IDFromBundle:    id,
+				IDFromComponent: c.IdFromProduct,
+				Quantity:        c.Quantity,
+			}); err != nil {
+				return err
+			}
+		}
+		var err error
+		product, err = q.UpdateProductIsBundle(r.Context(), repo.UpdateProductIsBundleParams{
+			ID:       id,
+			IsBundle: len(req.Components) > 0,
+		})
+		return err
+	})
+	if err != nil {
+		http.Error(w, "failed to save bundle components: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := s.bundleResponse(r.Context(), product)
+	if err != nil {
+		http.Error(w, "failed to fetch bundle components: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// AssembleKits handles POST /products/{id}/assemble: builds kits from component stock at a
+// location. The kits are booked at what their components cost.
+func (s *Server) AssembleKits(w http.ResponseWriter, r *http.Request) {
+	s.kitOperation(w, r, true)
+}
+
+// DisassembleKits handles POST /products/{id}/disassemble: breaks pre-built kits back into their
+// components. The kits' cost is spread over the components by their cost_idr.
+func (s *Server) DisassembleKits(w http.ResponseWriter, r *http.Request) {
+	s.kitOperation(w, r, false)
+}
+
+// kitOperation moves stock between a bundle and its components in one transaction
+func (s *Server) kitOperation(w http.ResponseWriter, r *http.Request, assemble bool) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req KitRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Quantity <= 0 {
+		http.Error(w, "quantity must be positive", http.StatusBadRequest)
+		return
+	}
+
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+	if !product.IsBundle {
+		http.Error(w, errNotBundle.Error(), http.StatusBadRequest)
+		return
+	}
+	if product.TrackSerials {
+		http.Error(w, fmt.Sprintf("%s: %s", errSerialTracked, product.ProductID), http.StatusConflict)
+		return
+	}
+
+	reason := movementReasonAssembly
+	if !assemble {
+		reason = movementReasonDisassembly
+	}
+	reference := strings.TrimSpace(req.Reference)
+	if reference == "" {
+		reference = fmt.Sprintf("%s %s x%d", reason, product.ProductID, req.Quantity)
+	}
+
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		warehouseID, err := resolveWarehouse(r.Context(), q, req.WarehouseID)
+		if err != nil {
+			return err
+		}
+		components, err := q.ListBundleComponentsByBundleIDs(r.Context(), []int32{id})
+		if err != nil {
+			return err
+		}
+		for _, c := range components {
+			if err := requireUntracked(r.Context(), q, c.IDFromComponent); err != nil {
+				return err
+			}
+		}
+
+		if assemble {
+			var cost int64
+			for _, c := range components {
+				need := req.Quantity * c.Quantity
+				if err := requireUnreserved(r.Context(), q, c.IDFromComponent, warehouseID, need); err != nil {
+					return err
+				}
+				used, err := s.issueStock(r.Context(), q, stockChange{
+					ProductID:   c.IDFromComponent,
+					Delta:       -need,
+					Reason:      reason,
+					Reference:   reference,
+					WarehouseID: warehouseID,
+				}, c.CostIdr)
+				if err != nil {
+					return err
+				}
+				cost += used
+			}
+			unitCost := cost / int64(req.Quantity)
+			_, _, err := s.applyStockChange(r.Context(), q, stockChange{
+				ProductID:   id,
+				Delta:       req.Quantity,
+				Reason:      reason,
+				Reference:   reference,
+				WarehouseID: warehouseID,
+				UnitCostIdr: &unitCost,
+			})
+			return err
+		}
+
+		if err := requireUnreserved(r.Context(), q, id, warehouseID, req.Quantity); err != nil {
+			return err
+		}
+		cost, err := s.issueStock(r.Context(), q, stockChange{
+			ProductID:   id,
+			Delta:       -req.Quantity,
+			Reason:      reason,
+			Reference:   reference,
+			WarehouseID: warehouseID,
+		}, product.CostIdr)
+		if err != nil {
+			return err
+		}
+
+		// nilai paket dibagi ke komponen sesuai HPP masing-masing
+		totals := splitKitCost(cost, components)
+		for i, c := range components {
+			// a layer has one unit cost, so units that carry a rounding rupiah get their own
+			units := int64(req.Quantity * c.Quantity)
+			unitCost, extra := totals[i]/units, totals[i]%units
+			for _, part := range []struct {
+				units int64
+				cost  int64
+			}{{units - extra, unitCost}, {extra, unitCost + 1}} {
+				if part.units == 0 {
+					continue
+				}
+				if _, _, err := s.applyStockChange(r.Context(), q, stockChange{
+					ProductID:   c.IDFromComponent,
+					Delta:       int32(part.units),
+					Reason:      reason,
+					Reference:   reference,
+					WarehouseID: warehouseID,
+					UnitCostIdr: &part.cost,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInsufficientStock), errors.Is(err, errSerialTracked):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, errWarehouseNotFound), isForeignKeyViolation(err):
+			http.Error(w, errWarehouseNotFound.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "failed to "+reason+": "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	product, ok = s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+	resp, err := s.bundleResponse(r.Context(), product)
+	if err != nil {
+		http.Error(w, "failed to fetch bundle components: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
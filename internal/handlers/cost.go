@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// UpdateCostRequest is the body of PUT /products/{id}/cost
+type UpdateCostRequest struct {
+	CostIdr int64  `json:"cost_idr"`
+	Note    string `json:"note,omitempty"`
+}
+
+// ListProductCosts handles GET /products/{id}/costs (newest first)
+func (s *Server) ListProductCosts(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+
+	costs, err := s.Repo.ListCostsByProductIDs(r.Context(), []int32{id})
+	if err != nil {
+		http.Error(w, "failed to list costs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if costs == nil {
+		costs = []repo.ProductCost{}
+	}
+	writeJSON(w, http.StatusOK, costs)
+}
+
+// UpdateProductCost handles PUT /products/{id}/cost. The new cost applies to orders created
+// from now on; existing orders keep the unit cost they were created with.
+func (s *Server) UpdateProductCost(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateCostRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.CostIdr < 0 {
+		http.Error(w, "cost_idr must not be negative", http.StatusBadRequest)
+		return
+	}
+	note := strings.TrimSpace(req.Note)
+
+	var product repo.Product
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		var err error
+		product, err = q.UpdateProductCost(r.Context(), repo.UpdateProductCostParams{
+			ID:      id,
+			CostIdr: req.CostIdr,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateProductCost(r.Context(), repo.CreateProductCostParams{
+			IDFromProduct: id,
+			CostIdr:       req.CostIdr,
+			Note:          pgtype.Text{String: note, Valid: note != ""},
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to update cost: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
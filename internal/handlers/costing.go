@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// CostingMethod selects how units leaving stock are costed (COSTING_METHOD)
+type CostingMethod string
+
+const (
+	CostingFIFO    CostingMethod = "fifo"    // biaya layer tertua dulu
+	CostingAverage CostingMethod = "average" // rata-rata tertimbang dari semua layer yang tersisa
+)
+
+// ParseCostingMethod validates a COSTING_METHOD value; empty means FIFO
+func ParseCostingMethod(s string) (CostingMethod, error) {
+	switch m := CostingMethod(strings.ToLower(strings.TrimSpace(s))); m {
+	case "", CostingFIFO:
+		return CostingFIFO, nil
+	case CostingAverage:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown costing method %q (use fifo or average)", s)
+	}
+}
+
+// averageUnitCost is the weighted average cost of quantity units worth valueIdr in total,
+// rounded to the nearest rupiah (0 without units)
+func averageUnitCost(valueIdr, quantity int64) int64 {
+	if quantity <= 0 {
+		return 0
+	}
+	return (valueIdr + quantity/2) / quantity
+}
+
+// consumeCostLayers takes qty units out of the product's open cost layers, oldest first, and
+// returns what they cost. With average costing the units are still taken from the oldest layers
+// (so quantities stay right) but charged at the current weighted average. Units not covered by
+// any layer are charged at fallbackCost.
+func (s *Server) consumeCostLayers(ctx context.Context, q *repo.Queries, productID, qty int32, orderID pgtype.Int4, reference string, fallbackCost int64) (int64, error) {
+	layers, err := q.ListOpenCostLayers(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	var average int64
+	if s.Costing == CostingAverage {
+		v, err := q.GetProductInventoryValue(ctx, productID)
+		if err != nil {
+			return 0, err
+		}
+		average = averageUnitCost(v.ValueIdr, v.Quantity)
+	}
+
+	var cost int64
+	for _, l := range layers {
+		if qty == 0 {
+			break
+		}
+		take := min(qty, l.QuantityRemaining)
+		unitCost := l.UnitCostIdr
+		if s.Costing == CostingAverage {
+			unitCost = average
+		}
+
+		if err := q.ConsumeCostLayer(ctx, repo.ConsumeCostLayerParams{
+			ID:                l.ID,
+			QuantityRemaining: take,
+		}); err != nil {
+			return 0, err
+		}
+		if _, err := q.CreateCostLayerConsumption(ctx, repo.CreateCostLayerConsumptionParams{
+			IDFromLayer: l.ID,
+			IDFromOrder: orderID,
+			Quantity:    take,
+			UnitCostIdr: unitCost,
+			Reference:   pgtype.Text{String: reference, Valid: reference != ""},
+		}); err != nil {
+			return 0, err
+		}
+
+		cost += int64(take) * unitCost
+		qty -= take
+	}
+
+	return cost + int64(qty)*fallbackCost, nil
+}
+
+// errInvalidReceipt is returned when a delivery does not fit the product (e.g. serials missing)
+var errInvalidReceipt = errors.New("invalid receipt")
+
+// parseReceivedAt parses an optional RFC3339 receipt time (zero = now), which must not lie ahead
+func parseReceivedAt(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, errors.New("invalid received_at format, must be RFC3339")
+	}
+	if t.After(time.Now()) {
+		return time.Time{}, errors.New("received_at must not be in the future")
+	}
+	return t, nil
+}
+
+// parseLot trims an optional lot number and parses its YYYY-MM-DD expiry date
+func parseLot(lotNumber, expiryDate string) (string, pgtype.Date, error) {
+	lotNumber = strings.TrimSpace(lotNumber)
+	if expiryDate == "" {
+		return lotNumber, pgtype.Date{}, nil
+	}
+	if lotNumber == "" {
+		return "", pgtype.Date{}, errors.New("expiry_date requires lot_number")
+	}
+	d, err := time.Parse(time.DateOnly, expiryDate)
+	if err != nil {
+		return "", pgtype.Date{}, errors.New("invalid expiry_date format, must be YYYY-MM-DD")
+	}
+	return lotNumber, pgtype.Date{Time: d, Valid: true}, nil
+}
+
+// receiveStock books an incoming delivery (c.Delta base units) through the movement ledger and
+// the cost layers and registers its serial numbers, one per unit for serial-tracked products
+func (s *Server) receiveStock(ctx context.Context, q *repo.Queries, product repo.Product, c stockChange, serials []string) (StockChangeResponse, error) {
+	var resp StockChangeResponse
+	if err := checkSerialCount(product, serials, c.Delta); err != nil {
+		return resp, fmt.Errorf("%w: %w", errInvalidReceipt, err)
+	}
+
+	var err error
+	resp.Product, resp.Movement, err = s.applyStockChange(ctx, q, c)
+	if err != nil {
+		return resp, err
+	}
+	if err := registerSerials(ctx, q, product.ID, c.WarehouseID, serials, c.Reference); err != nil {
+		return resp, err
+	}
+	resp.StockUnits, err = productStockUnits(ctx, q, resp.Product)
+	return resp, err
+}
+
+// ReceiveStockRequest is the body of POST /products/{id}/receipts
+type ReceiveStockRequest struct {
+	Quantity    int32  `json:"quantity"`
+	Unit        string `json:"unit,omitempty"`          // satuan quantity, default base unit
+	UnitCostIdr *int64 `json:"unit_cost_idr,omitempty"` // per unit; default: product cost_idr
+	Reference   string `json:"reference,omitempty"`     // e.g. supplier invoice number
+	ReceivedAt  string `json:"received_at,omitempty"`   // RFC3339, default now
+	WarehouseID int32  `json:"warehouse_id,omitempty"`  // default warehouse when omitted
+	LotNumber   string `json:"lot_number,omitempty"`    // receive into this lot
+	ExpiryDate  string `json:"expiry_date,omitempty"`   // YYYY-MM-DD, only with lot_number
+	// one per unit, required for products with track_serials
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+}
+
+// ReceiveProductStock handles POST /products/{id}/receipts: adds stock, opens a new cost layer
+// and, with lot_number, books the units into that lot. Serial-tracked products register
+// one serial number per received unit.
+func (s *Server) ReceiveProductStock(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req ReceiveStockRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Quantity <= 0 {
+		http.Error(w, "quantity must be positive", http.StatusBadRequest)
+		return
+	}
+	if req.UnitCostIdr != nil && *req.UnitCostIdr < 0 {
+		http.Error(w, "unit_cost_idr must not be negative", http.StatusBadRequest)
+		return
+	}
+	receivedAt, err := parseReceivedAt(req.ReceivedAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lotNumber, expiry, err := parseLot(req.LotNumber, req.ExpiryDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	serials, err := cleanSerials(req.SerialNumbers)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+
+	// dibeli per karton, disimpan per satuan dasar
+	quantity, factor, err := toBaseQuantity(r.Context(), s.Repo, product, req.Unit, req.Quantity)
+	if err != nil {
+		if errors.Is(err, errUnknownUnit) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "failed to convert unit: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if req.UnitCostIdr != nil && factor > 1 {
+		perBase := *req.UnitCostIdr / int64(factor)
+		req.UnitCostIdr = &perBase
+	}
+
+	var resp StockChangeResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		var err error
+		resp, err = s.receiveStock(r.Context(), q, product, stockChange{
+			ProductID:   id,
+			Delta:       quantity,
+			Reason:      movementReasonReceipt,
+			Reference:   strings.TrimSpace(req.Reference),
+			UnitCostIdr: req.UnitCostIdr,
+			ReceivedAt:  receivedAt,
+			WarehouseID: req.WarehouseID,
+			LotNumber:   lotNumber,
+			ExpiryDate:  expiry,
+		}, serials)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errWarehouseNotFound) || errors.Is(err, errInvalidReceipt) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, errDuplicateSerial) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to receive stock: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ListCostLayers handles GET /products/{id}/cost-layers (newest first)
+func (s *Server) ListCostLayers(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+
+	layers, err := s.Repo.ListCostLayersByProduct(r.Context(), repo.ListCostLayersByProductParams{
+		IDFromProduct: id,
+		Limit:         int32(queryInt(r, "limit", 50, 1, 200)),
+		Offset:        int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	})
+	if err != nil {
+		http.Error(w, "failed to list cost layers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if layers == nil {
+		layers = []repo.CostLayer{}
+	}
+	writeJSON(w, http.StatusOK, layers)
+}
+
+// InventoryValuation is the response of GET /products/valuation
+type InventoryValuation struct {
+	AsOf          time.Time                       `json:"as_of"`
+	Method        CostingMethod                   `json:"method"`
+	TotalQuantity int64                           `json:"total_quantity"`
+	TotalValueIdr int64                           `json:"total_value_idr"`
+	Items         []repo.GetInventoryValuationRow `json:"items"`
+}
+
+// GetInventoryValuation handles GET /products/valuation?as_of=: stock value per product at a
+// point in time (default now), e.g. for year-end closing
+func (s *Server) GetInventoryValuation(w http.ResponseWriter, r *http.Request) {
+	asOf := time.Now()
+	if raw := r.URL.Query().Get("as_of"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			// a plain date means the end of that day
+			d, derr := time.ParseInLocation(time.DateOnly, raw, time.Local)
+			if derr != nil {
+				http.Error(w, "invalid as_of, must be RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			t = d.AddDate(0, 0, 1).Add(-time.Microsecond)
+		}
+		asOf = t
+	}
+
+	rows, err := s.Repo.GetInventoryValuation(r.Context(), pgtype.Timestamptz{Time: asOf, Valid: true})
+	if err != nil {
+		http.Error(w, "failed to get inventory valuation: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := InventoryValuation{
+		AsOf:   asOf,
+		Method: s.Costing,
+		Items:  rows,
+	}
+	if resp.Method == "" {
+		resp.Method = CostingFIFO
+	}
+	if resp.Items == nil {
+		resp.Items = []repo.GetInventoryValuationRow{}
+	}
+	for _, row := range rows {
+		resp.TotalQuantity += row.Quantity
+		resp.TotalValueIdr += row.ValueIdr
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
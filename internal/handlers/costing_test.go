@@ -0,0 +1,56 @@
+package handlers
+
+import "testing"
+
+func TestParseCostingMethod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    CostingMethod
+		wantErr bool
+	}{
+		{"", CostingFIFO, false},
+		{"fifo", CostingFIFO, false},
+		{" FIFO ", CostingFIFO, false},
+		{"average", CostingAverage, false},
+		{"Average", CostingAverage, false},
+		{"lifo", "", true},
+		{"avg", "", true},
+		{"weighted average", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCostingMethod(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCostingMethod(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCostingMethod(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAverageUnitCost(t *testing.T) {
+	tests := []struct {
+		name            string
+		value, quantity int64
+		want            int64
+	}{
+		{"no units", 5000, 0, 0},
+		{"negative units", 5000, -2, 0},
+		{"single layer", 30000, 3, 10000},
+		// 10 @ 1000 + 20 @ 1300 = 36000 / 30
+		{"two layers", 36000, 30, 1200},
+		{"rounds up above half", 10001, 3, 3334}, // 3333.67
+		{"rounds down", 10000, 3, 3333},          // 3333.33
+		{"rounds half up", 15, 2, 8},             // 7.5
+		{"rounds half up, odd count", 7, 2, 4},   // 3.5
+		{"less than one rupiah", 1, 3, 0},
+		{"half a rupiah", 1, 2, 1},
+		{"large value", 9_000_000_000_000, 3, 3_000_000_000_000},
+	}
+	for _, tt := range tests {
+		if got := averageUnitCost(tt.value, tt.quantity); got != tt.want {
+			t.Errorf("%s: averageUnitCost(%d, %d) = %d, want %d", tt.name, tt.value, tt.quantity, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+	"github.com/nichorainer/backend-go/internal/middleware"
+)
+
+// urlParamInt32 parses a numeric route param (e.g. {id}) into int32, the type sqlc uses for SERIAL keys
+func urlParamInt32(r *http.Request, name string) (int32, error) {
+	raw := chi.URLParam(r, name)
+	if raw == "" {
+		return 0, errors.New("missing " + name)
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid " + name)
+	}
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		return 0, errors.New(name + " out of range")
+	}
+	return int32(v), nil
+}
+
+// queryInt reads an integer query param, falling back to def and clamping to [lo, hi]
+func queryInt(r *http.Request, name string, def, lo, hi int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return def
+	}
+	return min(max(v, lo), hi)
+}
+
+// decodeJSON decodes a strict JSON body (unknown fields are rejected)
+func decodeJSON(r *http.Request, dst any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(dst)
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation (SQLSTATE 23505)
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isCheckViolation reports whether err is a Postgres CHECK constraint violation (SQLSTATE 23514)
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514"
+}
+
+// isForeignKeyViolation reports whether err is a Postgres foreign key violation (SQLSTATE 23503)
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// productOr404 fetches a product by products.id, writing a 404/500 response when it cannot
+func (s *Server) productOr404(w http.ResponseWriter, r *http.Request, id int32) (repo.Product, bool) {
+	product, err := s.Repo.GetProductByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "product not found", http.StatusNotFound)
+			return repo.Product{}, false
+		}
+		http.Error(w, "failed to fetch product: "+err.Error(), http.StatusInternalServerError)
+		return repo.Product{}, false
+	}
+	return product, true
+}
+
+// inTx runs fn with queries bound to a single database transaction, committing when fn
+// returns nil and rolling back otherwise
+func (s *Server) inTx(ctx context.Context, fn func(q *repo.Queries) error) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(repo.New(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// requestUser is the caller from the verified login token as a nullable users.id reference
+// (who did something); requests without a valid token record no one
+func requestUser(r *http.Request) pgtype.Int4 {
+	id, ok := middleware.UserID(r)
+	return pgtype.Int4{Int32: id, Valid: ok}
+}
+
+// requireAdmin resolves the caller from the verified login token, writing 401/403 unless it is
+// an admin
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) (repo.UserByIDRow, bool) {
+	id, ok := middleware.UserID(r)
+	if !ok {
+		http.Error(w, "login required", http.StatusUnauthorized)
+		return repo.UserByIDRow{}, false
+	}
+	user, err := s.Repo.UserByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "unknown user", http.StatusUnauthorized)
+			return repo.UserByIDRow{}, false
+		}
+		http.Error(w, "failed to fetch user: "+err.Error(), http.StatusInternalServerError)
+		return repo.UserByIDRow{}, false
+	}
+	if user.Role != "admin" {
+		http.Error(w, "admin role required", http.StatusForbidden)
+		return repo.UserByIDRow{}, false
+	}
+	return user, true
+}
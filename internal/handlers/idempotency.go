@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+	"github.com/nichorainer/backend-go/internal/middleware"
+)
+
+// IdempotencyKeyHeader lets clients retry a POST without running it twice
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// DefaultIdempotencyTTL is how long a stored response is replayed when IDEMPOTENCY_TTL is unset
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// maxIdempotencyKeyLen keeps keys to the size of a UUID with room for a prefix
+const maxIdempotencyKeyLen = 255
+
+// idempotencyLease is how long a claimed key stays locked while its request runs. It outlasts
+// the request timeout, so a key still unanswered after that belongs to a process that died and
+// the next retry may take it over.
+const idempotencyLease = 2 * time.Minute
+
+// idempotencyRecorder passes the response through to the client and keeps a copy to store
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotencyTTL is the configured replay window, or the default
+func (s *Server) idempotencyTTL() time.Duration {
+	if s.IdempotencyTTL <= 0 {
+		return DefaultIdempotencyTTL
+	}
+	return s.IdempotencyTTL
+}
+
+// idempotencyCaller scopes a key to whoever sent it: the logged-in user, or the client address
+// for requests without a login token, so two clients picking the same key never share responses
+func idempotencyCaller(r *http.Request) string {
+	if id, ok := middleware.UserID(r); ok {
+		return "user:" + strconv.Itoa(int(id))
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// Idempotent is middleware for POST requests carrying an Idempotency-Key header; it is mounted
+// on the create routes only (orders, products, stock), never on auth. The first request with a
+// key runs and its response is stored; retries with the same key and body get
+// that response again (marked with Idempotent-Replayed: true) until the key expires. Reusing a
+// key with a different body is rejected with 422, and a retry that arrives while the first
+// request is still running gets 409 (until the lease runs out, should that request never
+// finish). Server errors (5xx) are not stored so the client can retry.
+func (s *Server) Idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		ctx := r.Context()
+		now := time.Now()
+		caller := idempotencyCaller(r)
+		claimed, err := s.Repo.ClaimIdempotencyKey(ctx, repo.ClaimIdempotencyKeyParams{
+			IdempotencyKey: key,
+			Method:         r.Method,
+			Path:           r.URL.Path,
+			Caller:         caller,
+			RequestHash:    hash,
+			ExpiresAt:      pgtype.Timestamptz{Time: now.Add(s.idempotencyTTL()), Valid: true},
+			LockedUntil:    pgtype.Timestamptz{Time: now.Add(idempotencyLease), Valid: true},
+		})
+		if err != nil {
+			http.Error(w, "failed to check idempotency key: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if claimed == 0 {
+			s.replayIdempotent(w, r, key, caller, hash)
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			// the request may have timed out; the key must still be settled
+			ctx := context.WithoutCancel(ctx)
+			params := repo.DeleteIdempotencyKeyParams{IdempotencyKey: key, Method: r.Method, Path: r.URL.Path, Caller: caller}
+			if !completed || rec.status >= http.StatusInternalServerError {
+				// panicked or failed: free the key so a retry runs the request again
+				if err := s.Repo.DeleteIdempotencyKey(ctx, params); err != nil {
+					log.Printf("failed to release idempotency key %q: %v", key, err)
+				}
+				return
+			}
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			contentType := rec.Header().Get("Content-Type")
+			if err := s.Repo.SaveIdempotencyResponse(ctx, repo.SaveIdempotencyResponseParams{
+				IdempotencyKey: key,
+				Method:         r.Method,
+				Path:           r.URL.Path,
+				Caller:         caller,
+				StatusCode:     pgtype.Int4{Int32: int32(status), Valid: true},
+				ContentType:    pgtype.Text{String: contentType, Valid: contentType != ""},
+				ResponseBody:   rec.body.Bytes(),
+			}); err != nil {
+				log.Printf("failed to store response for idempotency key %q: %v", key, err)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+		completed = true
+	})
+}
+
+// replayIdempotent answers a request whose key is already taken
+func (s *Server) replayIdempotent(w http.ResponseWriter, r *http.Request, key, caller, hash string) {
+	stored, err := s.Repo.GetIdempotencyKey(r.Context(), repo.GetIdempotencyKeyParams{
+		IdempotencyKey: key,
+		Method:         r.Method,
+		Path:           r.URL.Path,
+		Caller:         caller,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// the first request failed and released the key in the meantime
+		http.Error(w, "request with this Idempotency-Key failed, retry", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to check idempotency key: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if stored.RequestHash != hash {
+		http.Error(w, "Idempotency-Key was already used with a different request body", http.StatusUnprocessableEntity)
+		return
+	}
+	if !stored.StatusCode.Valid {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "request with this Idempotency-Key is still being processed", http.StatusConflict)
+		return
+	}
+
+	if stored.ContentType.Valid {
+		w.Header().Set("Content-Type", stored.ContentType.String)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(stored.StatusCode.Int32))
+	w.Write(stored.ResponseBody)
+}
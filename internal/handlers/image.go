@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoder for image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+	"github.com/nichorainer/backend-go/internal/utils"
+)
+
+const (
+	maxImageBytes     = 5 << 20 // per file
+	maxImagesPerBatch = 10
+	maxImagePixels    = 40_000_000 // reject decompression bombs before decoding
+	thumbnailSize     = 320
+)
+
+// allowedImageTypes maps sniffed content types to the file extension used for the stored blob
+var allowedImageTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// ProductImageResponse is a product image as returned to the frontend
+type ProductImageResponse struct {
+	ID           int32              `json:"id"`
+	URL          string             `json:"url"`
+	ThumbnailURL string             `json:"thumbnail_url"`
+	ContentType  string             `json:"content_type"`
+	SizeBytes    int64              `json:"size_bytes"`
+	Width        int32              `json:"width"`
+	Height       int32              `json:"height"`
+	SortOrder    int32              `json:"sort_order"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+func (s *Server) newImageResponse(img repo.ProductImage) ProductImageResponse {
+	return ProductImageResponse{
+		ID:           img.ID,
+		URL:          s.Blobs.URL(img.StorageKey),
+		ThumbnailURL: s.Blobs.URL(img.ThumbnailKey),
+		ContentType:  img.ContentType,
+		SizeBytes:    img.SizeBytes,
+		Width:        img.Width,
+		Height:       img.Height,
+		SortOrder:    img.SortOrder,
+		CreatedAt:    img.CreatedAt,
+	}
+}
+
+// UploadProductImages handles POST /products/{id}/images (multipart/form-data, field "images", one or more files)
+func (s *Server) UploadProductImages(w http.ResponseWriter, r *http.Request) {
+	productID, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.Repo.GetProductByID(r.Context(), productID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch product: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImagesPerBatch*maxImageBytes+(1<<20))
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		http.Error(w, "invalid multipart form: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File["images"]
+	if len(files) == 0 {
+		http.Error(w, "no files in field 'images'", http.StatusBadRequest)
+		return
+	}
+	if len(files) > maxImagesPerBatch {
+		http.Error(w, fmt.Sprintf("at most %d images per upload", maxImagesPerBatch), http.StatusBadRequest)
+		return
+	}
+
+	// validate everything before storing anything, so a bad file does not leave a half-done upload
+	uploads := make([]imageUpload, 0, len(files))
+	for _, fh := range files {
+		up, err := readImageUpload(fh)
+		if err != nil {
+			http.Error(w, fh.Filename+": "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		uploads = append(uploads, up)
+	}
+
+	existing, err := s.Repo.CountImagesByProduct(r.Context(), productID)
+	if err != nil {
+		http.Error(w, "failed to count images: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ProductImageResponse, 0, len(uploads))
+	for i, up := range uploads {
+		img, err := s.storeImage(r.Context(), productID, int32(existing)+int32(i), up)
+		if err != nil {
+			log.Printf("failed to store image %s for product %d: %v", up.filename, productID, err)
+			http.Error(w, "failed to store image: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp = append(resp, s.newImageResponse(img))
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// DeleteProductImage handles DELETE /products/{id}/images/{imageId}
+func (s *Server) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	productID, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	imageID, err := urlParamInt32(r, "imageId")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	img, err := s.Repo.GetProductImageByID(r.Context(), imageID)
+	if err != nil || img.IDFromProduct != productID {
+		http.Error(w, "image not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.Repo.DeleteProductImage(r.Context(), imageID); err != nil {
+		http.Error(w, "failed to delete image: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// the row is gone, so a leftover blob is only wasted space; log instead of failing the request
+	for _, key := range []string{img.StorageKey, img.ThumbnailKey} {
+		if err := s.Blobs.Delete(r.Context(), key); err != nil {
+			log.Printf("failed to delete blob %s: %v", key, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// imageUpload is a validated, fully read upload
+type imageUpload struct {
+	filename    string
+	contentType string
+	data        []byte
+	img         image.Image
+}
+
+func readImageUpload(fh *multipart.FileHeader) (imageUpload, error) {
+	if fh.Size > maxImageBytes {
+		return imageUpload{}, fmt.Errorf("file too large (max %d MB)", maxImageBytes>>20)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return imageUpload{}, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxImageBytes+1))
+	if err != nil {
+		return imageUpload{}, err
+	}
+	if len(data) > maxImageBytes {
+		return imageUpload{}, fmt.Errorf("file too large (max %d MB)", maxImageBytes>>20)
+	}
+
+	// trust the content, not the client-supplied Content-Type header
+	contentType := http.DetectContentType(data)
+	if _, ok := allowedImageTypes[contentType]; !ok {
+		return imageUpload{}, fmt.Errorf("unsupported image type %q (allowed: jpeg, png, gif)", contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return imageUpload{}, errors.New("invalid image data")
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return imageUpload{}, errors.New("image dimensions too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return imageUpload{}, errors.New("invalid image data")
+	}
+
+	return imageUpload{
+		filename:    fh.Filename,
+		contentType: contentType,
+		data:        data,
+		img:         img,
+	}, nil
+}
+
+// storeImage writes the original and a thumbnail to the blob store and records them in product_images
+func (s *Server) storeImage(ctx context.Context, productID, sortOrder int32, up imageUpload) (repo.ProductImage, error) {
+	base := fmt.Sprintf("products/%d/%s", productID, uuid.NewString())
+	key := base + "." + allowedImageTypes[up.contentType]
+
+	// JPEG thumbnails for photos; PNG for formats that may carry transparency
+	var thumb bytes.Buffer
+	small := utils.Thumbnail(up.img, thumbnailSize)
+	thumbKey, thumbType := base+"_thumb.png", "image/png"
+	if up.contentType == "image/jpeg" {
+		thumbKey, thumbType = base+"_thumb.jpg", "image/jpeg"
+		err := jpeg.Encode(&thumb, small, &jpeg.Options{Quality: 85})
+		if err != nil {
+			return repo.ProductImage{}, err
+		}
+	} else if err := png.Encode(&thumb, small); err != nil {
+		return repo.ProductImage{}, err
+	}
+
+	if err := s.Blobs.Put(ctx, key, bytes.NewReader(up.data), up.contentType); err != nil {
+		return repo.ProductImage{}, err
+	}
+	if err := s.Blobs.Put(ctx, thumbKey, &thumb, thumbType); err != nil {
+		s.Blobs.Delete(ctx, key)
+		return repo.ProductImage{}, err
+	}
+
+	b := up.img.Bounds()
+	img, err := s.Repo.CreateProductImage(ctx, repo.CreateProductImageParams{
+		IDFromProduct: productID,
+		StorageKey:    key,
+		ThumbnailKey:  thumbKey,
+		ContentType:   up.contentType,
+		SizeBytes:     int64(len(up.data)),
+		Width:         int32(b.Dx()),
+		Height:        int32(b.Dy()),
+		SortOrder:     sortOrder,
+	})
+	if err != nil {
+		s.Blobs.Delete(ctx, key)
+		s.Blobs.Delete(ctx, thumbKey)
+		return repo.ProductImage{}, err
+	}
+	return img, nil
+}
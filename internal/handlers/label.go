@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+	"github.com/nichorainer/backend-go/internal/barcode"
+	"github.com/nichorainer/backend-go/internal/pdf"
+	"github.com/nichorainer/backend-go/internal/utils"
+)
+
+// GetProductBarcode handles GET /products/{id}/barcode?type=code128|ean13&format=png|svg
+// The barcode encodes the product's product_id code. EAN-13 requires a numeric 12/13 digit code.
+func (s *Server) GetProductBarcode(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+
+	var bars barcode.Bars
+	caption := product.ProductID
+	switch strings.ToLower(r.URL.Query().Get("type")) {
+	case "", "code128":
+		bars, err = barcode.Code128(product.ProductID)
+	case "ean13":
+		bars, caption, err = barcode.EAN13(product.ProductID)
+	default:
+		http.Error(w, "type must be code128 or ean13", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	scale := queryInt(r, "scale", 2, 1, 10)
+	height := queryInt(r, "height", 80, 10, 600)
+
+	var buf bytes.Buffer
+	contentType, err := renderBars(&buf, bars, r.URL.Query().Get("format"), scale, height, caption)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(buf.Bytes())
+}
+
+// GetProductQRCode handles GET /products/{id}/qrcode?format=png|svg
+func (s *Server) GetProductQRCode(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+
+	qr, err := barcode.QR(product.ProductID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	scale := queryInt(r, "scale", 8, 1, 40)
+	var buf bytes.Buffer
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "", "png":
+		err = qr.PNG(&buf, scale)
+		w.Header().Set("Content-Type", "image/png")
+	case "svg":
+		err = qr.SVG(&buf, scale)
+		w.Header().Set("Content-Type", "image/svg+xml")
+	default:
+		http.Error(w, "format must be png or svg", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to render qr code: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(buf.Bytes())
+}
+
+func renderBars(buf *bytes.Buffer, bars barcode.Bars, format string, scale, height int, caption string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "png":
+		return "image/png", bars.PNG(buf, scale, height)
+	case "svg":
+		return "image/svg+xml", bars.SVG(buf, scale, height, caption)
+	default:
+		return "", errors.New("format must be png or svg")
+	}
+}
+
+// PrintLabelsRequest is the body of POST /products/labels
+type PrintLabelsRequest struct {
+	IDs    []int32 `json:"ids"`    // products.id
+	Copies int     `json:"copies"` // labels per product, default 1
+	Format string  `json:"format"` // "pdf" (label sheet) or "zpl" (thermal printer)
+}
+
+const maxLabelCopies = 100
+
+// PrintProductLabels handles POST /products/labels and returns a printable label sheet
+// (A4 PDF, 3 x 7 labels) or ZPL for thermal printers. Each label shows the product name,
+// price in rupiah and a Code 128 barcode of product_id.
+func (s *Server) PrintProductLabels(w http.ResponseWriter, r *http.Request) {
+	var req PrintLabelsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		http.Error(w, "ids must not be empty", http.StatusBadRequest)
+		return
+	}
+	if req.Copies == 0 {
+		req.Copies = 1
+	}
+	if req.Copies < 0 || req.Copies > maxLabelCopies {
+		http.Error(w, fmt.Sprintf("copies must be between 1 and %d", maxLabelCopies), http.StatusBadRequest)
+		return
+	}
+
+	products, err := s.Repo.GetProductsByIDs(r.Context(), req.IDs)
+	if err != nil {
+		http.Error(w, "failed to fetch products: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(products) != len(uniqueIDs(req.IDs)) {
+		http.Error(w, "one or more products not found", http.StatusNotFound)
+		return
+	}
+
+	// keep the order the user selected
+	byID := make(map[int32]repo.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	ordered := make([]repo.Product, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		ordered = append(ordered, byID[id])
+	}
+
+	switch strings.ToLower(req.Format) {
+	case "", "pdf":
+		doc, err := labelSheet(ordered, req.Copies)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		var buf bytes.Buffer
+		doc.WriteTo(&buf)
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `inline; filename="labels.pdf"`)
+		w.Write(buf.Bytes())
+	case "zpl":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="labels.zpl"`)
+		w.Write([]byte(labelsZPL(ordered, req.Copies)))
+	default:
+		http.Error(w, "format must be pdf or zpl", http.StatusBadRequest)
+	}
+}
+
+func uniqueIDs(ids []int32) map[int32]struct{} {
+	set := make(map[int32]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
+// label sheet geometry (3 x 7 labels of 63.5 x 38.1 mm on A4)
+const (
+	labelCols    = 3
+	labelRows    = 7
+	labelWidth   = 63.5 * pdf.MM
+	labelHeight  = 38.1 * pdf.MM
+	labelMarginX = 7.2 * pdf.MM
+	labelMarginY = 15.15 * pdf.MM
+	labelGapX    = 2.5 * pdf.MM
+	labelPadding = 3 * pdf.MM
+)
+
+func labelSheet(products []repo.Product, copies int) (*pdf.Document, error) {
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	var page *pdf.Page
+	n := 0
+	for _, p := range products {
+		bars, err := barcode.Code128(p.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.ProductID, err)
+		}
+		for c := 0; c < copies; c++ {
+			slot := n % (labelCols * labelRows)
+			if slot == 0 {
+				page = doc.AddPage()
+			}
+			x := labelMarginX + float64(slot%labelCols)*(labelWidth+labelGapX)
+			y := labelMarginY + float64(slot/labelCols)*labelHeight
+			drawLabel(page, x, y, p, bars)
+			n++
+		}
+	}
+	return doc, nil
+}
+
+func drawLabel(page *pdf.Page, x, y float64, p repo.Product, bars barcode.Bars) {
+	inner := labelWidth - 2*labelPadding
+	left := x + labelPadding
+
+	page.Text(left, y+labelPadding+9, pdf.HelveticaBold, 9, pdf.Truncate(pdf.HelveticaBold, 9, inner, p.ProductName))
+	page.Text(left, y+labelPadding+21, pdf.Helvetica, 10, utils.FormatRupiah(p.PriceIdr))
+
+	// scale the bars to the label width, keeping whole modules crisp enough for scanners
+	module := inner / float64(len(bars))
+	barTop := y + labelPadding + 26
+	barHeight := labelHeight - 2*labelPadding - 26 - 9
+	for i := 0; i < len(bars); {
+		if !bars[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < len(bars) && bars[j] {
+			j++
+		}
+		page.FillRect(left+float64(i)*module, barTop, float64(j-i)*module, barHeight)
+		i = j
+	}
+	page.TextCenter(x+labelWidth/2, barTop+barHeight+8, pdf.Helvetica, 7, p.ProductID)
+}
+
+// labelsZPL renders one ZPL label per product (203 dpi, ~50 x 30 mm) with ^PQ for the copies
+func labelsZPL(products []repo.Product, copies int) string {
+	var sb strings.Builder
+	for _, p := range products {
+		sb.WriteString("^XA\n^CI28\n")
+		fmt.Fprintf(&sb, "^FO20,20^A0N,26,26^FB360,1,0,L^FD%s^FS\n", zplField(p.ProductName))
+		fmt.Fprintf(&sb, "^FO20,54^A0N,24,24^FD%s^FS\n", zplField(utils.FormatRupiah(p.PriceIdr)))
+		fmt.Fprintf(&sb, "^FO20,90^BY2^BCN,80,Y,N,N^FD%s^FS\n", zplField(p.ProductID))
+		fmt.Fprintf(&sb, "^PQ%d\n^XZ\n", copies)
+	}
+	return sb.String()
+}
+
+// zplField strips the ZPL command prefixes so product data cannot inject printer commands
+func zplField(s string) string {
+	return strings.NewReplacer("^", " ", "~", " ").Replace(s)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// changeLots applies a stock change to the lots at a location: into/out of the named lot, or
+// for outgoing units without a lot, out of the open lots first-expired-first-out (expired lots
+// included, so write-offs clear them first). Units beyond what the lots hold are untracked stock.
+func changeLots(ctx context.Context, q *repo.Queries, c stockChange, warehouseID int32) error {
+	if c.LotNumber != "" {
+		_, err := q.UpsertStockLot(ctx, repo.UpsertStockLotParams{
+			IDFromProduct:   c.ProductID,
+			IDFromWarehouse: warehouseID,
+			LotNumber:       c.LotNumber,
+			ExpiryDate:      c.ExpiryDate,
+			Quantity:        c.Delta,
+		})
+		if isCheckViolation(err) {
+			return fmt.Errorf("lot %s: %w", c.LotNumber, errInsufficientStock)
+		}
+		return err
+	}
+	if c.Delta >= 0 {
+		return nil
+	}
+
+	lots, err := q.ListOpenLots(ctx, repo.ListOpenLotsParams{
+		IDFromProduct:   c.ProductID,
+		IDFromWarehouse: warehouseID,
+		IncludeExpired:  true,
+	})
+	if err != nil {
+		return err
+	}
+	qty := -c.Delta
+	for _, l := range lots {
+		if qty == 0 {
+			break
+		}
+		take := min(qty, l.Quantity)
+		if err := q.TakeFromLot(ctx, repo.TakeFromLotParams{ID: l.ID, Quantity: take}); err != nil {
+			return err
+		}
+		qty -= take
+	}
+	return nil
+}
+
+// allocateLots takes qty units for an order from the product's unexpired lots at the location,
+// first-expired-first-out, and records the allocation. Products without lots at the location are
+// not lot-tracked and pass; lot-tracked products must be covered by unexpired lots in full.
+func allocateLots(ctx context.Context, q *repo.Queries, productID, warehouseID, qty, orderID int32) error {
+	tracked, err := q.ListOpenLots(ctx, repo.ListOpenLotsParams{
+		IDFromProduct:   productID,
+		IDFromWarehouse: warehouseID,
+		IncludeExpired:  true,
+	})
+	if err != nil || len(tracked) == 0 {
+		return err
+	}
+
+	lots, err := q.ListOpenLots(ctx, repo.ListOpenLotsParams{
+		IDFromProduct:   productID,
+		IDFromWarehouse: warehouseID,
+		IncludeExpired:  false,
+	})
+	if err != nil {
+		return err
+	}
+	for _, l := range lots {
+		if qty == 0 {
+			break
+		}
+		take := min(qty, l.Quantity)
+		if err := q.TakeFromLot(ctx, repo.TakeFromLotParams{ID: l.ID, Quantity: take}); err != nil {
+			return err
+		}
+		if _, err := q.CreateLotAllocation(ctx, repo.CreateLotAllocationParams{
+			IDFromLot:   l.ID,
+			IDFromOrder: orderID,
+			Quantity:    take,
+		}); err != nil {
+			return err
+		}
+		qty -= take
+	}
+	if qty > 0 {
+		return fmt.Errorf("%w: %d more unit(s) needed from unexpired lots", errInsufficientStock, qty)
+	}
+	return nil
+}
+
+// ListProductLots handles GET /products/{id}/lots (lots with stock, soonest expiry first)
+func (s *Server) ListProductLots(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+
+	lots, err := s.Repo.ListLotsByProductIDs(r.Context(), []int32{id})
+	if err != nil {
+		http.Error(w, "failed to list lots: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if lots == nil {
+		lots = []repo.StockLot{}
+	}
+	writeJSON(w, http.StatusOK, lots)
+}
+
+// ListExpiringLots handles GET /lots/expiring?days=30&warehouse_id=: lots expiring within the
+// window plus lots already expired (negative days_left) that still hold stock
+func (s *Server) ListExpiringLots(w http.ResponseWriter, r *http.Request) {
+	var warehouseID pgtype.Int4
+	if raw := r.URL.Query().Get("warehouse_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			http.Error(w, "invalid warehouse_id", http.StatusBadRequest)
+			return
+		}
+		warehouseID = pgtype.Int4{Int32: int32(id), Valid: true}
+	}
+
+	lots, err := s.Repo.ListExpiringLots(r.Context(), repo.ListExpiringLotsParams{
+		Days:        int32(queryInt(r, "days", 30, 0, 3650)),
+		WarehouseID: warehouseID,
+	})
+	if err != nil {
+		http.Error(w, "failed to list expiring lots: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if lots == nil {
+		lots = []repo.ListExpiringLotsRow{}
+	}
+	writeJSON(w, http.StatusOK, lots)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// ProductMargin is one row of the per-product margin report
+type ProductMargin struct {
+	repo.GetMarginsByProductRow
+	MarginPct float64 `json:"margin_pct"`
+}
+
+// PlatformMargin is one row of the per-platform margin report
+type PlatformMargin struct {
+	repo.GetMarginsByPlatformRow
+	MarginPct float64 `json:"margin_pct"`
+}
+
+// marginPct is gross margin as a percentage of revenue, rounded to two decimals
+func marginPct(margin, revenue int64) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(margin)*10000/float64(revenue)) / 100
+}
+
+// createdRange reads the optional ?from= and ?to= filters (RFC3339 or YYYY-MM-DD, to is exclusive)
+func createdRange(r *http.Request) (from, to pgtype.Timestamptz, err error) {
+	parse := func(name string) (pgtype.Timestamptz, error) {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			return pgtype.Timestamptz{}, nil
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return pgtype.Timestamptz{Time: t, Valid: true}, nil
+		}
+		if t, err := time.ParseInLocation(time.DateOnly, raw, time.Local); err == nil {
+			return pgtype.Timestamptz{Time: t, Valid: true}, nil
+		}
+		return pgtype.Timestamptz{}, errors.New("invalid " + name + ", must be RFC3339 or YYYY-MM-DD")
+	}
+
+	if from, err = parse("from"); err != nil {
+		return
+	}
+	to, err = parse("to")
+	return
+}
+
+// GetOrderMargins handles GET /orders/margins: revenue, COGS and gross margin per order
+func (s *Server) GetOrderMargins(w http.ResponseWriter, r *http.Request) {
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.Repo.GetOrderMargins(r.Context(), repo.GetOrderMarginsParams{
+		CreatedFrom: from,
+		CreatedTo:   to,
+		RowLimit:    int32(queryInt(r, "limit", 100, 1, 500)),
+		RowOffset:   int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	})
+	if err != nil {
+		http.Error(w, "failed to get order margins: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows == nil {
+		rows = []repo.GetOrderMarginsRow{}
+	}
+	writeJSON(w, http.StatusOK, rows)
+}
+
+// GetMarginsByProduct handles GET /orders/margins/products
+func (s *Server) GetMarginsByProduct(w http.ResponseWriter, r *http.Request) {
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.Repo.GetMarginsByProduct(r.Context(), repo.GetMarginsByProductParams{
+		CreatedFrom: from,
+		CreatedTo:   to,
+	})
+	if err != nil {
+		http.Error(w, "failed to get product margins: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ProductMargin, len(rows))
+	for i, row := range rows {
+		resp[i] = ProductMargin{row, marginPct(row.GrossMarginIdr, row.RevenueIdr)}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetMarginsByPlatform handles GET /orders/margins/platforms
+func (s *Server) GetMarginsByPlatform(w http.ResponseWriter, r *http.Request) {
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.Repo.GetMarginsByPlatform(r.Context(), repo.GetMarginsByPlatformParams{
+		CreatedFrom: from,
+		CreatedTo:   to,
+	})
+	if err != nil {
+		http.Error(w, "failed to get platform margins: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]PlatformMargin, len(rows))
+	for i, row := range rows {
+		resp[i] = PlatformMargin{row, marginPct(row.GrossMarginIdr, row.RevenueIdr)}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,22 +17,204 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+	"github.com/nichorainer/backend-go/internal/constants"
 )
 
 // CreateOrderParams represents the JSON payload from the frontend
 type CreateOrderParams struct {
-	OrderNumber   string `json:"order_number"`
-	IdFromProduct int32  `json:"id_from_product"` // FK ke products.id
-	ProductID     string `json:"product_id"`      // kode produk
-	CustomerName  string `json:"customer_name"`
-	Platform      string `json:"platform"`
-	Destination   string `json:"destination"`
-	TotalAmount   int32  `json:"total_amount"`
-	Status        string `json:"status"`
-	PriceIDR      int32  `json:"price_idr"`
-	CreatedAt     string `json:"created_at"`
+	OrderNumber  string             `json:"order_number,omitempty"` // diabaikan: nomor dibuat server
+	Items        []OrderItemRequest `json:"items,omitempty"` // baris order (produk + qty)
+	CustomerName string             `json:"customer_name"`
+	Platform     string             `json:"platform"`
+	Destination  string             `json:"destination"`
+	Status       string             `json:"status"`
+	CreatedAt    string             `json:"created_at"`
+	// lokasi pengambilan barang (opsional, default gudang utama)
+	IdFromWarehouse *int32 `json:"id_from_warehouse,omitempty"`
+
+	// single-product orders from older clients; used when items is empty
+	IdFromProduct int32  `json:"id_from_product,omitempty"`
+	IdFromVariant *int32 `json:"id_from_variant,omitempty"`
+	TotalAmount   int32  `json:"total_amount,omitempty"`
+	ProductID     string `json:"product_id,omitempty"` // diabaikan: kode diambil dari produk/varian
+	PriceIDR      int32  `json:"price_idr,omitempty"`  // diabaikan: harga diambil dari produk/varian
 }
 
+// OrderItemRequest is one line of a new order; code and price come from the product or variant
+type OrderItemRequest struct {
+	IdFromProduct int32  `json:"id_from_product"`
+	IdFromVariant *int32 `json:"id_from_variant,omitempty"` // FK ke product_variants.id (opsional)
+	Quantity      int32  `json:"quantity"`
+}
+
+// OrderResponse is an order with its lines
+type OrderResponse struct {
+	repo.Order
+	Items []repo.ListOrderItemsByOrderIDsRow `json:"items"`
+}
+
+// OrderDetail is the response of GET /orders/{id}: the order, its lines and status timeline
+type OrderDetail struct {
+	OrderResponse
+	History []repo.ListOrderStatusHistoryRow `json:"history"`
+}
+
+// recordOrderStatus appends a status change to the order's timeline (from "" = initial status)
+func recordOrderStatus(ctx context.Context, q *repo.Queries, orderID int32, from, to string, changedBy pgtype.Int4, note string) error {
+	_, err := q.CreateOrderStatusHistory(ctx, repo.CreateOrderStatusHistoryParams{
+		IDFromOrder: orderID,
+		FromStatus:  pgtype.Text{String: from, Valid: from != ""},
+		ToStatus:    to,
+		ChangedBy:   changedBy,
+		Note:        pgtype.Text{String: note, Valid: note != ""},
+	})
+	return err
+}
+
+// errInvalidOrder is returned for order lines that can't be placed (unknown product or variant)
+var errInvalidOrder = errors.New("invalid order")
+
+// orderResponses attaches the lines to each order
+func orderResponses(ctx context.Context, q repo.Querier, orders []repo.Order) ([]OrderResponse, error) {
+	ids := make([]int32, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	items, err := q.ListOrderItemsByOrderIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byOrder := make(map[int32][]repo.ListOrderItemsByOrderIDsRow, len(orders))
+	for _, it := range items {
+		byOrder[it.IDFromOrder] = append(byOrder[it.IDFromOrder], it)
+	}
+
+	resp := make([]OrderResponse, len(orders))
+	for i, o := range orders {
+		resp[i] = OrderResponse{Order: o, Items: byOrder[o.ID]}
+		if resp[i].Items == nil {
+			resp[i].Items = []repo.ListOrderItemsByOrderIDsRow{}
+		}
+	}
+	return resp, nil
+}
+
+// pricedItem is an order line resolved against the locked product row
+type pricedItem struct {
+	product   repo.Product
+	variantID pgtype.Int4
+	code      string
+	quantity  int32
+	unitPrice int64
+}
+
+// lockProducts locks the given products in id order, so concurrent orders can't deadlock
+func lockProducts(ctx context.Context, q *repo.Queries, ids []int32) (map[int32]repo.Product, error) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	products := make(map[int32]repo.Product, len(ids))
+	for _, id := range ids {
+		if _, ok := products[id]; ok {
+			continue
+		}
+		p, err := q.GetProductForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("%w: product %d not found", errInvalidOrder, id)
+			}
+			return nil, err
+		}
+		products[id] = p
+	}
+	return products, nil
+}
+
+// priceOrderItems locks the ordered products (in id order, so concurrent orders can't deadlock)
+// and takes code and price from the product or variant row, never from the client
+func priceOrderItems(ctx context.Context, q *repo.Queries, items []OrderItemRequest) ([]pricedItem, error) {
+	var ids []int32
+	seen := make(map[int32]bool, len(items))
+	for _, it := range items {
+		if !seen[it.IdFromProduct] {
+			seen[it.IdFromProduct] = true
+			ids = append(ids, it.IdFromProduct)
+		}
+	}
+	products, err := lockProducts(ctx, q, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	priced := make([]pricedItem, len(items))
+	for i, it := range items {
+		p := products[it.IdFromProduct]
+		priced[i] = pricedItem{product: p, code: p.ProductID, quantity: it.Quantity, unitPrice: p.PriceIdr}
+		// when a variant SKU is ordered, the line references the variant and carries its code
+		if it.IdFromVariant != nil {
+			v, err := q.GetProductVariantByID(ctx, *it.IdFromVariant)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return nil, fmt.Errorf("%w: variant %d not found", errInvalidOrder, *it.IdFromVariant)
+				}
+				return nil, err
+			}
+			if v.IDFromProduct != p.ID {
+				return nil, fmt.Errorf("%w: variant %d does not belong to product %d", errInvalidOrder, v.ID, p.ID)
+			}
+			priced[i].variantID = pgtype.Int4{Int32: v.ID, Valid: true}
+			priced[i].code = v.ProductID
+			if v.PriceIdr.Valid {
+				priced[i].unitPrice = v.PriceIdr.Int64
+			}
+		}
+	}
+	return priced, nil
+}
+
+// holdOrderStock takes the stock of one order item at the pick location: it is reserved until
+// the order ships or is cancelled, lot-tracked units are allocated first-expired-first-out and
+// the cost layers are consumed. It returns what the units cost.
+func (s *Server) holdOrderStock(ctx context.Context, q *repo.Queries, order repo.Order, product repo.Product, variantID pgtype.Int4, warehouseID, qty int32) (int64, error) {
+	// bundles take their stock from pre-built kits and/or component products
+	lines, err := orderLines(ctx, q, product, warehouseID, qty)
+	if err != nil {
+		return 0, err
+	}
+	var cogs int64
+	for _, line := range lines {
+		// a variant line holds the variant's stock too; bundle components have no variant
+		lineVariant := pgtype.Int4{}
+		if line.ProductID == product.ID {
+			lineVariant = variantID
+		}
+		if err := reserveStock(ctx, q, order.ID, line.ProductID, warehouseID, line.Quantity, lineVariant); err != nil {
+			return 0, err
+		}
+
+		// lot-tracked products are picked first-expired-first-out, never from expired lots
+		if err := allocateLots(ctx, q, line.ProductID, warehouseID, line.Quantity, order.ID); err != nil {
+			return 0, err
+		}
+
+		cost, err := s.consumeCostLayers(ctx, q, line.ProductID, line.Quantity,
+			pgtype.Int4{Int32: order.ID, Valid: true}, order.OrderNumber, line.CostIdr)
+		if err != nil {
+			return 0, err
+		}
+		cogs += cost
+	}
+	return cogs, nil
+}
+
+// CreateOrder handles POST /orders: one order with one or more product lines. Totals are
+// computed here from the product prices. Every line is reserved at the pick location (409 when
+// short) and takes its lots and cost layers right away, which fixes the order's COGS; the
+// on-hand balances (products.stock, the location, the variant) are only deducted when the
+// order ships. Availability is checked against the row being sold, so a variant line needs
+// the variant's own stock as well.
+//
+// On-hand stock is not deducted with a delta update at creation, so it keeps matching what is
+// physically on the shelf until the goods leave. Cancelling or deleting an unshipped order
+// releases its reservations and puts back the lots and cost layers it took.
 func (s *Server) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var req CreateOrderParams
 	dec := json.NewDecoder(r.Body)
@@ -46,104 +230,514 @@ func (s *Server) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	normalizedStatus := strings.ToLower(req.Status)
-	if normalizedStatus == "shipped" {
-		normalizedStatus = "shipping"
+	normalizedStatus := constants.NormalizeOrderStatus(req.Status)
+	if normalizedStatus == "" {
+		normalizedStatus = constants.OrderStatusPending
+	}
+	if !constants.IsOrderStatus(normalizedStatus) {
+		http.Error(w, "unknown status "+strconv.Quote(req.Status)+", must be one of "+strings.Join(constants.OrderStatuses(), ", "), http.StatusBadRequest)
+		return
+	}
+
+	// payload lama: satu produk per order
+	if len(req.Items) == 0 && req.IdFromProduct != 0 {
+		req.Items = []OrderItemRequest{{
+			IdFromProduct: req.IdFromProduct,
+			IdFromVariant: req.IdFromVariant,
+			Quantity:      req.TotalAmount,
+		}}
+	}
+	if len(req.Items) == 0 {
+		http.Error(w, "items must not be empty", http.StatusBadRequest)
+		return
+	}
+	for _, it := range req.Items {
+		if it.Quantity <= 0 {
+			http.Error(w, "item quantity must be positive", http.StatusBadRequest)
+			return
+		}
 	}
 
-	arg := repo.CreateOrderParams{
-		OrderNumber:   req.OrderNumber,
-		IDFromProduct: pgtype.Int4{Int32: req.IdFromProduct, Valid: true},
-		ProductID:     pgtype.Text{String: req.ProductID, Valid: true},
-		CustomerName:  req.CustomerName,
-		Platform:      req.Platform,
-		Destination:   req.Destination,
-		TotalAmount:   pgtype.Int4{Int32: req.TotalAmount, Valid: true},
-		Status:        normalizedStatus,
-		PriceIdr:      pgtype.Int4{Int32: req.PriceIDR, Valid: true},
-		CreatedAt:     pgtype.Timestamptz{Time: createdAt, Valid: true},
+	// the order is picked from the chosen location, or the default warehouse
+	var warehouse repo.Warehouse
+	if req.IdFromWarehouse != nil {
+		warehouse, err = s.Repo.GetWarehouseByID(r.Context(), *req.IdFromWarehouse)
+	} else {
+		warehouse, err = s.Repo.GetDefaultWarehouse(r.Context())
 	}
-	order, err := s.Repo.CreateOrder(r.Context(), arg)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "warehouse not found", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "failed to fetch warehouse: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// each line consumes cost layers; the order's COGS is what those units cost
+	var resp OrderResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		items, err := priceOrderItems(r.Context(), q, req.Items)
+		if err != nil {
+			return err
+		}
+		var totalQty, totalIdr int64
+		for _, it := range items {
+			totalQty += int64(it.quantity)
+			totalIdr += int64(it.quantity) * it.unitPrice
+		}
+		if totalQty > math.MaxInt32 {
+			return fmt.Errorf("%w: total quantity out of range", errInvalidOrder)
+		}
+
+		// locks the period's counter until commit, see nextOrderNumber
+		orderNumber, err := s.nextOrderNumber(r.Context(), q, time.Now())
+		if err != nil {
+			return err
+		}
+		order, err := q.CreateOrder(r.Context(), repo.CreateOrderParams{
+			OrderNumber:     orderNumber,
+			CustomerName:    req.CustomerName,
+			Platform:        req.Platform,
+			Destination:     req.Destination,
+			TotalAmount:     pgtype.Int4{Int32: int32(totalQty), Valid: true},
+			TotalIdr:        totalIdr,
+			Status:          normalizedStatus,
+			CreatedAt:       pgtype.Timestamptz{Time: createdAt, Valid: true},
+			IDFromWarehouse: pgtype.Int4{Int32: warehouse.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		var cogs int64
+		for _, it := range items {
+			// an order entered as cancelled holds no stock, lots or cost layers
+			var itemCogs int64
+			if normalizedStatus != constants.OrderStatusCancelled {
+				cost, err := s.holdOrderStock(r.Context(), q, order, it.product, it.variantID, warehouse.ID, it.quantity)
+				if err != nil {
+					return err
+				}
+				itemCogs = cost
+			}
+
+			// capture the unit cost now so later cost changes don't rewrite past margins
+			if _, err := q.CreateOrderItem(r.Context(), repo.CreateOrderItemParams{
+				IDFromOrder:   order.ID,
+				IDFromProduct: pgtype.Int4{Int32: it.product.ID, Valid: true},
+				IDFromVariant: it.variantID,
+				ProductID:     it.code,
+				Quantity:      it.quantity,
+				UnitPriceIdr:  it.unitPrice,
+				LineTotalIdr:  int64(it.quantity) * it.unitPrice,
+				UnitCostIdr:   it.product.CostIdr,
+				CogsIdr:       pgtype.Int8{Int64: itemCogs, Valid: true},
+			}); err != nil {
+				return err
+			}
+			cogs += itemCogs
+		}
+		order, err = q.UpdateOrderCogs(r.Context(), repo.UpdateOrderCogsParams{
+			ID:      order.ID,
+			CogsIdr: pgtype.Int8{Int64: cogs, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordOrderStatus(r.Context(), q, order.ID, "", normalizedStatus, requestUser(r), ""); err != nil {
+			return err
+		}
+
+		// orders entered as already shipped leave the shelf right away; serial-tracked units
+		// can't, since nobody said which serials went out
+		if normalizedStatus == constants.OrderStatusShipping || normalizedStatus == constants.OrderStatusCompleted {
+			need, err := serialUnitsNeeded(r.Context(), q, order.ID)
+			if err != nil {
+				return err
+			}
+			if len(need) > 0 {
+				return fmt.Errorf("%w: orders with serial-tracked products must be created as pending and shipped with serial_numbers", errInvalidOrder)
+			}
+			if err := s.fulfilReservations(r.Context(), q, order); err != nil {
+				return err
+			}
+		}
+
+		orders, err := orderResponses(r.Context(), q, []repo.Order{order})
+		if err != nil {
+			return err
+		}
+		resp = orders[0]
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidOrder) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, errInsufficientStock) {
+			http.Error(w, "insufficient stock: "+err.Error(), http.StatusConflict)
+			return
+		}
+		if isUniqueViolation(err) {
+			// only after ORDER_NUMBER_FORMAT changed to one that repeats older numbers
+			http.Error(w, "order number already in use, check ORDER_NUMBER_FORMAT", http.StatusConflict)
+			return
+		}
 		http.Error(w, "failed to create order: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(order); err != nil {
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		http.Error(w, "failed to encode response", http.StatusInternalServerError)
 	}
 }
 
-// generateOrderNumber generates the next order number in the format #000001
-func generateOrderNumber(ctx context.Context, repo repo.Querier) (string, error) {
-	last, err := repo.GetLastOrderNumber(ctx)
+// GetNextOrderNumber handles GET /orders/order-number: a preview of the number the next order
+// would get. Nothing is reserved; the real number is assigned when the order is created.
+func (s *Server) GetNextOrderNumber(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	f := s.orderNumberFormat()
+	seq, err := s.Repo.PeekOrderNumberSequence(r.Context(), f.period(now))
 	if err != nil {
-		// if there aren't any orders yet, start with #000001
-		if errors.Is(err, pgx.ErrNoRows) {
-			return "#000001", nil
+		http.Error(w, "failed to generate order number: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"order_number": f.render(now, seq),
+		"preview":      true,
+		"note":         "not reserved; the order number is assigned when the order is created",
+	})
+}
+
+// likeEscaper escapes the LIKE wildcards in user input so it is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeText is an optional ILIKE filter value; empty means no filter
+func likeText(v string) pgtype.Text {
+	v = strings.TrimSpace(v)
+	return pgtype.Text{String: likeEscaper.Replace(v), Valid: v != ""}
+}
+
+// ListOrders lists orders, newest first, each with its lines. Optional filters: ?status=,
+// ?platform=, ?from=&to= (created), ?customer= and ?q= (order number); paged with ?limit= and
+// ?offset=. The totals of all matching orders are sent in the X-Total-Count, X-Total-Quantity
+// and X-Total-Idr headers.
+func (s *Server) ListOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var status pgtype.Text
+	if v := query.Get("status"); v != "" {
+		status = pgtype.Text{String: constants.NormalizeOrderStatus(v), Valid: true}
+		if !constants.IsOrderStatus(status.String) {
+			http.Error(w, "unknown status "+strconv.Quote(v)+", must be one of "+strings.Join(constants.OrderStatuses(), ", "), http.StatusBadRequest)
+			return
 		}
-		return "", err
 	}
 
-	// strip '#'
-	numStr := strings.TrimPrefix(last, "#")
+	filter := repo.GetOrderTotalsParams{
+		Status:      status,
+		Platform:    likeText(query.Get("platform")),
+		CreatedFrom: from,
+		CreatedTo:   to,
+		Customer:    likeText(query.Get("customer")),
+		Search:      likeText(query.Get("q")),
+	}
+	params := repo.ListOrdersParams{
+		Status:      filter.Status,
+		Platform:    filter.Platform,
+		CreatedFrom: filter.CreatedFrom,
+		CreatedTo:   filter.CreatedTo,
+		Customer:    filter.Customer,
+		Search:      filter.Search,
+		RowLimit:    int32(queryInt(r, "limit", 100, 1, 500)),
+		RowOffset:   int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	}
 
-	// parse to int
-	num, err := strconv.Atoi(numStr)
+	orders, err := s.Repo.ListOrders(r.Context(), params)
+	if err != nil {
+		http.Error(w, "failed to list orders", http.StatusInternalServerError)
+		return
+	}
+	totals, err := s.Repo.GetOrderTotals(r.Context(), filter)
 	if err != nil {
-		return "", fmt.Errorf("invalid order_number format: %s", last)
+		http.Error(w, "failed to count orders", http.StatusInternalServerError)
+		return
 	}
 
-	// increment
-	next := num + 1
+	for i := range orders {
+		orders[i].Status = constants.NormalizeOrderStatus(orders[i].Status)
+	}
 
-	// format to 6 digit with leading zero
-	return fmt.Sprintf("#%06d", next), nil
+	resp, err := orderResponses(r.Context(), s.Repo, orders)
+	if err != nil {
+		http.Error(w, "failed to list order items", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(totals.OrderCount, 10))
+	w.Header().Set("X-Total-Quantity", strconv.FormatInt(totals.TotalQuantity, 10))
+	w.Header().Set("X-Total-Idr", strconv.FormatInt(totals.TotalIdr, 10))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
 }
 
-// GetNextOrderNumber handles the HTTP request to get the next order number
-func (s *Server) GetNextOrderNumber(w http.ResponseWriter, r *http.Request) {
-    next, err := generateOrderNumber(r.Context(), s.Repo)
-    if err != nil {
-        http.Error(w, "failed to generate order number: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
+// GetOrder handles GET /orders/{id}: the full order with its lines and status timeline
+func (s *Server) GetOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(map[string]string{
-        "order_number": next,
-    })
+	order, err := s.Repo.GetOrderByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch order: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	order.Status = constants.NormalizeOrderStatus(order.Status)
+
+	orders, err := orderResponses(r.Context(), s.Repo, []repo.Order{order})
+	if err != nil {
+		http.Error(w, "failed to fetch order items: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	history, err := s.Repo.ListOrderStatusHistory(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to fetch order history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if history == nil {
+		history = []repo.ListOrderStatusHistoryRow{}
+	}
+
+	writeJSON(w, http.StatusOK, OrderDetail{OrderResponse: orders[0], History: history})
+}
+
+// errOrderLocked is returned when an order that has shipped is edited
+var errOrderLocked = errors.New("order can no longer be edited")
+
+// UpdateOrderRequest is the body of PATCH /orders/{id}; omitted fields stay as they are
+type UpdateOrderRequest struct {
+	CustomerName *string `json:"customer_name,omitempty"`
+	Destination  *string `json:"destination,omitempty"`
+	Platform     *string `json:"platform,omitempty"`
 }
 
-// ListOrdersWithProduct lists all orders along with their associated product details
-func (s *Server) ListOrdersWithProduct(w http.ResponseWriter, r *http.Request) {
-	params := repo.ListOrdersWithProductParams{
-		Limit:  100,
-		Offset: 0,
+// UpdateOrder handles PATCH /orders/{id}: corrects customer name, destination or platform of
+// an order that has not shipped yet (see constants.OrderEditable); later edits get 409
+func (s *Server) UpdateOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	orders, err := s.Repo.ListOrdersWithProduct(r.Context(), params)
+	var req UpdateOrderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	params := repo.UpdateOrderDetailsParams{ID: id}
+	for _, f := range []struct {
+		name  string
+		value *string
+		dst   *pgtype.Text
+	}{
+		{"customer_name", req.CustomerName, &params.CustomerName},
+		{"destination", req.Destination, &params.Destination},
+		{"platform", req.Platform, &params.Platform},
+	} {
+		if f.value == nil {
+			continue
+		}
+		v := strings.TrimSpace(*f.value)
+		if v == "" {
+			http.Error(w, f.name+" must not be empty", http.StatusBadRequest)
+			return
+		}
+		*f.dst = pgtype.Text{String: v, Valid: true}
+	}
+	if !params.CustomerName.Valid && !params.Destination.Valid && !params.Platform.Valid {
+		http.Error(w, "nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	var resp OrderResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		current, err := q.GetOrderForUpdate(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if status := constants.NormalizeOrderStatus(current.Status); !constants.OrderEditable(status) {
+			return fmt.Errorf("%w: order is %s", errOrderLocked, status)
+		}
+		order, err := q.UpdateOrderDetails(r.Context(), params)
+		if err != nil {
+			return err
+		}
+		order.Status = constants.NormalizeOrderStatus(order.Status)
+		orders, err := orderResponses(r.Context(), q, []repo.Order{order})
+		if err != nil {
+			return err
+		}
+		resp = orders[0]
+		return nil
+	})
 	if err != nil {
-		http.Error(w, "failed to list orders with product", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "order not found", http.StatusNotFound)
+		case errors.Is(err, errOrderLocked):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "failed to update order: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
-	for i := range orders {
-		orders[i].Status = strings.ToLower(orders[i].Status)
-		if orders[i].Status == "shipped" {
-			orders[i].Status = "shipping"
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// errIllegalTransition is returned when an order can't move to the requested status
+var errIllegalTransition = errors.New("illegal order status transition")
+
+// orderStatusHook runs inside the status update transaction when an order enters a status
+type orderStatusHook func(s *Server, ctx context.Context, q *repo.Queries, order repo.Order, serials []string) error
+
+// orderStatusHooks: reserved stock is deducted when the order ships and freed when it is cancelled
+var orderStatusHooks = map[string]orderStatusHook{
+	constants.OrderStatusShipping:  (*Server).shipOrder,
+	constants.OrderStatusCancelled: (*Server).cancelOrder,
+}
+
+// shipOrder assigns the serials of serial-tracked lines and turns the reservations into stock
+// deductions
+func (s *Server) shipOrder(ctx context.Context, q *repo.Queries, order repo.Order, serials []string) error {
+	need, err := serialUnitsNeeded(ctx, q, order.ID)
+	if err != nil {
+		return err
+	}
+	if len(need) > 0 {
+		if err := shipSerials(ctx, q, order, need, serials); err != nil {
+			return err
 		}
+	} else if len(serials) > 0 {
+		return fmt.Errorf("%w: order has no serial-tracked products", errSerialUnavailable)
 	}
+	return s.fulfilReservations(ctx, q, order)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(orders); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+// cancelOrder frees the stock the order was holding
+func (s *Server) cancelOrder(ctx context.Context, q *repo.Queries, order repo.Order, _ []string) error {
+	return releaseReservations(ctx, q, order.ID)
+}
+
+// errCancelReason is returned when an order is cancelled without saying why
+var errCancelReason = errors.New("cancelling an order requires a reason")
+
+// orderStatusChange is one requested move of an order to another status
+type orderStatusChange struct {
+	To        string   // normalized target status
+	Note      string   // dicatat di riwayat status
+	Reason    string   // required when To is cancelled
+	Serials   []string // units shipped for serial-tracked lines
+	ChangedBy pgtype.Int4
+}
+
+// changeOrderStatus moves an order along its lifecycle (see constants.CanTransitionOrder),
+// running the status hook and recording the change; moving to the current status is a no-op
+func (s *Server) changeOrderStatus(ctx context.Context, orderID int32, c orderStatusChange) (repo.Order, error) {
+	if c.To == constants.OrderStatusCancelled && c.Reason == "" {
+		return repo.Order{}, errCancelReason
+	}
+
+	var order repo.Order
+	err := s.inTx(ctx, func(q *repo.Queries) error {
+		current, err := q.GetOrderForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		from := constants.NormalizeOrderStatus(current.Status)
+		if from == c.To && len(c.Serials) == 0 {
+			order = current // sudah di status itu
+			return nil
+		}
+		if !constants.CanTransitionOrder(from, c.To) {
+			next := constants.NextOrderStatuses(from)
+			if len(next) == 0 {
+				return fmt.Errorf("%w: order is %s, which is final", errIllegalTransition, from)
+			}
+			return fmt.Errorf("%w: order is %s and can only move to %s", errIllegalTransition, from, strings.Join(next, ", "))
+		}
+
+		// unit ber-serial harus ditetapkan saat barang dikirim
+		if len(c.Serials) > 0 && c.To != constants.OrderStatusShipping {
+			return fmt.Errorf("%w: serial_numbers are only taken when a serial-tracked order ships", errSerialUnavailable)
+		}
+		if hook := orderStatusHooks[c.To]; hook != nil {
+			if err := hook(s, ctx, q, current, c.Serials); err != nil {
+				return err
+			}
+		}
+
+		order, err = q.UpdateOrderStatus(ctx, repo.UpdateOrderStatusParams{
+			ID:     orderID,
+			Status: c.To,
+		})
+		if err != nil {
+			return err
+		}
+		note := c.Note
+		if c.To == constants.OrderStatusCancelled {
+			order, err = q.SetOrderCancellation(ctx, repo.SetOrderCancellationParams{
+				CancelReason: pgtype.Text{String: c.Reason, Valid: true},
+				ID:           orderID,
+			})
+			if err != nil {
+				return err
+			}
+			if note == "" {
+				note = c.Reason
+			}
+		}
+		return recordOrderStatus(ctx, q, order.ID, from, c.To, c.ChangedBy, note)
+	})
+	return order, err
+}
+
+// writeOrderStatusError maps the errors of changeOrderStatus to HTTP responses
+func writeOrderStatusError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "order not found", http.StatusNotFound)
+	case errors.Is(err, errCancelReason):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errIllegalTransition):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, errSerialUnavailable), errors.Is(err, errInsufficientStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "failed to update order status: "+err.Error(), http.StatusInternalServerError)
 	}
 }
 
-// UpdateOrderStatus updates the status of an existing order
+// UpdateOrderStatus moves an order along its lifecycle (see constants.CanTransitionOrder);
+// illegal transitions are rejected with 422
 func (s *Server) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
     if idStr == "" {
@@ -161,6 +755,11 @@ func (s *Server) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	// decode payload JSON { "status": "completed" }
 	var payload struct {
 		Status string `json:"status"`
+		Note   string `json:"note,omitempty"` // dicatat di riwayat status
+		// wajib saat status jadi "cancelled"
+		Reason string `json:"reason,omitempty"`
+		// wajib saat status jadi "shipping" untuk produk dengan track_serials
+		SerialNumbers []string `json:"serial_numbers,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
@@ -168,20 +767,27 @@ func (s *Server) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// normalize status
-	normalizedStatus := strings.ToLower(payload.Status)
-	if normalizedStatus == "shipped" {
-		normalizedStatus = "shipping"
+	normalizedStatus := constants.NormalizeOrderStatus(payload.Status)
+	if !constants.IsOrderStatus(normalizedStatus) {
+		http.Error(w, "unknown status "+strconv.Quote(payload.Status)+", must be one of "+strings.Join(constants.OrderStatuses(), ", "), http.StatusBadRequest)
+		return
 	}
 
-	// get query sqlc for update
-	arg := repo.UpdateOrderStatusParams{
-		ID:     int32(orderIDInt),
-		Status: normalizedStatus,
+	serials, err := cleanSerials(payload.SerialNumbers)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	order, err := s.Repo.UpdateOrderStatus(r.Context(), arg)
+	order, err := s.changeOrderStatus(r.Context(), int32(orderIDInt), orderStatusChange{
+		To:        normalizedStatus,
+		Note:      strings.TrimSpace(payload.Note),
+		Reason:    strings.TrimSpace(payload.Reason),
+		Serials:   serials,
+		ChangedBy: requestUser(r),
+	})
 	if err != nil {
-		http.Error(w, "failed to update order status: "+err.Error(), http.StatusInternalServerError)
+		writeOrderStatusError(w, err)
 		return
 	}
 
@@ -189,7 +795,40 @@ func (s *Server) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(order)
 }
 
-// DeleteOrder deletes an existing order by ID
+// CancelOrderRequest is the body of POST /orders/{id}/cancel
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelOrder handles POST /orders/{id}/cancel: cancels an order that has not shipped yet,
+// freeing its reserved stock. The order and its history are kept.
+func (s *Server) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req CancelOrderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	order, err := s.changeOrderStatus(r.Context(), id, orderStatusChange{
+		To:        constants.OrderStatusCancelled,
+		Reason:    strings.TrimSpace(req.Reason),
+		ChangedBy: requestUser(r),
+	})
+	if err != nil {
+		writeOrderStatusError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+// DeleteOrder soft-deletes an order by ID: it disappears from lists and reports but can be
+// restored by an admin until the purge job removes it. Stock it was holding is freed.
 func (s *Server) DeleteOrder(w http.ResponseWriter, r *http.Request) {
     // get order_id from param
     idStr := chi.URLParam(r, "id")
@@ -204,16 +843,26 @@ func (s *Server) DeleteOrder(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-	// get order before delete to check if it exists
-	order, err := s.Repo.GetOrderByID(r.Context(), int32(orderIDInt))
-    if err != nil {
-        http.Error(w, "order not found: "+err.Error(), http.StatusNotFound)
-        return
-    }
-
-    // delete order
-    err = s.Repo.DeleteOrder(r.Context(), int32(orderIDInt))
+	var order repo.Order
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		// lock the order so a concurrent status change can't reserve or ship in between
+		if _, err := q.GetOrderForUpdate(r.Context(), int32(orderIDInt)); err != nil {
+			return err
+		}
+		if err := releaseReservations(r.Context(), q, int32(orderIDInt)); err != nil {
+			return err
+		}
+		order, err = q.SoftDeleteOrder(r.Context(), repo.SoftDeleteOrderParams{
+			ID:        int32(orderIDInt),
+			DeletedBy: requestUser(r),
+		})
+		return err
+	})
     if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
         http.Error(w, "failed to delete order: "+err.Error(), http.StatusInternalServerError)
         return
     }
@@ -223,6 +872,122 @@ func (s *Server) DeleteOrder(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(order)
 }
 
+// ListDeletedOrders handles GET /orders/deleted (admin only, login token): soft-deleted orders
+// awaiting purge, most recently deleted first
+func (s *Server) ListDeletedOrders(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+
+	orders, err := s.Repo.ListDeletedOrders(r.Context(), repo.ListDeletedOrdersParams{
+		Limit:  int32(queryInt(r, "limit", 50, 1, 200)),
+		Offset: int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	})
+	if err != nil {
+		http.Error(w, "failed to list deleted orders: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := orderResponses(r.Context(), s.Repo, orders)
+	if err != nil {
+		http.Error(w, "failed to list deleted orders: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RestoreOrder handles POST /orders/{id}/restore (admin only, login token): brings back a
+// soft-deleted order. An order that has not shipped yet takes its stock, lots and cost layers
+// again the way CreateOrder does, so the restore fails with 409 when the stock has been sold in
+// the meantime.
+func (s *Server) RestoreOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+
+	var resp OrderResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		order, err := q.RestoreOrder(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		items, err := q.ListOrderItemsByOrderIDs(r.Context(), []int32{order.ID})
+		if err != nil {
+			return err
+		}
+
+		switch constants.NormalizeOrderStatus(order.Status) {
+		case constants.OrderStatusPending, constants.OrderStatusProcessing:
+			warehouseID, err := resolveWarehouse(r.Context(), q, order.IDFromWarehouse.Int32)
+			if err != nil {
+				return err
+			}
+			var ids []int32
+			for _, it := range items {
+				if it.IDFromProduct.Valid {
+					ids = append(ids, it.IDFromProduct.Int32)
+				}
+			}
+			products, err := lockProducts(r.Context(), q, ids)
+			if err != nil {
+				return err
+			}
+
+			var cogs int64
+			for _, it := range items {
+				// lines kept from before order items have no product to hold
+				if !it.IDFromProduct.Valid || it.Quantity == 0 {
+					continue
+				}
+				itemCogs, err := s.holdOrderStock(r.Context(), q, order, products[it.IDFromProduct.Int32], it.IDFromVariant, warehouseID, it.Quantity)
+				if err != nil {
+					return err
+				}
+				if err := q.UpdateOrderItemCogs(r.Context(), repo.UpdateOrderItemCogsParams{
+					ID:      it.ID,
+					CogsIdr: pgtype.Int8{Int64: itemCogs, Valid: true},
+				}); err != nil {
+					return err
+				}
+				cogs += itemCogs
+			}
+			if order, err = q.UpdateOrderCogs(r.Context(), repo.UpdateOrderCogsParams{
+				ID:      order.ID,
+				CogsIdr: pgtype.Int8{Int64: cogs, Valid: true},
+			}); err != nil {
+				return err
+			}
+			if items, err = q.ListOrderItemsByOrderIDs(r.Context(), []int32{order.ID}); err != nil {
+				return err
+			}
+		}
+
+		order.Status = constants.NormalizeOrderStatus(order.Status)
+		resp = OrderResponse{Order: order, Items: items}
+		if resp.Items == nil {
+			resp.Items = []repo.ListOrderItemsByOrderIDsRow{}
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "deleted order not found", http.StatusNotFound)
+		case errors.Is(err, errInsufficientStock):
+			http.Error(w, "cannot restore order: "+err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "failed to restore order: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 // GetTopProductsFromOrders returns top 5 products based on total_amount
 func (s *Server) GetTopProductsFromOrders(w http.ResponseWriter, r *http.Request) {
     products, err := s.Repo.GetTopProductsFromOrders(r.Context())
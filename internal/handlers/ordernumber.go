@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// OrderNumberReset says when the order number sequence starts again at 1 (ORDER_NUMBER_RESET)
+type OrderNumberReset string
+
+const (
+	OrderNumberResetNever   OrderNumberReset = "never"
+	OrderNumberResetYearly  OrderNumberReset = "yearly"
+	OrderNumberResetMonthly OrderNumberReset = "monthly"
+)
+
+// OrderNumberFormat renders server-assigned order numbers. Pattern tokens: {YYYY}, {YY}, {MM},
+// {DD} for the creation date and {SEQ} or {SEQ:n} for the sequence zero-padded to n digits
+// (default 6), e.g. "SO/{YYYY}/{MM}/{SEQ:6}" -> SO/2026/10/000123.
+type OrderNumberFormat struct {
+	Pattern string
+	Reset   OrderNumberReset
+}
+
+// DefaultOrderNumberFormat keeps the original #000001 numbering
+var DefaultOrderNumberFormat = OrderNumberFormat{Pattern: "#{SEQ:6}", Reset: OrderNumberResetNever}
+
+var orderNumberToken = regexp.MustCompile(`\{(YYYY|YY|MM|DD|SEQ(?::(\d+))?)\}`)
+
+// ParseOrderNumberFormat validates ORDER_NUMBER_FORMAT and ORDER_NUMBER_RESET; empty values
+// fall back to the default. A period that resets must appear in the pattern, otherwise the
+// numbers of two periods would collide.
+func ParseOrderNumberFormat(pattern, reset string) (OrderNumberFormat, error) {
+	f := DefaultOrderNumberFormat
+	if p := strings.TrimSpace(pattern); p != "" {
+		f.Pattern = p
+	}
+	switch r := OrderNumberReset(strings.ToLower(strings.TrimSpace(reset))); r {
+	case "":
+	case OrderNumberResetNever, OrderNumberResetYearly, OrderNumberResetMonthly:
+		f.Reset = r
+	default:
+		return f, fmt.Errorf("unknown order number reset %q (use never, yearly or monthly)", reset)
+	}
+
+	has := map[string]int{}
+	for _, m := range orderNumberToken.FindAllStringSubmatch(f.Pattern, -1) {
+		name, _, _ := strings.Cut(m[1], ":")
+		has[name]++
+	}
+	if has["SEQ"] != 1 {
+		return f, fmt.Errorf("order number format %q must contain {SEQ} exactly once", f.Pattern)
+	}
+	hasYear := has["YYYY"] > 0 || has["YY"] > 0
+	if f.Reset == OrderNumberResetYearly && !hasYear {
+		return f, fmt.Errorf("order number format %q resets yearly but has no {YYYY} or {YY}", f.Pattern)
+	}
+	if f.Reset == OrderNumberResetMonthly && (!hasYear || has["MM"] == 0) {
+		return f, fmt.Errorf("order number format %q resets monthly but lacks the year or {MM}", f.Pattern)
+	}
+	return f, nil
+}
+
+// period is the counter the sequence runs in: one for all time, per year or per month
+func (f OrderNumberFormat) period(t time.Time) string {
+	switch f.Reset {
+	case OrderNumberResetYearly:
+		return t.Format("2006")
+	case OrderNumberResetMonthly:
+		return t.Format("2006-01")
+	default:
+		return ""
+	}
+}
+
+// render fills the pattern for sequence value seq at time t
+func (f OrderNumberFormat) render(t time.Time, seq int64) string {
+	return orderNumberToken.ReplaceAllStringFunc(f.Pattern, func(tok string) string {
+		m := orderNumberToken.FindStringSubmatch(tok)
+		switch m[1] {
+		case "YYYY":
+			return t.Format("2006")
+		case "YY":
+			return t.Format("06")
+		case "MM":
+			return t.Format("01")
+		case "DD":
+			return t.Format("02")
+		}
+		width := 6
+		if m[2] != "" {
+			width, _ = strconv.Atoi(m[2])
+		}
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
+
+// orderNumbers is the configured format, or the default when the server was built without one
+func (s *Server) orderNumberFormat() OrderNumberFormat {
+	if s.OrderNumbers.Pattern == "" {
+		return DefaultOrderNumberFormat
+	}
+	return s.OrderNumbers
+}
+
+// nextOrderNumber takes the next number from the period's counter. The counter row stays
+// locked until the transaction ends, so concurrent orders never get the same number and a
+// rolled-back order gives its number back, leaving no gaps.
+//
+// The price of gap-free numbers is that order creation is serialized: CreateOrder needs the
+// number before it inserts the order, and everything after that (reserving stock, taking lots
+// and cost layers, which also record the number) runs while the row is held. A sequence
+// would not block but loses numbers to rolled-back orders.
+func (s *Server) nextOrderNumber(ctx context.Context, q *repo.Queries, now time.Time) (string, error) {
+	f := s.orderNumberFormat()
+	seq, err := q.NextOrderNumberSequence(ctx, f.period(now))
+	if err != nil {
+		return "", err
+	}
+	return f.render(now, seq), nil
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOrderNumberFormat(t *testing.T) {
+	tests := []struct {
+		pattern, reset string
+		want           OrderNumberFormat
+		wantErr        bool
+	}{
+		{"", "", DefaultOrderNumberFormat, false},
+		{"  ", " NEVER ", DefaultOrderNumberFormat, false},
+		{"SO/{YYYY}/{MM}/{SEQ:6}", "monthly", OrderNumberFormat{"SO/{YYYY}/{MM}/{SEQ:6}", OrderNumberResetMonthly}, false},
+		{"INV-{YY}-{SEQ}", "Yearly", OrderNumberFormat{"INV-{YY}-{SEQ}", OrderNumberResetYearly}, false},
+		{"{YYYY}{MM}{DD}-{SEQ:4}", "never", OrderNumberFormat{"{YYYY}{MM}{DD}-{SEQ:4}", OrderNumberResetNever}, false},
+
+		{"SO-{YYYY}", "", OrderNumberFormat{}, true},           // no sequence
+		{"{SEQ}-{SEQ:4}", "", OrderNumberFormat{}, true},       // sequence twice
+		{"#{SEQ:6}", "daily", OrderNumberFormat{}, true},       // unknown reset
+		{"#{SEQ:6}", "yearly", OrderNumberFormat{}, true},      // yearly reset without a year
+		{"{YYYY}-{SEQ}", "monthly", OrderNumberFormat{}, true}, // monthly reset without the month
+		{"{MM}-{SEQ}", "monthly", OrderNumberFormat{}, true},   // monthly reset without the year
+		{"{yyyy}-{SEQ}", "yearly", OrderNumberFormat{}, true},  // tokens are case-sensitive
+	}
+	for _, tt := range tests {
+		got, err := ParseOrderNumberFormat(tt.pattern, tt.reset)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseOrderNumberFormat(%q, %q) = %+v, want an error", tt.pattern, tt.reset, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseOrderNumberFormat(%q, %q): %v", tt.pattern, tt.reset, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseOrderNumberFormat(%q, %q) = %+v, want %+v", tt.pattern, tt.reset, got, tt.want)
+		}
+	}
+}
+
+func TestOrderNumberRender(t *testing.T) {
+	at := time.Date(2026, time.March, 7, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		pattern string
+		seq     int64
+		want    string
+	}{
+		{"#{SEQ:6}", 1, "#000001"},
+		{"#{SEQ:6}", 123, "#000123"},
+		{"#{SEQ:6}", 1234567, "#1234567"}, // wider than the padding, never cut
+		{"{SEQ}", 42, "000042"},           // default width 6
+		{"{SEQ:0}", 42, "42"},
+		{"{SEQ:3}", 7, "007"},
+		{"SO/{YYYY}/{MM}/{SEQ:6}", 123, "SO/2026/03/000123"},
+		{"INV{YY}{MM}{DD}-{SEQ:4}", 9, "INV260307-0009"},
+		{"{SEQ:2}/{YYYY}", 5, "05/2026"},
+		{"no {TOKEN} {SEQ:2}", 1, "no {TOKEN} 01"},
+	}
+	for _, tt := range tests {
+		f := OrderNumberFormat{Pattern: tt.pattern}
+		if got := f.render(at, tt.seq); got != tt.want {
+			t.Errorf("render(%q, %d) = %q, want %q", tt.pattern, tt.seq, got, tt.want)
+		}
+	}
+}
+
+func TestOrderNumberPeriod(t *testing.T) {
+	dec := time.Date(2025, time.December, 31, 23, 59, 0, 0, time.UTC)
+	jan := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		reset OrderNumberReset
+		t     time.Time
+		want  string
+	}{
+		{OrderNumberResetNever, dec, ""},
+		{OrderNumberResetNever, feb, ""},
+		{"", jan, ""},
+		{OrderNumberResetYearly, dec, "2025"},
+		{OrderNumberResetYearly, jan, "2026"},
+		{OrderNumberResetYearly, feb, "2026"},
+		{OrderNumberResetMonthly, dec, "2025-12"},
+		{OrderNumberResetMonthly, jan, "2026-01"},
+		{OrderNumberResetMonthly, feb, "2026-02"},
+	}
+	for _, tt := range tests {
+		f := OrderNumberFormat{Pattern: "{YYYY}{MM}{SEQ}", Reset: tt.reset}
+		if got := f.period(tt.t); got != tt.want {
+			t.Errorf("period(%q, %s) = %q, want %q", tt.reset, tt.t.Format(time.DateOnly), got, tt.want)
+		}
+	}
+
+	// the counter restarts exactly when the period key changes
+	yearly := OrderNumberFormat{Reset: OrderNumberResetYearly}
+	if yearly.period(jan) != yearly.period(feb) || yearly.period(dec) == yearly.period(jan) {
+		t.Error("yearly counter must restart at the new year only")
+	}
+	monthly := OrderNumberFormat{Reset: OrderNumberResetMonthly}
+	if monthly.period(jan) == monthly.period(feb) {
+		t.Error("monthly counter must restart every month")
+	}
+}
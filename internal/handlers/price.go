@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// SchedulePriceRequest is the body of POST /products/{id}/prices.
+// Without effective_at (or with a time that has already passed) the price applies immediately.
+type SchedulePriceRequest struct {
+	PriceIdr    int64  `json:"price_idr"`
+	EffectiveAt string `json:"effective_at,omitempty"` // RFC3339
+	Note        string `json:"note,omitempty"`
+}
+
+// ListProductPrices handles GET /products/{id}/prices (newest first, scheduled ones have applied_at = null)
+func (s *Server) ListProductPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+
+	prices, err := s.Repo.ListPricesByProductIDs(r.Context(), []int32{id})
+	if err != nil {
+		http.Error(w, "failed to list prices: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if prices == nil {
+		prices = []repo.ProductPrice{}
+	}
+	writeJSON(w, http.StatusOK, prices)
+}
+
+// ScheduleProductPrice handles POST /products/{id}/prices. Future prices are picked up by
+// the price scheduler job; prices effective now are applied in the same transaction.
+func (s *Server) ScheduleProductPrice(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req SchedulePriceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.PriceIdr < 0 {
+		http.Error(w, "price_idr must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	effectiveAt := now
+	if req.EffectiveAt != "" {
+		effectiveAt, err = time.Parse(time.RFC3339, req.EffectiveAt)
+		if err != nil {
+			http.Error(w, "invalid effective_at format, must be RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+	immediate := !effectiveAt.After(now)
+
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+
+	var price repo.ProductPrice
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		arg := repo.CreateProductPriceParams{
+			IDFromProduct: id,
+			PriceIdr:      req.PriceIdr,
+			EffectiveAt:   pgtype.Timestamptz{Time: effectiveAt, Valid: true},
+			Note:          pgtype.Text{String: strings.TrimSpace(req.Note), Valid: strings.TrimSpace(req.Note) != ""},
+		}
+		if immediate {
+			if _, err := q.UpdateProductPrice(r.Context(), repo.UpdateProductPriceParams{
+				ID:       id,
+				PriceIdr: req.PriceIdr,
+			}); err != nil {
+				return err
+			}
+			arg.AppliedAt = pgtype.Timestamptz{Time: now, Valid: true}
+		}
+
+		var err error
+		price, err = q.CreateProductPrice(r.Context(), arg)
+		return err
+	})
+	if err != nil {
+		http.Error(w, "failed to save price: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, price)
+}
+
+// CancelScheduledPrice handles DELETE /products/{id}/prices/{priceId}; only prices that
+// have not been applied yet can be cancelled
+func (s *Server) CancelScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	priceID, err := urlParamInt32(r, "priceId")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := s.Repo.DeleteScheduledPrice(r.Context(), repo.DeleteScheduledPriceParams{
+		ID:            priceID,
+		IDFromProduct: id,
+	})
+	if err != nil {
+		http.Error(w, "failed to cancel price: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "scheduled price not found (already applied prices are history)", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
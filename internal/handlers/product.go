@@ -6,17 +6,26 @@ import (
   "strconv"
   "math"
   "errors"
-  "database/sql"
+  "fmt"
+  "strings"
+  "time"
   
   "github.com/go-chi/chi/v5"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgtype"
   "github.com/jackc/pgx/v5/pgxpool"
   
   repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+  "github.com/nichorainer/backend-go/internal/adapters/storage"
 )
 
 type Server struct {
-  Repo repo.Querier
-  DB   *pgxpool.Pool
+  Repo           repo.Querier
+  DB             *pgxpool.Pool
+  Blobs          storage.BlobStore
+  Costing        CostingMethod
+  OrderNumbers   OrderNumberFormat
+  IdempotencyTTL time.Duration
 }
 
 // CreateProductRequest is the expected JSON body for creating a product
@@ -27,9 +36,12 @@ type CreateProductRequest struct {
     Category     string `json:"category"`
     PriceIdr     int64  `json:"price_idr"`
     Stock        int32  `json:"stock"`
+    CostIdr      int64  `json:"cost_idr"`
+    TrackSerials bool   `json:"track_serials"` // wajib nomor seri saat terima & kirim
+    BaseUnit     string `json:"base_unit"`     // satuan stok, default "pcs"
 }
 
-// ListProducts returns either full products or simplified options
+// ListProducts returns either full products, products grouped with their variants, or simplified options
 func (s *Server) ListProducts(w http.ResponseWriter, r *http.Request) {
 	params := repo.ListProductsParams{
 		Limit:  100,
@@ -45,10 +57,21 @@ func (s *Server) ListProducts(w http.ResponseWriter, r *http.Request) {
 	// cek query param ?mode=options
 	mode := r.URL.Query().Get("mode")
 	if mode == "options" {
-		// FE hanya butuh product_id + product_name
+		// FE hanya butuh product_id + product_name (+ stok yang masih bisa dijual)
 		type ProductOption struct {
 			ProductID   string `json:"product_id"`
 			ProductName string `json:"product_name"`
+			Available   int64  `json:"available"`
+		}
+
+		ids := make([]int32, len(products))
+		for i, p := range products {
+			ids[i] = p.ID
+		}
+		availability, err := s.loadAvailability(r.Context(), ids)
+		if err != nil {
+			http.Error(w, "failed to load available stock", http.StatusInternalServerError)
+			return
 		}
 
 		options := make([]ProductOption, len(products))
@@ -56,6 +79,7 @@ func (s *Server) ListProducts(w http.ResponseWriter, r *http.Request) {
 			options[i] = ProductOption{
 				ProductID:   p.ProductID,
 				ProductName: p.ProductName,
+				Available:   availability[p.ID].available(p.Stock),
 			}
 		}
 
@@ -66,9 +90,30 @@ func (s *Server) ListProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// default: kirim full products untuk productspage
+	// ?mode=grouped: produk induk beserta varian SKU-nya
+	if mode == "grouped" {
+		grouped, err := s.productResponses(r.Context(), products, productIncludes{locations: true, variants: true})
+		if err != nil {
+			http.Error(w, "failed to list variants", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(grouped); err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// default: kirim full products (plus gambar dan stok per lokasi) untuk productspage
+	full, err := s.productResponses(r.Context(), products, productIncludes{locations: true})
+	if err != nil {
+		http.Error(w, "failed to list product images", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(products); err != nil {
+	if err := json.NewEncoder(w).Encode(full); err != nil {
 		http.Error(w, "failed to encode response", http.StatusInternalServerError)
 	}
 }
@@ -97,7 +142,7 @@ func (s *Server) GetProductByID(w http.ResponseWriter, r *http.Request) {
 	product, err := s.Repo.GetProductByID(r.Context(), id)
 	if err != nil {
 		// distinguish not found vs server error
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			http.Error(w, "product not found", http.StatusNotFound)
 			return
 		}
@@ -105,8 +150,15 @@ func (s *Server) GetProductByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// include variant SKUs, barcodes and images of this product
+	resp, err := s.productResponses(r.Context(), []repo.Product{product}, productDetail)
+	if err != nil {
+		http.Error(w, "failed to fetch product details: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(product); err != nil {
+	if err := json.NewEncoder(w).Encode(resp[0]); err != nil {
 		http.Error(w, "failed to encode response", http.StatusInternalServerError)
 		return
 	}
@@ -120,18 +172,67 @@ func (s *Server) CreateProduct(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
         return
     }
+    if req.Stock < 0 {
+        http.Error(w, "stock must not be negative", http.StatusBadRequest)
+        return
+    }
+    if req.TrackSerials && req.Stock > 0 {
+        http.Error(w, "stock of a serial-tracked product must be received with serial numbers", http.StatusBadRequest)
+        return
+    }
 
+    // the opening stock is booked below like any other receipt
     arg := repo.CreateProductParams{
         ProductID:    req.ProductID,
         ProductName:  req.ProductName,
         SupplierName: req.SupplierName,
         Category:     req.Category,
         PriceIdr:     req.PriceIdr,
-        Stock:        req.Stock,
+        Stock:        0,
+        CostIdr:      req.CostIdr,
+        TrackSerials: req.TrackSerials,
+        BaseUnit:     defaultBaseUnit,
+    }
+    if unit := strings.TrimSpace(req.BaseUnit); unit != "" {
+        arg.BaseUnit = unit
     }
 
-    p, err := s.Repo.CreateProduct(r.Context(), arg)
-    
+    // produk baru langsung punya satu baris riwayat harga dan HPP (harga awal)
+    var p repo.Product
+    err := s.inTx(r.Context(), func(q *repo.Queries) error {
+        var err error
+        p, err = q.CreateProduct(r.Context(), arg)
+        if err != nil {
+            return err
+        }
+        now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+        _, err = q.CreateProductPrice(r.Context(), repo.CreateProductPriceParams{
+            IDFromProduct: p.ID,
+            PriceIdr:      p.PriceIdr,
+            EffectiveAt:   now,
+            AppliedAt:     now,
+            Note:          pgtype.Text{String: "initial price", Valid: true},
+        })
+        if err != nil {
+            return err
+        }
+        _, err = q.CreateProductCost(r.Context(), repo.CreateProductCostParams{
+            IDFromProduct: p.ID,
+            CostIdr:       p.CostIdr,
+            Note:          pgtype.Text{String: "initial cost", Valid: true},
+        })
+        if err != nil || req.Stock == 0 {
+            return err
+        }
+
+        // opening stock: movement ledger, default warehouse and first cost layer
+        p, _, err = s.applyStockChange(r.Context(), q, stockChange{
+            ProductID: p.ID,
+            Delta:     req.Stock,
+            Reason:    movementReasonInitial,
+        })
+        return err
+    })
     if err != nil {
         http.Error(w, "failed to create product: "+err.Error(), http.StatusInternalServerError)
         return
@@ -145,9 +246,12 @@ func (s *Server) CreateProduct(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateStockRequest accepts either a delta (relative change) or an absolute stock value.
+// WarehouseID picks the location that changes (default warehouse when omitted).
 type UpdateStockRequest struct {
-    Delta *int32 `json:"delta,omitempty"`
-    Stock *int32 `json:"stock,omitempty"`
+    Delta       *int32 `json:"delta,omitempty"`
+    Stock       *int32 `json:"stock,omitempty"`
+    WarehouseID *int32 `json:"warehouse_id,omitempty"`
+    Unit        string `json:"unit,omitempty"` // satuan delta/stock, default base unit
 }
 
 // UpdateProductStock handles PATCH /products/{product_id}/stock
@@ -182,43 +286,63 @@ func (s *Server) UpdateProductStock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// If delta provided, prefer atomic delta update in DB (prevents race)
-	if req.Delta != nil {
-		// Try repo method UpdateProductStockByDelta (generated by sqlc if you added it)
-		updated, err := s.Repo.UpdateProductStockByDelta(r.Context(), repo.UpdateProductStockByDeltaParams{
-			ID:    id,
-			Stock: *req.Delta,
-		})
+	// every change goes through the movement ledger and the cost layers
+	var updated repo.Product
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		// lock the row so an absolute stock value turns into the right delta
+		current, err := q.GetProductForUpdate(r.Context(), id)
 		if err != nil {
-			// if no rows returned, either product not found or condition failed (e.g. would go negative)
-			if errors.Is(err, sql.ErrNoRows) {
-				http.Error(w, "product not found or insufficient stock", http.StatusBadRequest)
-				return
-			}
-			http.Error(w, "failed to update stock: "+err.Error(), http.StatusInternalServerError)
-			return
+			return err
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(updated)
-		return
-	}
+		if current.TrackSerials {
+			return fmt.Errorf("%w: %s", errSerialTracked, current.ProductID)
+		}
+		updated = current
 
-	// If absolute stock provided, use UpdateProductStock (set stock)
-	if req.Stock != nil {
-		updated, err := s.Repo.UpdateProductStock(r.Context(), repo.UpdateProductStockParams{
-			ID:    id,
-			Stock: *req.Stock,
-		})
+		// absolute stock sets the product total; the difference lands on the chosen location.
+		// Quantities in a pack unit are converted to base units first.
+		var delta int32
+		if req.Delta != nil {
+			delta, _, err = toBaseQuantity(r.Context(), q, current, req.Unit, *req.Delta)
+		} else {
+			var stock int32
+			stock, _, err = toBaseQuantity(r.Context(), q, current, req.Unit, *req.Stock)
+			delta = stock - current.Stock
+		}
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				http.Error(w, "product not found", http.StatusNotFound)
-				return
-			}
+			return err
+		}
+		if delta == 0 {
+			return nil
+		}
+
+		change := stockChange{
+			ProductID: id,
+			Delta:     delta,
+			Reason:    movementReasonAdjustment,
+		}
+		if req.WarehouseID != nil {
+			change.WarehouseID = *req.WarehouseID
+		}
+		updated, _, err = s.applyStockChange(r.Context(), q, change)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "product not found", http.StatusNotFound)
+		case errors.Is(err, errInsufficientStock):
+			http.Error(w, "product not found or insufficient stock", http.StatusBadRequest)
+		case errors.Is(err, errSerialTracked):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, errWarehouseNotFound), errors.Is(err, errUnknownUnit):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
 			http.Error(w, "failed to update stock: "+err.Error(), http.StatusInternalServerError)
-			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(updated)
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
 }
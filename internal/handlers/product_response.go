@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// ProductResponse is a product together with its images, its stock per location and, for
+// detail views, its lots, variant SKUs, registered barcodes, price history and cost history.
+// Product.Stock is the total: the sum of Locations plus InTransit. Available is what can still
+// be sold: the stock at the locations minus what open orders have reserved.
+type ProductResponse struct {
+	repo.Product
+	Images       []ProductImageResponse `json:"images"`
+	Locations    []LocationStock        `json:"locations,omitempty"`
+	InTransit    int64                  `json:"in_transit"`
+	Reserved     int64                  `json:"reserved"`
+	Available    int64                  `json:"available"`
+	StockUnits   []UnitQuantity         `json:"stock_units,omitempty"`
+	Lots         []repo.StockLot        `json:"lots,omitempty"`
+	Variants     []VariantResponse      `json:"variants,omitempty"`
+	Barcodes     []string               `json:"barcodes,omitempty"`
+	PriceHistory []repo.ProductPrice    `json:"price_history,omitempty"`
+	CostHistory  []repo.ProductCost     `json:"cost_history,omitempty"`
+}
+
+// productIncludes selects the optional relations productResponses loads
+type productIncludes struct {
+	locations bool
+	lots      bool
+	variants  bool
+	barcodes  bool
+	prices    bool
+	costs     bool
+}
+
+// productResponses loads the related data of the given products in batch queries
+// (one query per relation, not per product) and attaches it to each product.
+func (s *Server) productResponses(ctx context.Context, products []repo.Product, inc productIncludes) ([]ProductResponse, error) {
+	ids := make([]int32, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	images, err := s.Repo.ListImagesByProductIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	imagesByProduct := make(map[int32][]repo.ProductImage)
+	for _, img := range images {
+		imagesByProduct[img.IDFromProduct] = append(imagesByProduct[img.IDFromProduct], img)
+	}
+
+	availability, err := s.loadAvailability(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// stok juga ditampilkan per satuan kemasan (mis. 2 box + 5 pcs)
+	units, err := s.Repo.ListUnitsByProductIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	unitsByProduct := make(map[int32][]repo.ProductUnit)
+	for _, u := range units {
+		unitsByProduct[u.IDFromProduct] = append(unitsByProduct[u.IDFromProduct], u)
+	}
+
+	locationsByProduct := make(map[int32][]LocationStock)
+	if inc.locations {
+		stock, err := s.Repo.ListWarehouseStockByProductIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, ws := range stock {
+			locationsByProduct[ws.IDFromProduct] = append(locationsByProduct[ws.IDFromProduct], LocationStock{
+				WarehouseID: ws.IDFromWarehouse,
+				Code:        ws.Code,
+				Name:        ws.Name,
+				Quantity:    ws.Quantity,
+				Reserved:    availability[ws.IDFromProduct].ByLocation[ws.IDFromWarehouse],
+			})
+		}
+	}
+
+	lotsByProduct := make(map[int32][]repo.StockLot)
+	if inc.lots {
+		lots, err := s.Repo.ListLotsByProductIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lots {
+			lotsByProduct[l.IDFromProduct] = append(lotsByProduct[l.IDFromProduct], l)
+		}
+	}
+
+	variantsByProduct := make(map[int32][]repo.ProductVariant)
+	if inc.variants {
+		variants, err := s.Repo.ListVariantsByProductIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range variants {
+			variantsByProduct[v.IDFromProduct] = append(variantsByProduct[v.IDFromProduct], v)
+		}
+	}
+
+	barcodesByProduct := make(map[int32][]string)
+	if inc.barcodes {
+		barcodes, err := s.Repo.ListBarcodesByProductIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range barcodes {
+			barcodesByProduct[b.IDFromProduct] = append(barcodesByProduct[b.IDFromProduct], b.Barcode)
+		}
+	}
+
+	pricesByProduct := make(map[int32][]repo.ProductPrice)
+	if inc.prices {
+		prices, err := s.Repo.ListPricesByProductIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prices {
+			pricesByProduct[pr.IDFromProduct] = append(pricesByProduct[pr.IDFromProduct], pr)
+		}
+	}
+
+	costsByProduct := make(map[int32][]repo.ProductCost)
+	if inc.costs {
+		costs, err := s.Repo.ListCostsByProductIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range costs {
+			costsByProduct[c.IDFromProduct] = append(costsByProduct[c.IDFromProduct], c)
+		}
+	}
+
+	resp := make([]ProductResponse, len(products))
+	for i, p := range products {
+		resp[i] = ProductResponse{
+			Product:      p,
+			Images:       make([]ProductImageResponse, 0, len(imagesByProduct[p.ID])),
+			Locations:    locationsByProduct[p.ID],
+			InTransit:    availability[p.ID].InTransit,
+			Reserved:     availability[p.ID].Reserved,
+			Available:    availability[p.ID].available(p.Stock),
+			StockUnits:   stockInUnits(p.Stock, unitsByProduct[p.ID]),
+			Lots:         lotsByProduct[p.ID],
+			Barcodes:     barcodesByProduct[p.ID],
+			PriceHistory: pricesByProduct[p.ID],
+			CostHistory:  costsByProduct[p.ID],
+		}
+		for _, img := range imagesByProduct[p.ID] {
+			resp[i].Images = append(resp[i].Images, s.newImageResponse(img))
+		}
+		for _, v := range variantsByProduct[p.ID] {
+			resp[i].Variants = append(resp[i].Variants, newVariantResponse(v, p.PriceIdr))
+		}
+	}
+	return resp, nil
+}
+
+// productDetail is what single-product views (GetProductByID, scan lookup) include
+var productDetail = productIncludes{locations: true, lots: true, variants: true, barcodes: true, prices: true, costs: true}
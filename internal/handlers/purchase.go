@@ -0,0 +1,564 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// purchase order statuses
+const (
+	purchaseStatusDraft             = "draft"
+	purchaseStatusSent              = "sent"
+	purchaseStatusPartiallyReceived = "partially_received"
+	purchaseStatusReceived          = "received"
+	purchaseStatusCancelled         = "cancelled"
+)
+
+var (
+	// errPurchaseOrderNotFound is returned for an unknown purchase order
+	errPurchaseOrderNotFound = errors.New("purchase order not found")
+	// errPurchaseOrderStatus is returned when the PO's status does not allow the operation
+	errPurchaseOrderStatus = errors.New("purchase order status does not allow this")
+	// errInvalidPurchaseOrder is returned for lines that do not make a valid purchase order
+	errInvalidPurchaseOrder = errors.New("invalid purchase order")
+)
+
+// CreatePurchaseOrderRequest is the body of POST /purchase-orders
+type CreatePurchaseOrderRequest struct {
+	SupplierName string                     `json:"supplier_name"`
+	WarehouseID  int32                      `json:"warehouse_id,omitempty"` // deliver to, default warehouse
+	Note         string                     `json:"note,omitempty"`
+	ExpectedAt   string                     `json:"expected_at,omitempty"` // YYYY-MM-DD
+	Items        []PurchaseOrderItemRequest `json:"items"`
+}
+
+// PurchaseOrderItemRequest is one ordered product; quantity and cost may be given in a pack unit
+type PurchaseOrderItemRequest struct {
+	IdFromProduct int32  `json:"id_from_product"`
+	Quantity      int32  `json:"quantity"`
+	Unit          string `json:"unit,omitempty"`          // default base unit
+	UnitCostIdr   *int64 `json:"unit_cost_idr,omitempty"` // per unit; default: product cost_idr
+}
+
+// ReceivePurchaseOrderRequest is the body of POST /purchase-orders/{id}/receipts
+type ReceivePurchaseOrderRequest struct {
+	Reference  string                `json:"reference,omitempty"`   // e.g. supplier delivery note
+	ReceivedAt string                `json:"received_at,omitempty"` // RFC3339, default now
+	Items      []PurchaseReceiptLine `json:"items"`
+}
+
+// PurchaseReceiptLine is what arrived of one ordered product
+type PurchaseReceiptLine struct {
+	IdFromProduct int32    `json:"id_from_product"`
+	Quantity      int32    `json:"quantity"`
+	Unit          string   `json:"unit,omitempty"`
+	LotNumber     string   `json:"lot_number,omitempty"`
+	ExpiryDate    string   `json:"expiry_date,omitempty"`
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+}
+
+// PurchaseOrderResponse is a purchase order with its lines, deliveries and total value
+type PurchaseOrderResponse struct {
+	repo.PurchaseOrder
+	Items    []repo.ListPurchaseOrderItemsRow `json:"items"`
+	Receipts []repo.PurchaseReceipt           `json:"receipts"`
+	TotalIdr int64                            `json:"total_idr"`
+}
+
+// purchaseOrderResponse loads the lines and deliveries of a purchase order
+func purchaseOrderResponse(ctx context.Context, q repo.Querier, po repo.PurchaseOrder) (PurchaseOrderResponse, error) {
+	resp := PurchaseOrderResponse{PurchaseOrder: po}
+	var err error
+	resp.Items, err = q.ListPurchaseOrderItems(ctx, po.ID)
+	if err != nil {
+		return resp, err
+	}
+	resp.Receipts, err = q.ListPurchaseReceipts(ctx, po.ID)
+	if err != nil {
+		return resp, err
+	}
+	if resp.Items == nil {
+		resp.Items = []repo.ListPurchaseOrderItemsRow{}
+	}
+	if resp.Receipts == nil {
+		resp.Receipts = []repo.PurchaseReceipt{}
+	}
+	for _, it := range resp.Items {
+		resp.TotalIdr += int64(it.Quantity) * it.UnitCostIdr
+	}
+	return resp, nil
+}
+
+// lockPurchaseOrder locks a purchase order for the transaction, failing unless its status is
+// one of allowed
+func lockPurchaseOrder(ctx context.Context, q *repo.Queries, id int32, allowed ...string) (repo.PurchaseOrder, error) {
+	po, err := q.GetPurchaseOrderForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.PurchaseOrder{}, errPurchaseOrderNotFound
+		}
+		return repo.PurchaseOrder{}, err
+	}
+	for _, st := range allowed {
+		if po.Status == st {
+			return po, nil
+		}
+	}
+	return repo.PurchaseOrder{}, fmt.Errorf("%w: purchase order is %s", errPurchaseOrderStatus, po.Status)
+}
+
+// productsByID fetches the given products, failing with errNotFound naming the first missing one
+func productsByID(ctx context.Context, q repo.Querier, ids []int32, errNotFound error) (map[int32]repo.Product, error) {
+	products, err := q.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int32]repo.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	for _, id := range ids {
+		if _, ok := byID[id]; !ok {
+			return nil, fmt.Errorf("%w: product %d not found", errNotFound, id)
+		}
+	}
+	return byID, nil
+}
+
+// savePurchaseOrderItems writes the lines of a purchase order in base units and cost per base unit
+func savePurchaseOrderItems(ctx context.Context, q *repo.Queries, poID int32, items []PurchaseOrderItemRequest) error {
+	if len(items) == 0 {
+		return fmt.Errorf("%w: items must not be empty", errInvalidPurchaseOrder)
+	}
+	ids := make([]int32, len(items))
+	for i, it := range items {
+		if it.Quantity <= 0 {
+			return fmt.Errorf("%w: item quantity must be positive", errInvalidPurchaseOrder)
+		}
+		if it.UnitCostIdr != nil && *it.UnitCostIdr < 0 {
+			return fmt.Errorf("%w: unit_cost_idr must not be negative", errInvalidPurchaseOrder)
+		}
+		ids[i] = it.IdFromProduct
+	}
+	if len(uniqueIDs(ids)) != len(ids) {
+		return fmt.Errorf("%w: items must not repeat a product", errInvalidPurchaseOrder)
+	}
+	products, err := productsByID(ctx, q, ids, errInvalidPurchaseOrder)
+	if err != nil {
+		return err
+	}
+
+	for _, it := range items {
+		product := products[it.IdFromProduct]
+		quantity, factor, err := toBaseQuantity(ctx, q, product, it.Unit, it.Quantity)
+		if err != nil {
+			if errors.Is(err, errUnknownUnit) {
+				return fmt.Errorf("%w: %w", errInvalidPurchaseOrder, err)
+			}
+			return err
+		}
+		unitCost := product.CostIdr
+		if it.UnitCostIdr != nil {
+			unitCost = *it.UnitCostIdr / int64(factor)
+		}
+		if _, err := q.CreatePurchaseOrderItem(ctx, repo.CreatePurchaseOrderItemParams{
+			IDFromPurchaseOrder: poID,
+			IDFromProduct:       product.ID,
+			Quantity:            quantity,
+			UnitCostIdr:         unitCost,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePurchaseOrderError maps the errors shared by the purchase order handlers
+func writePurchaseOrderError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, errPurchaseOrderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errInvalidPurchaseOrder), errors.Is(err, errInvalidReceipt),
+		errors.Is(err, errWarehouseNotFound), errors.Is(err, errUnknownUnit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errPurchaseOrderStatus), errors.Is(err, errDuplicateSerial):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "failed to "+action+": "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ListPurchaseOrders handles GET /purchase-orders (?status=&supplier=)
+func (s *Server) ListPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	status := strings.ToLower(r.URL.Query().Get("status"))
+	supplier := strings.TrimSpace(r.URL.Query().Get("supplier"))
+	orders, err := s.Repo.ListPurchaseOrders(r.Context(), repo.ListPurchaseOrdersParams{
+		Status:       pgtype.Text{String: status, Valid: status != ""},
+		SupplierName: pgtype.Text{String: supplier, Valid: supplier != ""},
+		RowLimit:     int32(queryInt(r, "limit", 50, 1, 200)),
+		RowOffset:    int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	})
+	if err != nil {
+		http.Error(w, "failed to list purchase orders: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if orders == nil {
+		orders = []repo.PurchaseOrder{}
+	}
+	writeJSON(w, http.StatusOK, orders)
+}
+
+// GetPurchaseOrder handles GET /purchase-orders/{id}
+func (s *Server) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	po, err := s.Repo.GetPurchaseOrderByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, errPurchaseOrderNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch purchase order: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := purchaseOrderResponse(r.Context(), s.Repo, po)
+	if err != nil {
+		http.Error(w, "failed to fetch purchase order items: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// CreatePurchaseOrder handles POST /purchase-orders: creates a draft PO with its lines
+func (s *Server) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req CreatePurchaseOrderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.SupplierName = strings.TrimSpace(req.SupplierName)
+	if req.SupplierName == "" {
+		http.Error(w, "supplier_name is required", http.StatusBadRequest)
+		return
+	}
+	var expected pgtype.Date
+	if req.ExpectedAt != "" {
+		d, err := time.Parse(time.DateOnly, req.ExpectedAt)
+		if err != nil {
+			http.Error(w, "invalid expected_at format, must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		expected = pgtype.Date{Time: d, Valid: true}
+	}
+	note := strings.TrimSpace(req.Note)
+
+	var resp PurchaseOrderResponse
+	err := s.inTx(r.Context(), func(q *repo.Queries) error {
+		warehouseID, err := resolveWarehouse(r.Context(), q, req.WarehouseID)
+		if err != nil {
+			return err
+		}
+		po, err := q.CreatePurchaseOrder(r.Context(), repo.CreatePurchaseOrderParams{
+			SupplierName:    req.SupplierName,
+			IDFromWarehouse: warehouseID,
+			Note:            pgtype.Text{String: note, Valid: note != ""},
+			ExpectedAt:      expected,
+		})
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return errWarehouseNotFound
+			}
+			return err
+		}
+		if err := savePurchaseOrderItems(r.Context(), q, po.ID, req.Items); err != nil {
+			return err
+		}
+		resp, err = purchaseOrderResponse(r.Context(), q, po)
+		return err
+	})
+	if err != nil {
+		writePurchaseOrderError(w, err, "create purchase order")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// UpdatePurchaseOrderItems handles PUT /purchase-orders/{id}/items: replaces the lines of a draft
+func (s *Server) UpdatePurchaseOrderItems(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Items []PurchaseOrderItemRequest `json:"items"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp PurchaseOrderResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		po, err := lockPurchaseOrder(r.Context(), q, id, purchaseStatusDraft)
+		if err != nil {
+			return err
+		}
+		if err := q.DeletePurchaseOrderItems(r.Context(), id); err != nil {
+			return err
+		}
+		if err := savePurchaseOrderItems(r.Context(), q, id, req.Items); err != nil {
+			return err
+		}
+		resp, err = purchaseOrderResponse(r.Context(), q, po)
+		return err
+	})
+	if err != nil {
+		writePurchaseOrderError(w, err, "update purchase order")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SendPurchaseOrder handles POST /purchase-orders/{id}/send: the draft goes to the supplier and
+// its lines are frozen
+func (s *Server) SendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	s.setPurchaseOrderStatus(w, r, purchaseStatusSent, purchaseStatusDraft)
+}
+
+// CancelPurchaseOrder handles POST /purchase-orders/{id}/cancel; only POs nothing has been
+// received for can be cancelled
+func (s *Server) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	s.setPurchaseOrderStatus(w, r, purchaseStatusCancelled, purchaseStatusDraft, purchaseStatusSent)
+}
+
+// setPurchaseOrderStatus moves a purchase order to status when it is in one of from
+func (s *Server) setPurchaseOrderStatus(w http.ResponseWriter, r *http.Request, status string, from ...string) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp PurchaseOrderResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		if _, err := lockPurchaseOrder(r.Context(), q, id, from...); err != nil {
+			return err
+		}
+		po, err := q.UpdatePurchaseOrderStatus(r.Context(), repo.UpdatePurchaseOrderStatusParams{
+			Status: status,
+			ID:     id,
+		})
+		if err != nil {
+			return err
+		}
+		resp, err = purchaseOrderResponse(r.Context(), q, po)
+		return err
+	})
+	if err != nil {
+		writePurchaseOrderError(w, err, "update purchase order")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ReceivePurchaseOrder handles POST /purchase-orders/{id}/receipts: books a (partial) delivery
+// into the PO's location through the movement ledger at the ordered cost. The PO becomes
+// partially_received, or received once every line is complete.
+func (s *Server) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req ReceivePurchaseOrderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) == 0 {
+		http.Error(w, "items must not be empty", http.StatusBadRequest)
+		return
+	}
+	receivedAt, err := parseReceivedAt(req.ReceivedAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if receivedAt.IsZero() {
+		receivedAt = time.Now()
+	}
+	reference := strings.TrimSpace(req.Reference)
+
+	var resp PurchaseOrderResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		po, err := lockPurchaseOrder(r.Context(), q, id, purchaseStatusSent, purchaseStatusPartiallyReceived)
+		if err != nil {
+			return err
+		}
+		items, err := q.ListPurchaseOrderItems(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		itemByProduct := make(map[int32]repo.ListPurchaseOrderItemsRow, len(items))
+		for _, it := range items {
+			itemByProduct[it.IDFromProduct] = it
+		}
+
+		ids := make([]int32, len(req.Items))
+		for i, line := range req.Items {
+			if line.Quantity <= 0 {
+				return fmt.Errorf("%w: quantity must be positive", errInvalidReceipt)
+			}
+			if _, ok := itemByProduct[line.IdFromProduct]; !ok {
+				return fmt.Errorf("%w: product %d is not on this purchase order", errInvalidReceipt, line.IdFromProduct)
+			}
+			ids[i] = line.IdFromProduct
+		}
+		products, err := productsByID(r.Context(), q, ids, errInvalidReceipt)
+		if err != nil {
+			return err
+		}
+
+		receipt, err := q.CreatePurchaseReceipt(r.Context(), repo.CreatePurchaseReceiptParams{
+			IDFromPurchaseOrder: id,
+			Reference:           pgtype.Text{String: reference, Valid: reference != ""},
+			ReceivedAt:          pgtype.Timestamptz{Time: receivedAt, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, line := range req.Items {
+			product := products[line.IdFromProduct]
+			item := itemByProduct[line.IdFromProduct]
+			quantity, _, err := toBaseQuantity(r.Context(), q, product, line.Unit, line.Quantity)
+			if err != nil {
+				return err
+			}
+			lotNumber, expiry, err := parseLot(line.LotNumber, line.ExpiryDate)
+			if err != nil {
+				return fmt.Errorf("%w: %w", errInvalidReceipt, err)
+			}
+			serials, err := cleanSerials(line.SerialNumbers)
+			if err != nil {
+				return fmt.Errorf("%w: %w", errInvalidReceipt, err)
+			}
+
+			if _, err := q.ReceivePurchaseOrderItem(r.Context(), repo.ReceivePurchaseOrderItemParams{
+				ID:          item.ID,
+				ReceivedQty: quantity,
+			}); err != nil {
+				if isCheckViolation(err) {
+					return fmt.Errorf("%w: %s would exceed the ordered %d %s", errInvalidReceipt, product.ProductID, item.Quantity, product.BaseUnit)
+				}
+				return err
+			}
+			if _, err := q.CreatePurchaseReceiptItem(r.Context(), repo.CreatePurchaseReceiptItemParams{
+				IDFromReceipt:           receipt.ID,
+				IDFromPurchaseOrderItem: item.ID,
+				Quantity:                quantity,
+				LotNumber:               pgtype.Text{String: lotNumber, Valid: lotNumber != ""},
+			}); err != nil {
+				return err
+			}
+
+			unitCost := item.UnitCostIdr
+			if _, err := s.receiveStock(r.Context(), q, product, stockChange{
+				ProductID:   product.ID,
+				Delta:       quantity,
+				Reason:      movementReasonReceipt,
+				Reference:   po.PoNumber,
+				UnitCostIdr: &unitCost,
+				ReceivedAt:  receivedAt,
+				WarehouseID: po.IDFromWarehouse,
+				LotNumber:   lotNumber,
+				ExpiryDate:  expiry,
+			}, serials); err != nil {
+				return err
+			}
+		}
+
+		// status mengikuti sisa barang yang belum datang
+		items, err = q.ListPurchaseOrderItems(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		status := purchaseStatusReceived
+		for _, it := range items {
+			if it.ReceivedQty < it.Quantity {
+				status = purchaseStatusPartiallyReceived
+				break
+			}
+		}
+		po, err = q.UpdatePurchaseOrderStatus(r.Context(), repo.UpdatePurchaseOrderStatusParams{
+			Status: status,
+			ID:     id,
+		})
+		if err != nil {
+			return err
+		}
+		resp, err = purchaseOrderResponse(r.Context(), q, po)
+		return err
+	})
+	if err != nil {
+		writePurchaseOrderError(w, err, "receive purchase order")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// GetPurchaseOrderPDF handles GET /purchase-orders/{id}/pdf: the printable PO for the supplier
+func (s *Server) GetPurchaseOrderPDF(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	po, err := s.Repo.GetPurchaseOrderByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, errPurchaseOrderNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch purchase order: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := purchaseOrderResponse(r.Context(), s.Repo, po)
+	if err != nil {
+		http.Error(w, "failed to fetch purchase order items: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	warehouse, err := s.Repo.GetWarehouseByID(r.Context(), po.IDFromWarehouse)
+	if err != nil {
+		http.Error(w, "failed to fetch warehouse: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	purchaseOrderPDF(resp, warehouse).WriteTo(&buf)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, po.PoNumber))
+	w.Write(buf.Bytes())
+}
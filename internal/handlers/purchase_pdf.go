@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+	"github.com/nichorainer/backend-go/internal/pdf"
+	"github.com/nichorainer/backend-go/internal/utils"
+)
+
+// purchase order page geometry (A4, 20 mm margins)
+const (
+	poMargin    = 20 * pdf.MM
+	poRowHeight = 16.0
+	poRight     = pdf.A4Width - poMargin
+	poBottom    = pdf.A4Height - poMargin
+)
+
+// x positions of the purchase order table columns (Qty, Unit cost and Total are right-aligned)
+var poColumns = struct{ no, code, name, qty, cost, total float64 }{
+	no:    poMargin,
+	code:  poMargin + 24,
+	name:  poMargin + 110,
+	qty:   poMargin + 330,
+	cost:  poMargin + 420,
+	total: poRight,
+}
+
+// purchaseOrderPDF renders a purchase order: header, supplier and delivery address, one row per
+// line and the grand total. Long orders continue on further pages with the table header repeated.
+func purchaseOrderPDF(po PurchaseOrderResponse, warehouse repo.Warehouse) *pdf.Document {
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	page := doc.AddPage()
+
+	page.Text(poMargin, poMargin+18, pdf.HelveticaBold, 18, "PURCHASE ORDER")
+	page.TextRight(poRight, poMargin+18, pdf.HelveticaBold, 12, po.PoNumber)
+
+	y := poMargin + 48
+	details := [][2]string{
+		{"Supplier", po.SupplierName},
+		{"Deliver to", warehouse.Code + " - " + warehouse.Name},
+		{"Order date", po.CreatedAt.Time.Format("02 Jan 2006")},
+	}
+	if po.ExpectedAt.Valid {
+		details = append(details, [2]string{"Expected", po.ExpectedAt.Time.Format("02 Jan 2006")})
+	}
+	for _, d := range details {
+		page.Text(poMargin, y, pdf.HelveticaBold, 10, d[0])
+		page.Text(poMargin+80, y, pdf.Helvetica, 10, pdf.Truncate(pdf.Helvetica, 10, poRight-poMargin-80, d[1]))
+		y += 14
+	}
+
+	y += 12
+	y = purchaseOrderTableHeader(page, y)
+	for i, it := range po.Items {
+		if y+poRowHeight > poBottom-30 {
+			page = doc.AddPage()
+			y = purchaseOrderTableHeader(page, poMargin+12)
+		}
+		page.Text(poColumns.no, y, pdf.Helvetica, 9, strconv.Itoa(i+1))
+		page.Text(poColumns.code, y, pdf.Helvetica, 9, pdf.Truncate(pdf.Helvetica, 9, poColumns.name-poColumns.code-6, it.ProductID))
+		page.Text(poColumns.name, y, pdf.Helvetica, 9, pdf.Truncate(pdf.Helvetica, 9, poColumns.qty-poColumns.name-50, it.ProductName))
+		page.TextRight(poColumns.qty, y, pdf.Helvetica, 9, fmt.Sprintf("%d %s", it.Quantity, it.BaseUnit))
+		page.TextRight(poColumns.cost, y, pdf.Helvetica, 9, utils.FormatRupiah(it.UnitCostIdr))
+		page.TextRight(poColumns.total, y, pdf.Helvetica, 9, utils.FormatRupiah(int64(it.Quantity)*it.UnitCostIdr))
+		y += poRowHeight
+	}
+
+	page.Line(poMargin, y-10, poRight, y-10, 0.5)
+	page.Text(poColumns.cost-80, y+4, pdf.HelveticaBold, 10, "Total")
+	page.TextRight(poColumns.total, y+4, pdf.HelveticaBold, 10, utils.FormatRupiah(po.TotalIdr))
+
+	if po.Note.Valid {
+		page.Text(poMargin, y+30, pdf.Helvetica, 9, pdf.Truncate(pdf.Helvetica, 9, poRight-poMargin, "Note: "+po.Note.String))
+	}
+	return doc
+}
+
+// purchaseOrderTableHeader draws the column titles at y and returns the baseline of the first row
+func purchaseOrderTableHeader(page *pdf.Page, y float64) float64 {
+	page.Text(poColumns.no, y, pdf.HelveticaBold, 9, "No")
+	page.Text(poColumns.code, y, pdf.HelveticaBold, 9, "Product")
+	page.Text(poColumns.name, y, pdf.HelveticaBold, 9, "Name")
+	page.TextRight(poColumns.qty, y, pdf.HelveticaBold, 9, "Qty")
+	page.TextRight(poColumns.cost, y, pdf.HelveticaBold, 9, "Unit cost")
+	page.TextRight(poColumns.total, y, pdf.HelveticaBold, 9, "Total")
+	page.Line(poMargin, y+5, poRight, y+5, 0.5)
+	return y + 5 + poRowHeight
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// stock_reservations.status values
+const (
+	reservationStatusActive    = "active"
+	reservationStatusFulfilled = "fulfilled"
+	reservationStatusReleased  = "released"
+)
+
+// reserveStock holds qty units at a location for an order. The product row is locked first so
+// concurrent orders for the same product see each other's reservations; the order is refused
+// with errInsufficientStock when on-hand minus reserved does not cover it. A variant line
+// (variantID set) must also fit in the variant's own stock.
+func reserveStock(ctx context.Context, q *repo.Queries, orderID, productID, warehouseID, qty int32, variantID pgtype.Int4) error {
+	if _, err := q.GetProductForUpdate(ctx, productID); err != nil {
+		return err
+	}
+	stock, err := q.GetAvailableStock(ctx, repo.GetAvailableStockParams{
+		IDFromProduct:   productID,
+		IDFromWarehouse: warehouseID,
+	})
+	if err != nil {
+		return err
+	}
+	if available := stock.OnHand - stock.Reserved; available < int64(qty) {
+		return fmt.Errorf("%w: %d available at the location, %d ordered", errInsufficientStock, max(available, 0), qty)
+	}
+
+	if variantID.Valid {
+		v, err := q.GetProductVariantForUpdate(ctx, variantID.Int32)
+		if err != nil {
+			return err
+		}
+		reserved, err := q.GetReservedVariantStock(ctx, variantID)
+		if err != nil {
+			return err
+		}
+		if available := int64(v.Stock) - reserved; available < int64(qty) {
+			return fmt.Errorf("%w: %d of variant %s available, %d ordered", errInsufficientStock, max(available, 0), v.ProductID, qty)
+		}
+	}
+
+	_, err = q.CreateStockReservation(ctx, repo.CreateStockReservationParams{
+		IDFromOrder:     orderID,
+		IDFromProduct:   productID,
+		IDFromWarehouse: warehouseID,
+		Quantity:        qty,
+		IDFromVariant:   variantID,
+	})
+	return err
+}
+
+// fulfilReservations turns the order's active reservations into stock deductions when it
+// ships, including the variant stock of variant lines. Lots and cost layers were taken when
+// the order was placed, so only the on-hand balances change here. Orders without reservations (placed before reservations existed) pass.
+func (s *Server) fulfilReservations(ctx context.Context, q *repo.Queries, order repo.Order) error {
+	reservations, err := q.CloseOrderReservations(ctx, repo.CloseOrderReservationsParams{
+		IDFromOrder: order.ID,
+		Status:      reservationStatusFulfilled,
+	})
+	if err != nil {
+		return err
+	}
+	for _, res := range reservations {
+		if _, _, err := s.applyStockChange(ctx, q, stockChange{
+			ProductID:   res.IDFromProduct,
+			Delta:       -res.Quantity,
+			Reason:      movementReasonSale,
+			Reference:   order.OrderNumber,
+			WarehouseID: res.IDFromWarehouse,
+			Allocated:   true,
+		}); err != nil {
+			return err
+		}
+		if res.IDFromVariant.Valid {
+			if err := adjustVariantStock(ctx, q, res.IDFromVariant.Int32, -res.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// adjustVariantStock moves a variant's own stock by delta; it never goes below zero
+func adjustVariantStock(ctx context.Context, q *repo.Queries, variantID, delta int32) error {
+	_, err := q.UpdateProductVariantStockByDelta(ctx, repo.UpdateProductVariantStockByDeltaParams{
+		ID:    variantID,
+		Stock: delta,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: variant %d has fewer than %d unit(s)", errInsufficientStock, variantID, -delta)
+	}
+	return err
+}
+
+// releaseReservations frees the stock held by an order that will not ship, and gives back the
+// lots and cost layers taken for it when it was placed. Orders that already shipped (or never
+// reserved) hold nothing, so their allocations and COGS stay as they are.
+func releaseReservations(ctx context.Context, q *repo.Queries, orderID int32) error {
+	released, err := q.CloseOrderReservations(ctx, repo.CloseOrderReservationsParams{
+		IDFromOrder: orderID,
+		Status:      reservationStatusReleased,
+	})
+	if err != nil || len(released) == 0 {
+		return err
+	}
+	if err := q.ReleaseLotAllocations(ctx, orderID); err != nil {
+		return err
+	}
+	return q.ReleaseCostLayerConsumptions(ctx, pgtype.Int4{Int32: orderID, Valid: true})
+}
+
+// stockAvailability is what of a product's stock can still be sold
+type stockAvailability struct {
+	InTransit  int64           // on its way between locations
+	Reserved   int64           // held by open orders
+	ByLocation map[int32]int64 // reserved per warehouse id
+	Buildable  int64           // bundles only: kits the components can still make
+}
+
+// available is on-hand stock (at a location, not in transit) minus what open orders hold; for
+// bundles the kits that can be built from component stock count too
+func (a stockAvailability) available(stock int32) int64 {
+	return int64(stock) - a.InTransit - a.Reserved + a.Buildable
+}
+
+// loadAvailability loads in-transit and reserved quantities of the given products in two queries
+func (s *Server) loadAvailability(ctx context.Context, ids []int32) (map[int32]stockAvailability, error) {
+	byProduct := make(map[int32]stockAvailability, len(ids))
+
+	inTransit, err := s.Repo.ListInTransitByProductIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range inTransit {
+		a := byProduct[t.IDFromProduct]
+		a.InTransit = t.Quantity
+		byProduct[t.IDFromProduct] = a
+	}
+
+	reserved, err := s.Repo.ListReservedByProductIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range reserved {
+		a := byProduct[res.IDFromProduct]
+		if a.ByLocation == nil {
+			a.ByLocation = make(map[int32]int64)
+		}
+		a.Reserved += res.Quantity
+		a.ByLocation[res.IDFromWarehouse] = res.Quantity
+		byProduct[res.IDFromProduct] = a
+	}
+
+	// a bundle can be sold as often as its scarcest component allows
+	components, err := s.Repo.ListBundleComponentsByBundleIDs(ctx, ids)
+	if err != nil || len(components) == 0 {
+		return byProduct, err
+	}
+	componentIDs := make([]int32, len(components))
+	for i, c := range components {
+		componentIDs[i] = c.IDFromComponent
+	}
+	componentAvailability, err := s.loadAvailability(ctx, componentIDs)
+	if err != nil {
+		return nil, err
+	}
+	buildable := make(map[int32]int64)
+	for _, c := range components {
+		kits := max(componentAvailability[c.IDFromComponent].available(c.Stock), 0) / int64(c.Quantity)
+		if current, ok := buildable[c.IDFromBundle]; !ok || kits < current {
+			buildable[c.IDFromBundle] = kits
+		}
+	}
+	for bundleID, kits := range buildable {
+		a := byProduct[bundleID]
+		a.Buildable = kits
+		byProduct[bundleID] = a
+	}
+	return byProduct, nil
+}
@@ -0,0 +1,609 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+	"github.com/nichorainer/backend-go/internal/constants"
+)
+
+// order_returns.status values
+const (
+	returnStatusRequested = "requested"
+	returnStatusCompleted = "completed"
+	returnStatusRejected  = "rejected"
+)
+
+// inspection outcomes of a returned line
+const (
+	returnOutcomeRestock = "restock" // kembali ke stok yang bisa dijual
+	returnOutcomeDamaged = "damaged" // disimpan terpisah, tidak dijual
+	returnOutcomeDiscard = "discard" // dibuang
+)
+
+var (
+	errOrderReturnNotFound = errors.New("return not found")
+	errInvalidReturn       = errors.New("invalid return")
+	errReturnStatus        = errors.New("return not allowed in current status")
+)
+
+// OrderReturnItemRequest is one returned order line
+type OrderReturnItemRequest struct {
+	IDFromOrderItem int32 `json:"id_from_order_item"`
+	Quantity        int32 `json:"quantity"`
+	// the returned units, required for serial-tracked products
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+}
+
+// CreateOrderReturnRequest is the body of POST /orders/{id}/returns
+type CreateOrderReturnRequest struct {
+	Reason      string                   `json:"reason"`
+	WarehouseID int32                    `json:"warehouse_id,omitempty"` // restock location, default the order's warehouse
+	Note        string                   `json:"note,omitempty"`
+	Items       []OrderReturnItemRequest `json:"items"`
+}
+
+// InspectReturnItemRequest is the inspection result of one returned line
+type InspectReturnItemRequest struct {
+	ID      int32  `json:"id"`      // order_return_items.id
+	Outcome string `json:"outcome"` // restock, damaged or discard
+	// default: unit price * quantity; may be lowered, never raised above that
+	RefundIdr *int64 `json:"refund_idr,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// InspectOrderReturnRequest is the body of POST /returns/{id}/inspect
+type InspectOrderReturnRequest struct {
+	Items []InspectReturnItemRequest `json:"items"`
+	Note  string                     `json:"note,omitempty"`
+}
+
+// RejectOrderReturnRequest is the body of POST /returns/{id}/reject
+type RejectOrderReturnRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// OrderReturnResponse is a return with its lines
+type OrderReturnResponse struct {
+	repo.ListOrderReturnsRow
+	Items []repo.ListOrderReturnItemsByReturnIDsRow `json:"items"`
+}
+
+// ReturnsReportTotals sums the rows of the returns report
+type ReturnsReportTotals struct {
+	ReturnedQty  int64 `json:"returned_qty"`
+	RestockedQty int64 `json:"restocked_qty"`
+	DamagedQty   int64 `json:"damaged_qty"`
+	DiscardedQty int64 `json:"discarded_qty"`
+	RefundIdr    int64 `json:"refund_idr"`
+}
+
+// ReturnsReport is the response of GET /returns/report
+type ReturnsReport struct {
+	Totals   ReturnsReportTotals        `json:"totals"`
+	Products []repo.GetReturnsReportRow `json:"products"`
+}
+
+// returnReference is the document number written on stock movements and serial events
+func returnReference(returnID int32) string {
+	return fmt.Sprintf("RMA-%06d", returnID)
+}
+
+// orderReturnResponses loads the lines of the given returns in one query
+func orderReturnResponses(ctx context.Context, q repo.Querier, returns []repo.ListOrderReturnsRow) ([]OrderReturnResponse, error) {
+	ids := make([]int32, len(returns))
+	for i, ret := range returns {
+		ids[i] = ret.ID
+	}
+	items, err := q.ListOrderReturnItemsByReturnIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byReturn := make(map[int32][]repo.ListOrderReturnItemsByReturnIDsRow, len(returns))
+	for _, it := range items {
+		byReturn[it.IDFromReturn] = append(byReturn[it.IDFromReturn], it)
+	}
+
+	resp := make([]OrderReturnResponse, len(returns))
+	for i, ret := range returns {
+		resp[i] = OrderReturnResponse{ListOrderReturnsRow: ret, Items: byReturn[ret.ID]}
+		if resp[i].Items == nil {
+			resp[i].Items = []repo.ListOrderReturnItemsByReturnIDsRow{}
+		}
+	}
+	return resp, nil
+}
+
+// loadOrderReturn loads one return with its lines
+func loadOrderReturn(ctx context.Context, q repo.Querier, id int32) (OrderReturnResponse, error) {
+	rows, err := q.ListOrderReturns(ctx, repo.ListOrderReturnsParams{
+		ReturnID: pgtype.Int4{Int32: id, Valid: true},
+		RowLimit: 1,
+	})
+	if err != nil {
+		return OrderReturnResponse{}, err
+	}
+	if len(rows) == 0 {
+		return OrderReturnResponse{}, errOrderReturnNotFound
+	}
+	resp, err := orderReturnResponses(ctx, q, rows)
+	if err != nil {
+		return OrderReturnResponse{}, err
+	}
+	return resp[0], nil
+}
+
+// writeOrderReturnError maps return errors to HTTP responses
+func writeOrderReturnError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, errOrderReturnNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "order not found", http.StatusNotFound)
+	case errors.Is(err, errInvalidReturn):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errWarehouseNotFound), isForeignKeyViolation(err):
+		http.Error(w, errWarehouseNotFound.Error(), http.StatusBadRequest)
+	case errors.Is(err, errReturnStatus), errors.Is(err, errSerialUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "failed to "+action+": "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// checkReturnSerials makes sure the serials were shipped with this order and are still out
+func checkReturnSerials(ctx context.Context, q *repo.Queries, productID, orderID int32, serials []string) error {
+	for _, sn := range serials {
+		serial, err := q.GetSerialForUpdate(ctx, repo.GetSerialForUpdateParams{
+			IDFromProduct: productID,
+			SerialNumber:  sn,
+		})
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && serial.IDFromOrder.Int32 != orderID) {
+			return fmt.Errorf("%w: %s was not shipped with this order", errInvalidReturn, sn)
+		}
+		if err != nil {
+			return err
+		}
+		if serial.Status != serialStatusSold {
+			return fmt.Errorf("%w: %s is %s", errSerialUnavailable, sn, serial.Status)
+		}
+	}
+	return nil
+}
+
+// CreateOrderReturn handles POST /orders/{id}/returns: the customer sends back (part of) a
+// shipped order. Each line can be returned up to the quantity ordered, across all returns
+// that were not rejected. Stock and refunds are settled when the return is inspected.
+func (s *Server) CreateOrderReturn(w http.ResponseWriter, r *http.Request) {
+	orderID, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req CreateOrderReturnRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) == 0 {
+		http.Error(w, "items must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	var resp OrderReturnResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		order, err := q.GetOrderForUpdate(r.Context(), orderID)
+		if err != nil {
+			return err
+		}
+		switch constants.NormalizeOrderStatus(order.Status) {
+		case constants.OrderStatusShipping, constants.OrderStatusCompleted:
+		default:
+			return fmt.Errorf("%w: order is %s, only shipped orders can be returned", errReturnStatus, order.Status)
+		}
+
+		lines, err := q.ListOrderItemsByOrderIDs(r.Context(), []int32{order.ID})
+		if err != nil {
+			return err
+		}
+		lineByID := make(map[int32]repo.ListOrderItemsByOrderIDsRow, len(lines))
+		var productIDs []int32
+		for _, l := range lines {
+			lineByID[l.ID] = l
+			if l.IDFromProduct.Valid {
+				productIDs = append(productIDs, l.IDFromProduct.Int32)
+			}
+		}
+		products, err := productsByID(r.Context(), q, productIDs, errInvalidReturn)
+		if err != nil {
+			return err
+		}
+		returned, err := q.ListReturnedQuantitiesByOrderID(r.Context(), order.ID)
+		if err != nil {
+			return err
+		}
+		open := make(map[int32]int64, len(lines))
+		for _, l := range lines {
+			open[l.ID] = int64(l.Quantity)
+		}
+		for _, rq := range returned {
+			open[rq.IDFromOrderItem] -= rq.ReturnedQty
+		}
+
+		serials := make([][]string, len(req.Items))
+		for i, it := range req.Items {
+			line, ok := lineByID[it.IDFromOrderItem]
+			if !ok {
+				return fmt.Errorf("%w: order item %d is not part of this order", errInvalidReturn, it.IDFromOrderItem)
+			}
+			if !line.IDFromProduct.Valid {
+				return fmt.Errorf("%w: order item %d has no product to take back", errInvalidReturn, line.ID)
+			}
+			if it.Quantity <= 0 {
+				return fmt.Errorf("%w: quantity must be positive", errInvalidReturn)
+			}
+			if int64(it.Quantity) > open[line.ID] {
+				return fmt.Errorf("%w: %s: %d returnable, %d requested", errInvalidReturn, line.ProductID, max(open[line.ID], 0), it.Quantity)
+			}
+			open[line.ID] -= int64(it.Quantity)
+
+			sns, err := cleanSerials(it.SerialNumbers)
+			if err != nil {
+				return fmt.Errorf("%w: %v", errInvalidReturn, err)
+			}
+			product := products[line.IDFromProduct.Int32]
+			if product.TrackSerials && int32(len(sns)) != it.Quantity {
+				return fmt.Errorf("%w: product %s tracks serial numbers, %d serial_numbers required", errInvalidReturn, line.ProductID, it.Quantity)
+			}
+			if !product.TrackSerials && len(sns) > 0 {
+				return fmt.Errorf("%w: product %s does not track serial numbers", errInvalidReturn, line.ProductID)
+			}
+			if err := checkReturnSerials(r.Context(), q, product.ID, order.ID, sns); err != nil {
+				return err
+			}
+			serials[i] = sns
+		}
+
+		warehouseID := req.WarehouseID
+		if warehouseID == 0 && order.IDFromWarehouse.Valid {
+			warehouseID = order.IDFromWarehouse.Int32
+		}
+		if warehouseID, err = resolveWarehouse(r.Context(), q, warehouseID); err != nil {
+			return err
+		}
+
+		note := strings.TrimSpace(req.Note)
+		ret, err := q.CreateOrderReturn(r.Context(), repo.CreateOrderReturnParams{
+			IDFromOrder:     order.ID,
+			Reason:          req.Reason,
+			IDFromWarehouse: warehouseID,
+			Note:            pgtype.Text{String: note, Valid: note != ""},
+			RequestedBy:     requestUser(r),
+		})
+		if err != nil {
+			return err
+		}
+		for i, it := range req.Items {
+			if _, err := q.CreateOrderReturnItem(r.Context(), repo.CreateOrderReturnItemParams{
+				IDFromReturn:    ret.ID,
+				IDFromOrderItem: it.IDFromOrderItem,
+				Quantity:        it.Quantity,
+				SerialNumbers:   serials[i],
+			}); err != nil {
+				return err
+			}
+		}
+
+		resp, err = loadOrderReturn(r.Context(), q, ret.ID)
+		return err
+	})
+	if err != nil {
+		writeOrderReturnError(w, err, "create return")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ListOrderReturnsForOrder handles GET /orders/{id}/returns
+func (s *Server) ListOrderReturnsForOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.Repo.ListOrderReturns(r.Context(), repo.ListOrderReturnsParams{
+		OrderID:  pgtype.Int4{Int32: orderID, Valid: true},
+		RowLimit: 200,
+	})
+	if err != nil {
+		http.Error(w, "failed to list returns: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := orderReturnResponses(r.Context(), s.Repo, rows)
+	if err != nil {
+		http.Error(w, "failed to list returns: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ListReturns handles GET /returns (?status=&from=&to=&limit=&offset=)
+func (s *Server) ListReturns(w http.ResponseWriter, r *http.Request) {
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("status")))
+	switch status {
+	case "", returnStatusRequested, returnStatusCompleted, returnStatusRejected:
+	default:
+		http.Error(w, "unknown status "+strconv.Quote(status)+", must be one of requested, completed, rejected", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.Repo.ListOrderReturns(r.Context(), repo.ListOrderReturnsParams{
+		Status:      pgtype.Text{String: status, Valid: status != ""},
+		CreatedFrom: from,
+		CreatedTo:   to,
+		RowLimit:    int32(queryInt(r, "limit", 50, 1, 200)),
+		RowOffset:   int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	})
+	if err != nil {
+		http.Error(w, "failed to list returns: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := orderReturnResponses(r.Context(), s.Repo, rows)
+	if err != nil {
+		http.Error(w, "failed to list returns: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetReturn handles GET /returns/{id}
+func (s *Server) GetReturn(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := loadOrderReturn(r.Context(), s.Repo, id)
+	if err != nil {
+		writeOrderReturnError(w, err, "get return")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// lockRequestedReturn locks a return that is still waiting for inspection
+func lockRequestedReturn(ctx context.Context, q *repo.Queries, id int32) (repo.OrderReturn, error) {
+	ret, err := q.GetOrderReturnForUpdate(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ret, errOrderReturnNotFound
+	}
+	if err != nil {
+		return ret, err
+	}
+	if ret.Status != returnStatusRequested {
+		return ret, fmt.Errorf("%w: return is %s", errReturnStatus, ret.Status)
+	}
+	return ret, nil
+}
+
+// lineUnitCost is what one unit of an order line cost when it shipped: the COGS the line
+// consumed from the cost layers, or the unit cost captured at order time for older lines
+func lineUnitCost(cogs pgtype.Int8, unitCost int64, qty int32) int64 {
+	if !cogs.Valid || qty <= 0 {
+		return unitCost
+	}
+	return averageUnitCost(cogs.Int64, int64(qty))
+}
+
+// InspectReturn handles POST /returns/{id}/inspect: every line gets an outcome. Restocked
+// units go back into stock at the return's warehouse (at the cost they left with) and
+// serial-tracked units become available again; damaged and discarded units stay out of stock.
+// The refund is the sum of the line refunds.
+func (s *Server) InspectReturn(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req InspectOrderReturnRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp OrderReturnResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		ret, err := lockRequestedReturn(r.Context(), q, id)
+		if err != nil {
+			return err
+		}
+		items, err := q.ListOrderReturnItemsByReturnIDs(r.Context(), []int32{ret.ID})
+		if err != nil {
+			return err
+		}
+		inspected := make(map[int32]InspectReturnItemRequest, len(req.Items))
+		for _, it := range req.Items {
+			if _, dup := inspected[it.ID]; dup {
+				return fmt.Errorf("%w: return item %d inspected twice", errInvalidReturn, it.ID)
+			}
+			inspected[it.ID] = it
+		}
+		if len(inspected) != len(items) {
+			return fmt.Errorf("%w: all %d return items need an outcome", errInvalidReturn, len(items))
+		}
+
+		reference := returnReference(ret.ID)
+		var refund int64
+		for _, item := range items {
+			in, ok := inspected[item.ID]
+			if !ok {
+				return fmt.Errorf("%w: return item %d needs an outcome", errInvalidReturn, item.ID)
+			}
+
+			lineRefund := item.UnitPriceIdr * int64(item.Quantity)
+			if in.RefundIdr != nil {
+				if *in.RefundIdr < 0 || *in.RefundIdr > lineRefund {
+					return fmt.Errorf("%w: refund for return item %d must be between 0 and %d", errInvalidReturn, item.ID, lineRefund)
+				}
+				lineRefund = *in.RefundIdr
+			}
+
+			outcome := strings.ToLower(strings.TrimSpace(in.Outcome))
+			switch outcome {
+			case returnOutcomeRestock:
+				unitCost := lineUnitCost(item.CogsIdr, item.UnitCostIdr, item.OrderedQty)
+				if _, _, err := s.applyStockChange(r.Context(), q, stockChange{
+					ProductID:   item.IDFromProduct,
+					Delta:       item.Quantity,
+					Reason:      movementReasonReturn,
+					Reference:   reference,
+					WarehouseID: ret.IDFromWarehouse,
+					UnitCostIdr: &unitCost,
+				}); err != nil {
+					return err
+				}
+				if item.IDFromVariant.Valid {
+					if err := adjustVariantStock(r.Context(), q, item.IDFromVariant.Int32, item.Quantity); err != nil {
+						return err
+					}
+				}
+				for _, sn := range item.SerialNumbers {
+					if _, err := returnSerial(r.Context(), q, item.IDFromProduct, sn, ret.IDFromWarehouse, reference); err != nil {
+						return err
+					}
+				}
+			case returnOutcomeDamaged, returnOutcomeDiscard:
+			default:
+				return fmt.Errorf("%w: unknown outcome %q for return item %d (use restock, damaged or discard)", errInvalidReturn, in.Outcome, item.ID)
+			}
+
+			note := strings.TrimSpace(in.Note)
+			if err := q.SetOrderReturnItemOutcome(r.Context(), repo.SetOrderReturnItemOutcomeParams{
+				ID:        item.ID,
+				Outcome:   pgtype.Text{String: outcome, Valid: true},
+				RefundIdr: lineRefund,
+				Note:      pgtype.Text{String: note, Valid: note != ""},
+			}); err != nil {
+				return err
+			}
+			refund += lineRefund
+		}
+
+		note := strings.TrimSpace(req.Note)
+		if _, err := q.CloseOrderReturn(r.Context(), repo.CloseOrderReturnParams{
+			ID:          ret.ID,
+			Status:      returnStatusCompleted,
+			RefundIdr:   refund,
+			InspectedBy: requestUser(r),
+			Note:        pgtype.Text{String: note, Valid: note != ""},
+		}); err != nil {
+			return err
+		}
+
+		resp, err = loadOrderReturn(r.Context(), q, ret.ID)
+		return err
+	})
+	if err != nil {
+		writeOrderReturnError(w, err, "inspect return")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RejectReturn handles POST /returns/{id}/reject: nothing comes back into stock and nothing is
+// refunded; the quantities become returnable again
+func (s *Server) RejectReturn(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req RejectOrderReturnRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var resp OrderReturnResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		ret, err := lockRequestedReturn(r.Context(), q, id)
+		if err != nil {
+			return err
+		}
+		note := strings.TrimSpace(req.Note)
+		if _, err := q.CloseOrderReturn(r.Context(), repo.CloseOrderReturnParams{
+			ID:          ret.ID,
+			Status:      returnStatusRejected,
+			InspectedBy: requestUser(r),
+			Note:        pgtype.Text{String: note, Valid: note != ""},
+		}); err != nil {
+			return err
+		}
+		resp, err = loadOrderReturn(r.Context(), q, ret.ID)
+		return err
+	})
+	if err != nil {
+		writeOrderReturnError(w, err, "reject return")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetReturnsReport handles GET /returns/report (?from=&to=): completed returns per product
+// with quantities by outcome and refunds, plus the totals
+func (s *Server) GetReturnsReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.Repo.GetReturnsReport(r.Context(), repo.GetReturnsReportParams{
+		CreatedFrom: from,
+		CreatedTo:   to,
+	})
+	if err != nil {
+		http.Error(w, "failed to get returns report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report := ReturnsReport{Products: rows}
+	if report.Products == nil {
+		report.Products = []repo.GetReturnsReportRow{}
+	}
+	for _, row := range rows {
+		report.Totals.ReturnedQty += row.ReturnedQty
+		report.Totals.RestockedQty += row.RestockedQty
+		report.Totals.DamagedQty += row.DamagedQty
+		report.Totals.DiscardedQty += row.DiscardedQty
+		report.Totals.RefundIdr += row.RefundIdr
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// LookupProduct handles GET /products/lookup?code=... and resolves a scanned code
+// (product_id or any registered barcode) to the product
+func (s *Server) LookupProduct(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimSpace(r.URL.Query().Get("code"))
+	if code == "" {
+		http.Error(w, "missing code", http.StatusBadRequest)
+		return
+	}
+
+	product, err := s.Repo.GetProductByCode(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "no product for code "+code, http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to look up product: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := s.productResponses(r.Context(), []repo.Product{product}, productDetail)
+	if err != nil {
+		http.Error(w, "failed to fetch product details: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp[0])
+}
+
+// CreateProductBarcode handles POST /products/{id}/barcodes { "barcode": "8991234567890" }
+func (s *Server) CreateProductBarcode(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Barcode string `json:"barcode"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Barcode = strings.TrimSpace(req.Barcode)
+	if req.Barcode == "" {
+		http.Error(w, "barcode is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+
+	// a barcode that is already another product's code would make lookups ambiguous
+	if other, err := s.Repo.GetProductByCode(r.Context(), req.Barcode); err == nil && other.ID != id {
+		http.Error(w, "code already used by product "+other.ProductID, http.StatusConflict)
+		return
+	}
+
+	barcode, err := s.Repo.CreateProductBarcode(r.Context(), repo.CreateProductBarcodeParams{
+		IDFromProduct: id,
+		Barcode:       req.Barcode,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, "barcode already registered", http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to register barcode: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, barcode)
+}
+
+// DeleteProductBarcode handles DELETE /products/{id}/barcodes/{barcodeId}
+func (s *Server) DeleteProductBarcode(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	barcodeID, err := urlParamInt32(r, "barcodeId")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := s.Repo.DeleteProductBarcode(r.Context(), repo.DeleteProductBarcodeParams{
+		ID:            barcodeID,
+		IDFromProduct: id,
+	})
+	if err != nil {
+		http.Error(w, "failed to delete barcode: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "barcode not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ScanStockRequest is the body of POST /products/scan.
+// Delta defaults to +1 (one scan = one unit in); send -1 for one unit out or any quantity.
+type ScanStockRequest struct {
+	Code      string `json:"code"`
+	Delta     *int32 `json:"delta,omitempty"`
+	Unit      string `json:"unit,omitempty"` // satuan delta, default base unit
+	Reason    string `json:"reason,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// ScanAdjustStock handles POST /products/scan: resolves the scanned code and applies the
+// stock change atomically (UpdateProductStockByDelta semantics: never below zero),
+// recording the movement and its reason in the same transaction
+func (s *Server) ScanAdjustStock(w http.ResponseWriter, r *http.Request) {
+	var req ScanStockRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Code = strings.TrimSpace(req.Code)
+	if req.Code == "" {
+		http.Error(w, "code is required", http.StatusBadRequest)
+		return
+	}
+	delta := int32(1)
+	if req.Delta != nil {
+		delta = *req.Delta
+	}
+	if delta == 0 {
+		http.Error(w, "delta must not be zero", http.StatusBadRequest)
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = movementReasonScan
+	}
+	reference := strings.TrimSpace(req.Reference)
+	if reference == "" {
+		reference = req.Code
+	}
+
+	var resp StockChangeResponse
+	err := s.inTx(r.Context(), func(q *repo.Queries) error {
+		product, err := q.GetProductByCode(r.Context(), req.Code)
+		if err != nil {
+			return err
+		}
+		if product.TrackSerials {
+			return fmt.Errorf("%w: %s", errSerialTracked, product.ProductID)
+		}
+		delta, _, err := toBaseQuantity(r.Context(), q, product, req.Unit, delta)
+		if err != nil {
+			return err
+		}
+		resp.Product, resp.Movement, err = s.applyStockChange(r.Context(), q, stockChange{
+			ProductID: product.ID,
+			Delta:     delta,
+			Reason:    reason,
+			Reference: reference,
+		})
+		if err != nil {
+			return err
+		}
+		resp.StockUnits, err = productStockUnits(r.Context(), q, resp.Product)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "no product for code "+req.Code, http.StatusNotFound)
+		case errors.Is(err, errInsufficientStock):
+			http.Error(w, "insufficient stock", http.StatusConflict)
+		case errors.Is(err, errSerialTracked):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, errUnknownUnit):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "failed to adjust stock: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ListStockMovements handles GET /products/{id}/movements?limit=&offset=
+func (s *Server) ListStockMovements(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movements, err := s.Repo.ListStockMovementsByProduct(r.Context(), repo.ListStockMovementsByProductParams{
+		IDFromProduct: id,
+		Limit:         int32(queryInt(r, "limit", 100, 1, 500)),
+		Offset:        int32(queryInt(r, "offset", 0, 0, math.MaxInt32)),
+	})
+	if err != nil {
+		http.Error(w, "failed to list movements: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if movements == nil {
+		movements = []repo.StockMovement{}
+	}
+	writeJSON(w, http.StatusOK, movements)
+}
@@ -0,0 +1,524 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// serial_numbers.status values
+const (
+	serialStatusInStock   = "in_stock"
+	serialStatusSold      = "sold"
+	serialStatusInTransit = "in_transit"
+)
+
+// serial_events.event values
+const (
+	serialEventReceived    = "received"
+	serialEventShipped     = "shipped"
+	serialEventReturned    = "returned"
+	serialEventTransferOut = "transfer out"
+	serialEventTransferIn  = "transfer in"
+)
+
+// errSerialUnavailable is returned when a serial cannot be used for the requested change
+// (unknown for the product, already sold, at another location, ...)
+var errSerialUnavailable = errors.New("serial number not available")
+
+// errDuplicateSerial is returned when a serial is registered twice for the same product
+var errDuplicateSerial = errors.New("serial number already registered")
+
+// errSerialTracked is returned when stock of a serial-tracked product would change without
+// naming the units
+var errSerialTracked = errors.New("product tracks serial numbers")
+
+// cleanSerials trims the given serial numbers and rejects blanks and duplicates
+func cleanSerials(in []string) ([]string, error) {
+	out := make([]string, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, sn := range in {
+		sn = strings.TrimSpace(sn)
+		if sn == "" {
+			return nil, errors.New("serial_numbers must not contain blanks")
+		}
+		if seen[sn] {
+			return nil, fmt.Errorf("serial number %q listed twice", sn)
+		}
+		seen[sn] = true
+		out = append(out, sn)
+	}
+	return out, nil
+}
+
+// checkSerialCount enforces one serial number per unit of a serial-tracked product and none for
+// other products; serials should already be cleaned with cleanSerials
+func checkSerialCount(product repo.Product, serials []string, units int32) error {
+	if product.TrackSerials && int32(len(serials)) != units {
+		return fmt.Errorf("product %s tracks serial numbers, %d serial_numbers required", product.ProductID, units)
+	}
+	if !product.TrackSerials && len(serials) > 0 {
+		return fmt.Errorf("product %s does not track serial numbers", product.ProductID)
+	}
+	return nil
+}
+
+// requireUntracked fails with errSerialTracked for serial-tracked products: their units only
+// change through receipts, orders, returns and transfers, which name the serials
+func requireUntracked(ctx context.Context, q *repo.Queries, productID int32) error {
+	product, err := q.GetProductByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if product.TrackSerials {
+		return fmt.Errorf("%w: %s", errSerialTracked, product.ProductID)
+	}
+	return nil
+}
+
+// registerSerials books newly received units of a serial-tracked product at a location
+func registerSerials(ctx context.Context, q *repo.Queries, productID, warehouseID int32, serials []string, reference string) error {
+	warehouseID, err := resolveWarehouse(ctx, q, warehouseID)
+	if err != nil {
+		return err
+	}
+	for _, sn := range serials {
+		serial, err := q.CreateSerialNumber(ctx, repo.CreateSerialNumberParams{
+			IDFromProduct:   productID,
+			SerialNumber:    sn,
+			IDFromWarehouse: pgtype.Int4{Int32: warehouseID, Valid: true},
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("%w: %s", errDuplicateSerial, sn)
+			}
+			return err
+		}
+		if _, err := q.CreateSerialEvent(ctx, repo.CreateSerialEventParams{
+			IDFromSerial: serial.ID,
+			Event:        serialEventReceived,
+			Reference:    pgtype.Text{String: reference, Valid: reference != ""},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serialUnitsNeeded counts the units of serial-tracked products an order takes, per product.
+// The order's reservations hold the lines as orderLines split them, so bundles count their
+// components (or pre-built kits); orders without reservations fall back to their own lines.
+func serialUnitsNeeded(ctx context.Context, q *repo.Queries, orderID int32) (map[int32]int32, error) {
+	units := make(map[int32]int32)
+	reservations, err := q.ListActiveReservationsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range reservations {
+		units[res.IDFromProduct] += res.Quantity
+	}
+	if len(reservations) == 0 {
+		items, err := q.ListOrderItemsByOrderIDs(ctx, []int32{orderID})
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			if it.IDFromProduct.Valid {
+				units[it.IDFromProduct.Int32] += it.Quantity
+			}
+		}
+	}
+
+	ids := make([]int32, 0, len(units))
+	for id := range units {
+		ids = append(ids, id)
+	}
+	products, err := q.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	need := make(map[int32]int32)
+	for _, p := range products {
+		if p.TrackSerials && units[p.ID] > 0 {
+			need[p.ID] = units[p.ID]
+		}
+	}
+	return need, nil
+}
+
+// shipSerials assigns in-stock serials to an order that is leaving the warehouse; every unit
+// of a serial-tracked line (need: product id -> units) needs exactly one serial
+func shipSerials(ctx context.Context, q *repo.Queries, order repo.Order, need map[int32]int32, serials []string) error {
+	var total int32
+	productIDs := make([]int32, 0, len(need))
+	for id, n := range need {
+		total += n
+		productIDs = append(productIDs, id)
+	}
+	if int32(len(serials)) != total {
+		return fmt.Errorf("%w: order needs %d serial numbers, got %d", errSerialUnavailable, total, len(serials))
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	for _, sn := range serials {
+		// the serial belongs to whichever ordered product registered it
+		var serial repo.SerialNumber
+		found := false
+		for _, id := range productIDs {
+			var err error
+			serial, err = q.GetSerialForUpdate(ctx, repo.GetSerialForUpdateParams{
+				IDFromProduct: id,
+				SerialNumber:  sn,
+			})
+			if err == nil {
+				found = true
+				break
+			}
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s is not registered for a product on this order", errSerialUnavailable, sn)
+		}
+		if need[serial.IDFromProduct] == 0 {
+			return fmt.Errorf("%w: more serial numbers than ordered units for %s", errSerialUnavailable, sn)
+		}
+		need[serial.IDFromProduct]--
+		if serial.Status != serialStatusInStock {
+			return fmt.Errorf("%w: %s is %s", errSerialUnavailable, sn, serial.Status)
+		}
+		// barang harus diambil dari gudang order
+		if order.IDFromWarehouse.Valid && serial.IDFromWarehouse.Valid && serial.IDFromWarehouse.Int32 != order.IDFromWarehouse.Int32 {
+			return fmt.Errorf("%w: %s is not at the order's pick location", errSerialUnavailable, sn)
+		}
+
+		if _, err := q.UpdateSerialStatus(ctx, repo.UpdateSerialStatusParams{
+			ID:          serial.ID,
+			Status:      serialStatusSold,
+			IDFromOrder: pgtype.Int4{Int32: order.ID, Valid: true},
+		}); err != nil {
+			return err
+		}
+		if _, err := q.CreateSerialEvent(ctx, repo.CreateSerialEventParams{
+			IDFromSerial: serial.ID,
+			Event:        serialEventShipped,
+			IDFromOrder:  pgtype.Int4{Int32: order.ID, Valid: true},
+			Reference:    pgtype.Text{String: order.OrderNumber, Valid: true},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returnSerial puts a sold unit back in stock at a location (0 = default warehouse),
+// recording the order it came back from
+func returnSerial(ctx context.Context, q *repo.Queries, productID int32, sn string, warehouseID int32, reference string) (repo.SerialNumber, error) {
+	serial, err := q.GetSerialForUpdate(ctx, repo.GetSerialForUpdateParams{
+		IDFromProduct: productID,
+		SerialNumber:  sn,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.SerialNumber{}, fmt.Errorf("%w: %s is not registered for this product", errSerialUnavailable, sn)
+		}
+		return repo.SerialNumber{}, err
+	}
+	if serial.Status != serialStatusSold {
+		return repo.SerialNumber{}, fmt.Errorf("%w: %s is %s", errSerialUnavailable, sn, serial.Status)
+	}
+	warehouseID, err = resolveWarehouse(ctx, q, warehouseID)
+	if err != nil {
+		return repo.SerialNumber{}, err
+	}
+
+	if _, err := q.CreateSerialEvent(ctx, repo.CreateSerialEventParams{
+		IDFromSerial: serial.ID,
+		Event:        serialEventReturned,
+		IDFromOrder:  serial.IDFromOrder,
+		Reference:    pgtype.Text{String: reference, Valid: reference != ""},
+	}); err != nil {
+		return repo.SerialNumber{}, err
+	}
+	return q.UpdateSerialStatus(ctx, repo.UpdateSerialStatusParams{
+		ID:              serial.ID,
+		Status:          serialStatusInStock,
+		IDFromWarehouse: pgtype.Int4{Int32: warehouseID, Valid: true},
+	})
+}
+
+// transferSerials moves the units of a transfer line. Sending takes in-stock units at the
+// source (warehouseID) into transit; closing the transfer books them in at warehouseID, the
+// destination or, when cancelled, the source again.
+func transferSerials(ctx context.Context, q *repo.Queries, productID int32, serials []string, sending bool, warehouseID int32, reference string) error {
+	from, to, event := serialStatusInTransit, serialStatusInStock, serialEventTransferIn
+	if sending {
+		from, to, event = serialStatusInStock, serialStatusInTransit, serialEventTransferOut
+	}
+	for _, sn := range serials {
+		serial, err := q.GetSerialForUpdate(ctx, repo.GetSerialForUpdateParams{
+			IDFromProduct: productID,
+			SerialNumber:  sn,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w: %s is not registered for this product", errSerialUnavailable, sn)
+			}
+			return err
+		}
+		if serial.Status != from {
+			return fmt.Errorf("%w: %s is %s", errSerialUnavailable, sn, serial.Status)
+		}
+		if sending && serial.IDFromWarehouse.Int32 != warehouseID {
+			return fmt.Errorf("%w: %s is not at the source location", errSerialUnavailable, sn)
+		}
+
+		if _, err := q.UpdateSerialStatus(ctx, repo.UpdateSerialStatusParams{
+			ID:              serial.ID,
+			Status:          to,
+			IDFromWarehouse: pgtype.Int4{Int32: warehouseID, Valid: true},
+			IDFromOrder:     serial.IDFromOrder,
+		}); err != nil {
+			return err
+		}
+		if _, err := q.CreateSerialEvent(ctx, repo.CreateSerialEventParams{
+			IDFromSerial: serial.ID,
+			Event:        event,
+			Reference:    pgtype.Text{String: reference, Valid: reference != ""},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateSerialTrackingRequest is the body of PUT /products/{id}/serial-tracking
+type UpdateSerialTrackingRequest struct {
+	TrackSerials bool `json:"track_serials"`
+}
+
+// UpdateSerialTracking handles PUT /products/{id}/serial-tracking. Tracking can only be
+// switched on while the product has no stock, since existing units carry no serial.
+func (s *Server) UpdateSerialTracking(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateSerialTrackingRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+	if req.TrackSerials && !product.TrackSerials && product.Stock > 0 {
+		http.Error(w, "product still has stock without serial numbers", http.StatusConflict)
+		return
+	}
+
+	product, err = s.Repo.UpdateProductSerialTracking(r.Context(), repo.UpdateProductSerialTrackingParams{
+		ID:           id,
+		TrackSerials: req.TrackSerials,
+	})
+	if err != nil {
+		http.Error(w, "failed to update serial tracking: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
+
+// ListProductSerials handles GET /products/{id}/serials (optional ?status=in_stock|sold)
+func (s *Server) ListProductSerials(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	serials, err := s.Repo.ListSerialsByProduct(r.Context(), repo.ListSerialsByProductParams{
+		IDFromProduct: id,
+		Status:        pgtype.Text{String: status, Valid: status != ""},
+		RowLimit:      int32(queryInt(r, "limit", 100, 1, 500)),
+		RowOffset:     int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	})
+	if err != nil {
+		http.Error(w, "failed to list serial numbers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if serials == nil {
+		serials = []repo.SerialNumber{}
+	}
+
+	writeJSON(w, http.StatusOK, serials)
+}
+
+// ReturnSerialRequest is the body of POST /products/{id}/serials/{serial}/return
+type ReturnSerialRequest struct {
+	WarehouseID int32  `json:"warehouse_id,omitempty"` // default warehouse when omitted
+	Reference   string `json:"reference,omitempty"`    // e.g. RMA number
+}
+
+// ReturnProductSerial handles POST /products/{id}/serials/{serial}/return: a sold unit
+// comes back and is available for shipping again. It is booked like a restocked return: one
+// unit into the location's stock and the ledger, with a cost layer at what the unit cost when
+// it shipped.
+func (s *Server) ReturnProductSerial(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req ReturnSerialRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+
+	var serial repo.SerialNumber
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		sn := chi.URLParam(r, "serial")
+		sold, err := q.GetSerialForUpdate(r.Context(), repo.GetSerialForUpdateParams{
+			IDFromProduct: id,
+			SerialNumber:  sn,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		reference := strings.TrimSpace(req.Reference)
+		serial, err = returnSerial(r.Context(), q, id, sn, req.WarehouseID, reference)
+		if err != nil {
+			return err
+		}
+
+		unitCost, variantID, err := serialOrderLine(r.Context(), q, id, sold.IDFromOrder)
+		if err != nil {
+			return err
+		}
+		if reference == "" {
+			reference = sn
+		}
+		if _, _, err := s.applyStockChange(r.Context(), q, stockChange{
+			ProductID:   id,
+			Delta:       1,
+			Reason:      movementReasonReturn,
+			Reference:   reference,
+			WarehouseID: serial.IDFromWarehouse.Int32,
+			UnitCostIdr: unitCost,
+		}); err != nil {
+			return err
+		}
+		if variantID.Valid {
+			return adjustVariantStock(r.Context(), q, variantID.Int32, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errSerialUnavailable), errors.Is(err, errInsufficientStock):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, errWarehouseNotFound), isForeignKeyViolation(err):
+			http.Error(w, errWarehouseNotFound.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "failed to return serial number: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, serial)
+}
+
+// serialOrderLine finds the line of the order a serial shipped with: the unit cost it left
+// with (nil = product cost, e.g. for units sold before orders had lines) and its variant
+func serialOrderLine(ctx context.Context, q *repo.Queries, productID int32, orderID pgtype.Int4) (*int64, pgtype.Int4, error) {
+	if !orderID.Valid {
+		return nil, pgtype.Int4{}, nil
+	}
+	items, err := q.ListOrderItemsByOrderIDs(ctx, []int32{orderID.Int32})
+	if err != nil {
+		return nil, pgtype.Int4{}, err
+	}
+	for _, it := range items {
+		if it.IDFromProduct.Valid && it.IDFromProduct.Int32 == productID {
+			cost := lineUnitCost(it.CogsIdr, it.UnitCostIdr, it.Quantity)
+			return &cost, it.IDFromVariant, nil
+		}
+	}
+	return nil, pgtype.Int4{}, nil
+}
+
+// SerialHistory is one registered unit with everything that happened to it
+type SerialHistory struct {
+	repo.ListSerialsByNumberRow
+	Events []repo.ListSerialEventsBySerialIDsRow `json:"events"`
+}
+
+// LookupSerial handles GET /serials/{serial}: every product unit carrying the serial
+// number, with its receipts, shipments (incl. order numbers) and returns
+func (s *Server) LookupSerial(w http.ResponseWriter, r *http.Request) {
+	sn := strings.TrimSpace(chi.URLParam(r, "serial"))
+	if sn == "" {
+		http.Error(w, "missing serial", http.StatusBadRequest)
+		return
+	}
+
+	serials, err := s.Repo.ListSerialsByNumber(r.Context(), sn)
+	if err != nil {
+		http.Error(w, "failed to look up serial number: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(serials) == 0 {
+		http.Error(w, "serial number not found", http.StatusNotFound)
+		return
+	}
+
+	ids := make([]int32, len(serials))
+	for i, sr := range serials {
+		ids[i] = sr.ID
+	}
+	events, err := s.Repo.ListSerialEventsBySerialIDs(r.Context(), ids)
+	if err != nil {
+		http.Error(w, "failed to list serial history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	bySerial := make(map[int32][]repo.ListSerialEventsBySerialIDsRow, len(serials))
+	for _, e := range events {
+		bySerial[e.IDFromSerial] = append(bySerial[e.IDFromSerial], e)
+	}
+
+	resp := make([]SerialHistory, len(serials))
+	for i, sr := range serials {
+		resp[i] = SerialHistory{ListSerialsByNumberRow: sr, Events: bySerial[sr.ID]}
+		if resp[i].Events == nil {
+			resp[i].Events = []repo.ListSerialEventsBySerialIDsRow{}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
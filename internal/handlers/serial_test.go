@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+func TestCleanSerials(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr bool
+	}{
+		{"none", nil, []string{}, false},
+		{"kept in order", []string{"SN-2", "SN-1", "SN-3"}, []string{"SN-2", "SN-1", "SN-3"}, false},
+		{"trimmed", []string{" SN-1", "SN-2\t"}, []string{"SN-1", "SN-2"}, false},
+		{"case matters", []string{"sn-1", "SN-1"}, []string{"sn-1", "SN-1"}, false},
+		{"blank", []string{"SN-1", ""}, nil, true},
+		{"whitespace only", []string{"   "}, nil, true},
+		{"duplicate", []string{"SN-1", "SN-2", "SN-1"}, nil, true},
+		{"duplicate after trimming", []string{"SN-1", " SN-1 "}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := cleanSerials(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: cleanSerials(%q) error = %v, want error %v", tt.name, tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !slices.Equal(got, tt.want) {
+			t.Errorf("%s: cleanSerials(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSerialCount(t *testing.T) {
+	tracked := repo.Product{ProductID: "PHN-01", TrackSerials: true}
+	untracked := repo.Product{ProductID: "CBL-01"}
+
+	tests := []struct {
+		name    string
+		product repo.Product
+		in      []string
+		units   int32
+		wantErr bool
+	}{
+		{"one serial per unit", tracked, []string{"SN-1", "SN-2"}, 2, false},
+		{"too few serials", tracked, []string{"SN-1"}, 2, true},
+		{"too many serials", tracked, []string{"SN-1", "SN-2", "SN-3"}, 2, true},
+		{"no serials", tracked, nil, 1, true},
+		// cleanSerials rejects a repeated serial rather than counting it twice
+		{"duplicate", tracked, []string{"SN-1", "SN-1"}, 2, true},
+		{"blank", tracked, []string{"SN-1", " "}, 2, true},
+		{"untracked without serials", untracked, nil, 5, false},
+		{"untracked with serials", untracked, []string{"SN-1"}, 1, true},
+	}
+	for _, tt := range tests {
+		serials, err := cleanSerials(tt.in)
+		if err == nil {
+			err = checkSerialCount(tt.product, serials, tt.units)
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: serials %q for %d unit(s) of %s: error = %v, want error %v", tt.name, tt.in, tt.units, tt.product.ProductID, err, tt.wantErr)
+		}
+	}
+}
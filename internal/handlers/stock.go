@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// errInsufficientStock is returned when a change would take stock below zero
+var errInsufficientStock = errors.New("insufficient stock")
+
+// errWarehouseNotFound is returned when a stock change names an unknown location
+var errWarehouseNotFound = errors.New("warehouse not found")
+
+// stock movement reasons written by the system (users may also supply their own reason)
+const (
+	movementReasonScan        = "scan"
+	movementReasonReceipt     = "receipt"
+	movementReasonAdjustment  = "adjustment"
+	movementReasonInitial     = "initial stock"
+	movementReasonStockTake   = "stock take"
+	movementReasonSale        = "sale"
+	movementReasonReturn      = "return"
+	movementReasonTransferOut = "transfer out"
+	movementReasonTransferIn  = "transfer in"
+)
+
+// stockChange is one change of on-hand stock, recorded in the stock_movements ledger
+type stockChange struct {
+	ProductID int32  // products.id
+	Delta     int32  // + masuk, - keluar
+	Reason    string // why the stock changed
+	Reference string // optional: scanned code, document number, ...
+
+	// location whose balance changes with the product total (0 = default warehouse)
+	WarehouseID int32
+
+	// optional lot the units go into / come out of; without one, outgoing units are
+	// taken from the location's lots first-expired-first-out
+	LotNumber  string
+	ExpiryDate pgtype.Date // only for incoming units of a new lot
+
+	// only for incoming stock (Delta > 0): cost of the new cost layer (nil = product cost_idr)
+	// and when the goods arrived (zero = now)
+	UnitCostIdr *int64
+	ReceivedAt  time.Time
+
+	// set when the units were already allocated to an order (lots and cost layers taken when
+	// the order was placed), so only the on-hand balances change
+	Allocated bool
+}
+
+// StockChangeResponse returns the updated product and the recorded movement, with the new
+// stock also expressed in the product's pack units
+type StockChangeResponse struct {
+	Product    repo.Product       `json:"product"`
+	Movement   repo.StockMovement `json:"movement"`
+	StockUnits []UnitQuantity     `json:"stock_units,omitempty"`
+}
+
+// applyStockChange atomically adds c.Delta to products.stock and to the balance at
+// c.WarehouseID (neither may go below zero), records the movement and keeps the cost
+// layers in step: incoming stock opens a layer, outgoing stock consumes the oldest ones.
+// q should be bound to a transaction so all of it commits together.
+func (s *Server) applyStockChange(ctx context.Context, q *repo.Queries, c stockChange) (repo.Product, repo.StockMovement, error) {
+	product, err := q.UpdateProductStockByDelta(ctx, repo.UpdateProductStockByDeltaParams{
+		ID:    c.ProductID,
+		Stock: c.Delta,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.Product{}, repo.StockMovement{}, errInsufficientStock
+		}
+		return repo.Product{}, repo.StockMovement{}, err
+	}
+
+	warehouseID, err := resolveWarehouse(ctx, q, c.WarehouseID)
+	if err != nil {
+		return repo.Product{}, repo.StockMovement{}, err
+	}
+	if err := adjustWarehouseStock(ctx, q, warehouseID, c.ProductID, c.Delta); err != nil {
+		return repo.Product{}, repo.StockMovement{}, err
+	}
+	if !c.Allocated {
+		if err := changeLots(ctx, q, c, warehouseID); err != nil {
+			return repo.Product{}, repo.StockMovement{}, err
+		}
+	}
+
+	movement, err := q.CreateStockMovement(ctx, repo.CreateStockMovementParams{
+		IDFromProduct: c.ProductID,
+		Quantity:      c.Delta,
+		StockAfter:    product.Stock,
+		Reason:        c.Reason,
+		Reference:     pgtype.Text{String: c.Reference, Valid: c.Reference != ""},
+	})
+	if err != nil {
+		return repo.Product{}, repo.StockMovement{}, err
+	}
+
+	reference := c.Reference
+	if reference == "" {
+		reference = c.Reason
+	}
+	switch {
+	case c.Allocated:
+	case c.Delta > 0:
+		unitCost := product.CostIdr
+		if c.UnitCostIdr != nil {
+			unitCost = *c.UnitCostIdr
+		}
+		receivedAt := c.ReceivedAt
+		if receivedAt.IsZero() {
+			receivedAt = time.Now()
+		}
+		_, err = q.CreateCostLayer(ctx, repo.CreateCostLayerParams{
+			IDFromProduct: c.ProductID,
+			Quantity:      c.Delta,
+			UnitCostIdr:   unitCost,
+			ReceivedAt:    pgtype.Timestamptz{Time: receivedAt, Valid: true},
+			Reference:     pgtype.Text{String: reference, Valid: true},
+		})
+	case c.Delta < 0:
+		_, err = s.consumeCostLayers(ctx, q, c.ProductID, -c.Delta, pgtype.Int4{}, reference, product.CostIdr)
+	}
+	if err != nil {
+		return repo.Product{}, repo.StockMovement{}, err
+	}
+	return product, movement, nil
+}
+
+// resolveWarehouse turns 0 into the id of the default warehouse
+func resolveWarehouse(ctx context.Context, q *repo.Queries, warehouseID int32) (int32, error) {
+	if warehouseID != 0 {
+		return warehouseID, nil
+	}
+	w, err := q.GetDefaultWarehouse(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, errWarehouseNotFound
+		}
+		return 0, err
+	}
+	return w.ID, nil
+}
+
+// adjustWarehouseStock adds delta to the product's balance at a location (0 = default warehouse)
+func adjustWarehouseStock(ctx context.Context, q *repo.Queries, warehouseID, productID, delta int32) error {
+	warehouseID, err := resolveWarehouse(ctx, q, warehouseID)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.AdjustWarehouseStock(ctx, repo.AdjustWarehouseStockParams{
+		IDFromWarehouse: warehouseID,
+		IDFromProduct:   productID,
+		Quantity:        delta,
+	})
+	switch {
+	case isCheckViolation(err):
+		return errInsufficientStock
+	case isForeignKeyViolation(err):
+		return errWarehouseNotFound
+	}
+	return err
+}
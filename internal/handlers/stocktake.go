@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// stock-take session statuses
+const (
+	stockTakeStatusOpen      = "open"
+	stockTakeStatusApproved  = "approved"
+	stockTakeStatusCancelled = "cancelled"
+)
+
+var (
+	// errStockTakeNotFound is returned for an unknown stock-take session
+	errStockTakeNotFound = errors.New("stock take not found")
+	// errStockTakeClosed is returned when a session is no longer open for counting
+	errStockTakeClosed = errors.New("stock take is not open")
+)
+
+// CreateStockTakeRequest is the body of POST /stock-takes
+type CreateStockTakeRequest struct {
+	WarehouseID int32  `json:"warehouse_id,omitempty"` // default warehouse when omitted
+	Category    string `json:"category,omitempty"`     // hanya hitung satu kategori
+	Note        string `json:"note,omitempty"`
+}
+
+// StockTakeCountRequest is the body of POST /stock-takes/{id}/counts
+type StockTakeCountRequest struct {
+	Items []StockTakeCountItem `json:"items"`
+}
+
+// StockTakeCountItem sets the counted quantity of one product (overwrites earlier counts)
+type StockTakeCountItem struct {
+	IdFromProduct int32 `json:"id_from_product"`
+	CountedQty    int32 `json:"counted_qty"`
+}
+
+// StockTakeScanRequest is the body of POST /stock-takes/{id}/scan; every scan adds to the count
+type StockTakeScanRequest struct {
+	Code     string `json:"code"`               // product_id or barcode
+	Quantity *int32 `json:"quantity,omitempty"` // default 1
+}
+
+// StockTakeLine is one product of a session; variance = counted - expected (nil while uncounted)
+type StockTakeLine struct {
+	repo.ListStockTakeItemsRow
+	Variance         *int32 `json:"variance"`
+	VarianceValueIdr *int64 `json:"variance_value_idr"` // variance x cost_idr
+}
+
+// StockTakeResponse is a session with its lines
+type StockTakeResponse struct {
+	repo.StockTake
+	Items []StockTakeLine `json:"items"`
+}
+
+// StockTakeVarianceReport summarises the differences found by a session
+type StockTakeVarianceReport struct {
+	StockTake      repo.StockTake  `json:"stock_take"`
+	CountedLines   int             `json:"counted_lines"`
+	UncountedLines int             `json:"uncounted_lines"`
+	UnitsOver      int64           `json:"units_over"`
+	UnitsShort     int64           `json:"units_short"`
+	ValueOverIdr   int64           `json:"value_over_idr"`
+	ValueShortIdr  int64           `json:"value_short_idr"`
+	NetVarianceIdr int64           `json:"net_variance_idr"`
+	Lines          []StockTakeLine `json:"lines"` // only lines with a variance
+}
+
+// stockTakeLines loads the lines of a session and computes their variances
+func stockTakeLines(ctx context.Context, q repo.Querier, id int32) ([]StockTakeLine, error) {
+	rows, err := q.ListStockTakeItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]StockTakeLine, len(rows))
+	for i, row := range rows {
+		lines[i].ListStockTakeItemsRow = row
+		if row.CountedQty.Valid {
+			v := row.CountedQty.Int32 - row.ExpectedQty
+			value := int64(v) * row.CostIdr
+			lines[i].Variance = &v
+			lines[i].VarianceValueIdr = &value
+		}
+	}
+	return lines, nil
+}
+
+// lockOpenStockTake locks a session for the rest of the transaction, failing unless it is open
+func lockOpenStockTake(ctx context.Context, q *repo.Queries, id int32) (repo.StockTake, error) {
+	st, err := q.GetStockTakeForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.StockTake{}, errStockTakeNotFound
+		}
+		return repo.StockTake{}, err
+	}
+	if st.Status != stockTakeStatusOpen {
+		return repo.StockTake{}, errStockTakeClosed
+	}
+	return st, nil
+}
+
+// writeStockTakeError maps the session errors shared by the stock-take handlers
+func writeStockTakeError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, errStockTakeNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errStockTakeClosed), errors.Is(err, errInsufficientStock), errors.Is(err, errSerialTracked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "failed to "+action+": "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ListStockTakes handles GET /stock-takes (?status=open|approved|cancelled)
+func (s *Server) ListStockTakes(w http.ResponseWriter, r *http.Request) {
+	status := strings.ToLower(r.URL.Query().Get("status"))
+	sessions, err := s.Repo.ListStockTakes(r.Context(), repo.ListStockTakesParams{
+		Status:    pgtype.Text{String: status, Valid: status != ""},
+		RowLimit:  int32(queryInt(r, "limit", 50, 1, 200)),
+		RowOffset: int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	})
+	if err != nil {
+		http.Error(w, "failed to list stock takes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if sessions == nil {
+		sessions = []repo.StockTake{}
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// CreateStockTake handles POST /stock-takes: opens a session and snapshots the expected
+// quantity of every product held at the location (optionally one category only)
+func (s *Server) CreateStockTake(w http.ResponseWriter, r *http.Request) {
+	var req CreateStockTakeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	category := strings.TrimSpace(req.Category)
+	note := strings.TrimSpace(req.Note)
+	createdBy := requestUser(r)
+
+	var resp StockTakeResponse
+	err := s.inTx(r.Context(), func(q *repo.Queries) error {
+		warehouseID, err := resolveWarehouse(r.Context(), q, req.WarehouseID)
+		if err != nil {
+			return err
+		}
+		resp.StockTake, err = q.CreateStockTake(r.Context(), repo.CreateStockTakeParams{
+			IDFromWarehouse: warehouseID,
+			Note:            pgtype.Text{String: note, Valid: note != ""},
+			CreatedBy:       createdBy,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.SnapshotStockTakeItems(r.Context(), repo.SnapshotStockTakeItemsParams{
+			IDFromStockTake: resp.ID,
+			IDFromWarehouse: warehouseID,
+			Category:        pgtype.Text{String: category, Valid: category != ""},
+		}); err != nil {
+			return err
+		}
+		resp.Items, err = stockTakeLines(r.Context(), q, resp.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errWarehouseNotFound) || isForeignKeyViolation(err) {
+			http.Error(w, "warehouse or user not found", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "failed to create stock take: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// GetStockTake handles GET /stock-takes/{id}
+func (s *Server) GetStockTake(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	st, err := s.Repo.GetStockTakeByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, errStockTakeNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch stock take: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	lines, err := stockTakeLines(r.Context(), s.Repo, id)
+	if err != nil {
+		http.Error(w, "failed to fetch stock take items: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StockTakeResponse{st, lines})
+}
+
+// RecordStockTakeCounts handles POST /stock-takes/{id}/counts with manually entered quantities
+func (s *Server) RecordStockTakeCounts(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req StockTakeCountRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) == 0 {
+		http.Error(w, "items must not be empty", http.StatusBadRequest)
+		return
+	}
+	for _, it := range req.Items {
+		if it.CountedQty < 0 {
+			http.Error(w, "counted_qty must not be negative", http.StatusBadRequest)
+			return
+		}
+	}
+
+	errUnknownProduct := errors.New("product not found")
+	var resp StockTakeResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		var err error
+		resp.StockTake, err = lockOpenStockTake(r.Context(), q, id)
+		if err != nil {
+			return err
+		}
+		for _, it := range req.Items {
+			if _, err := q.RecordStockTakeCount(r.Context(), repo.RecordStockTakeCountParams{
+				IDFromStockTake: id,
+				IDFromProduct:   it.IdFromProduct,
+				IDFromWarehouse: resp.IDFromWarehouse,
+				CountedQty:      pgtype.Int4{Int32: it.CountedQty, Valid: true},
+			}); err != nil {
+				if isForeignKeyViolation(err) {
+					return fmt.Errorf("%w: %d", errUnknownProduct, it.IdFromProduct)
+				}
+				return err
+			}
+		}
+		resp.Items, err = stockTakeLines(r.Context(), q, id)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errUnknownProduct) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeStockTakeError(w, err, "record counts")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ScanStockTakeCount handles POST /stock-takes/{id}/scan: a scanned code (product_id or
+// barcode) adds quantity (default 1) to the product's count
+func (s *Server) ScanStockTakeCount(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req StockTakeScanRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Code = strings.TrimSpace(req.Code)
+	if req.Code == "" {
+		http.Error(w, "code is required", http.StatusBadRequest)
+		return
+	}
+	qty := int32(1)
+	if req.Quantity != nil {
+		qty = *req.Quantity
+	}
+	if qty <= 0 {
+		http.Error(w, "quantity must be positive", http.StatusBadRequest)
+		return
+	}
+
+	errUnknownCode := errors.New("no product for code " + req.Code)
+	var item repo.StockTakeItem
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		st, err := lockOpenStockTake(r.Context(), q, id)
+		if err != nil {
+			return err
+		}
+		product, err := q.GetProductByCode(r.Context(), req.Code)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errUnknownCode
+			}
+			return err
+		}
+		item, err = q.RecordStockTakeCount(r.Context(), repo.RecordStockTakeCountParams{
+			IDFromStockTake: id,
+			IDFromProduct:   product.ID,
+			IDFromWarehouse: st.IDFromWarehouse,
+			CountedQty:      pgtype.Int4{Int32: qty, Valid: true},
+			AddToCount:      true,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errUnknownCode) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeStockTakeError(w, err, "record scan")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+// ApproveStockTake handles POST /stock-takes/{id}/approve (admin only, login token): every
+// counted variance is posted as an adjustment movement at the session's location, all in
+// one transaction. Uncounted lines are left untouched; a variance on a serial-tracked product
+// blocks approval, since the adjustment could not say which units appeared or went missing.
+func (s *Server) ApproveStockTake(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	admin, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var resp StockTakeResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		st, err := lockOpenStockTake(r.Context(), q, id)
+		if err != nil {
+			return err
+		}
+		resp.Items, err = stockTakeLines(r.Context(), q, id)
+		if err != nil {
+			return err
+		}
+
+		// selisih dibukukan terhadap stok saat ini, jadi transaksi sejak snapshot tetap terhitung
+		for _, line := range resp.Items {
+			if line.Variance == nil || *line.Variance == 0 {
+				continue
+			}
+			if err := requireUntracked(r.Context(), q, line.IDFromProduct); err != nil {
+				return err
+			}
+			if _, _, err := s.applyStockChange(r.Context(), q, stockChange{
+				ProductID:   line.IDFromProduct,
+				Delta:       *line.Variance,
+				Reason:      movementReasonStockTake,
+				Reference:   fmt.Sprintf("stock take #%d", id),
+				WarehouseID: st.IDFromWarehouse,
+			}); err != nil {
+				return fmt.Errorf("product %s: %w", line.ProductID, err)
+			}
+		}
+
+		resp.StockTake, err = q.CompleteStockTake(r.Context(), repo.CompleteStockTakeParams{
+			ID:         id,
+			Status:     stockTakeStatusApproved,
+			ApprovedBy: pgtype.Int4{Int32: admin.ID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		writeStockTakeError(w, err, "approve stock take")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// CancelStockTake handles POST /stock-takes/{id}/cancel; no stock is changed
+func (s *Server) CancelStockTake(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var st repo.StockTake
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		if _, err := lockOpenStockTake(r.Context(), q, id); err != nil {
+			return err
+		}
+		var err error
+		st, err = q.CompleteStockTake(r.Context(), repo.CompleteStockTakeParams{
+			ID:     id,
+			Status: stockTakeStatusCancelled,
+		})
+		return err
+	})
+	if err != nil {
+		writeStockTakeError(w, err, "cancel stock take")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, st)
+}
+
+// GetStockTakeVariance handles GET /stock-takes/{id}/variance: units and value over/short
+// (at current cost_idr) with the lines that differ from the snapshot
+func (s *Server) GetStockTakeVariance(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	st, err := s.Repo.GetStockTakeByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, errStockTakeNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch stock take: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	lines, err := stockTakeLines(r.Context(), s.Repo, id)
+	if err != nil {
+		http.Error(w, "failed to fetch stock take items: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report := StockTakeVarianceReport{StockTake: st, Lines: []StockTakeLine{}}
+	for _, line := range lines {
+		if line.Variance == nil {
+			report.UncountedLines++
+			continue
+		}
+		report.CountedLines++
+		switch v := *line.Variance; {
+		case v > 0:
+			report.UnitsOver += int64(v)
+			report.ValueOverIdr += *line.VarianceValueIdr
+		case v < 0:
+			report.UnitsShort += int64(-v)
+			report.ValueShortIdr += -*line.VarianceValueIdr
+		default:
+			continue
+		}
+		report.Lines = append(report.Lines, line)
+	}
+	report.NetVarianceIdr = report.ValueOverIdr - report.ValueShortIdr
+
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// SupplierPerformance is the purchasing record of one supplier
+type SupplierPerformance struct {
+	repo.GetSupplierPerformanceRow
+	FillRatePct   float64 `json:"fill_rate_pct"`    // received / ordered quantity
+	OnTimeRatePct float64 `json:"on_time_rate_pct"` // POs received in full by expected_at
+}
+
+// SupplierRank is one row of GET /suppliers/ranking
+type SupplierRank struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"` // 0-100
+	SupplierPerformance
+}
+
+// PriceTrendPoint is the average purchase cost of a product in one month
+type PriceTrendPoint struct {
+	Month          time.Time `json:"month"`
+	Quantity       int64     `json:"quantity"`
+	AvgUnitCostIdr int64     `json:"avg_unit_cost_idr"`
+}
+
+// ProductPriceTrend is how the purchase cost of one product moved over the period
+type ProductPriceTrend struct {
+	IDFromProduct    int32             `json:"id_from_product"`
+	ProductID        string            `json:"product_id"`
+	ProductName      string            `json:"product_name"`
+	FirstUnitCostIdr int64             `json:"first_unit_cost_idr"`
+	LastUnitCostIdr  int64             `json:"last_unit_cost_idr"`
+	ChangePct        float64           `json:"change_pct"`
+	Months           []PriceTrendPoint `json:"months"`
+}
+
+// SupplierDetail is the response of GET /suppliers/{name}
+type SupplierDetail struct {
+	SupplierPerformance
+	PriceTrend []ProductPriceTrend `json:"price_trend"`
+}
+
+// percentOf is part as a percentage of whole, rounded to two decimals
+func percentOf(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(whole)) / 100
+}
+
+// supplierPerformance adds the derived rates to a performance row
+func supplierPerformance(row repo.GetSupplierPerformanceRow) SupplierPerformance {
+	row.AvgLeadTimeDays = math.Round(row.AvgLeadTimeDays*100) / 100
+	return SupplierPerformance{
+		GetSupplierPerformanceRow: row,
+		FillRatePct:               percentOf(row.ReceivedQty, row.OrderedQty),
+		OnTimeRatePct:             percentOf(row.OnTimeOrderCount, row.DueOrderCount),
+	}
+}
+
+// rankSuppliers scores suppliers that have sent purchase orders: 40% fill rate, 40% on-time rate
+// and 20% speed (fastest average lead time = 100). Without due POs the on-time part is left out
+// and the rest reweighted.
+func rankSuppliers(perf []SupplierPerformance) []SupplierRank {
+	fastest := math.MaxFloat64
+	for _, p := range perf {
+		if p.PurchaseOrderCount > 0 && p.AvgLeadTimeDays > 0 {
+			fastest = min(fastest, p.AvgLeadTimeDays)
+		}
+	}
+
+	ranks := make([]SupplierRank, 0, len(perf))
+	for _, p := range perf {
+		if p.PurchaseOrderCount == 0 {
+			continue
+		}
+		speed := 100.0
+		if p.AvgLeadTimeDays > 0 {
+			speed = 100 * fastest / p.AvgLeadTimeDays
+		}
+		score := (0.4*p.FillRatePct + 0.2*speed) / 0.6
+		if p.DueOrderCount > 0 {
+			score = 0.4*p.FillRatePct + 0.4*p.OnTimeRatePct + 0.2*speed
+		}
+		ranks = append(ranks, SupplierRank{Score: math.Round(score*100) / 100, SupplierPerformance: p})
+	}
+
+	sort.SliceStable(ranks, func(i, j int) bool {
+		if ranks[i].Score != ranks[j].Score {
+			return ranks[i].Score > ranks[j].Score
+		}
+		return ranks[i].SupplierName < ranks[j].SupplierName
+	})
+	for i := range ranks {
+		ranks[i].Rank = i + 1
+	}
+	return ranks
+}
+
+// priceTrends groups the monthly purchase costs per product (rows come ordered by product, month)
+func priceTrends(rows []repo.GetSupplierPriceTrendRow) []ProductPriceTrend {
+	trends := []ProductPriceTrend{}
+	for _, row := range rows {
+		if n := len(trends); n == 0 || trends[n-1].IDFromProduct != row.IDFromProduct {
+			trends = append(trends, ProductPriceTrend{
+				IDFromProduct:    row.IDFromProduct,
+				ProductID:        row.ProductID,
+				ProductName:      row.ProductName,
+				FirstUnitCostIdr: row.AvgUnitCostIdr,
+			})
+		}
+		t := &trends[len(trends)-1]
+		t.LastUnitCostIdr = row.AvgUnitCostIdr
+		t.Months = append(t.Months, PriceTrendPoint{
+			Month:          row.Month.Time,
+			Quantity:       row.Quantity,
+			AvgUnitCostIdr: row.AvgUnitCostIdr,
+		})
+	}
+	for i := range trends {
+		trends[i].ChangePct = percentOf(trends[i].LastUnitCostIdr-trends[i].FirstUnitCostIdr, trends[i].FirstUnitCostIdr)
+	}
+	return trends
+}
+
+// loadSupplierPerformance runs the performance query for POs created in [from, to), optionally
+// for one supplier
+func (s *Server) loadSupplierPerformance(ctx context.Context, from, to pgtype.Timestamptz, supplier string) ([]SupplierPerformance, error) {
+	rows, err := s.Repo.GetSupplierPerformance(ctx, repo.GetSupplierPerformanceParams{
+		CreatedFrom:  from,
+		CreatedTo:    to,
+		SupplierName: pgtype.Text{String: supplier, Valid: supplier != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+	perf := make([]SupplierPerformance, len(rows))
+	for i, row := range rows {
+		perf[i] = supplierPerformance(row)
+	}
+	return perf, nil
+}
+
+// ListSuppliers handles GET /suppliers: lead time, fill rate and on-time rate per supplier
+func (s *Server) ListSuppliers(w http.ResponseWriter, r *http.Request) {
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	perf, err := s.loadSupplierPerformance(r.Context(), from, to, "")
+	if err != nil {
+		http.Error(w, "failed to get supplier performance: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, perf)
+}
+
+// GetSupplierRanking handles GET /suppliers/ranking: suppliers with purchase orders, best first
+func (s *Server) GetSupplierRanking(w http.ResponseWriter, r *http.Request) {
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	perf, err := s.loadSupplierPerformance(r.Context(), from, to, "")
+	if err != nil {
+		http.Error(w, "failed to get supplier performance: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, rankSuppliers(perf))
+}
+
+// GetSupplier handles GET /suppliers/{name}: performance plus the purchase price trend per product
+func (s *Server) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if r.URL.RawPath != "" {
+		// chi matched the escaped path, e.g. a name containing "/"
+		if v, err := url.PathUnescape(name); err == nil {
+			name = v
+		}
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		http.Error(w, "missing supplier name", http.StatusBadRequest)
+		return
+	}
+	from, to, err := createdRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	perf, err := s.loadSupplierPerformance(r.Context(), from, to, name)
+	if err != nil {
+		http.Error(w, "failed to get supplier performance: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(perf) == 0 {
+		http.Error(w, "supplier not found", http.StatusNotFound)
+		return
+	}
+
+	rows, err := s.Repo.GetSupplierPriceTrend(r.Context(), repo.GetSupplierPriceTrendParams{
+		SupplierName: name,
+		CreatedFrom:  from,
+		CreatedTo:    to,
+	})
+	if err != nil {
+		http.Error(w, "failed to get supplier price trend: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SupplierDetail{
+		SupplierPerformance: perf[0],
+		PriceTrend:          priceTrends(rows),
+	})
+}
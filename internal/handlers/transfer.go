@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// stock transfer statuses
+const (
+	transferStatusInTransit = "in_transit"
+	transferStatusReceived  = "received"
+	transferStatusCancelled = "cancelled"
+)
+
+// errInvalidTransfer is returned when a transfer line cannot be moved as given
+var errInvalidTransfer = errors.New("invalid transfer")
+
+// CreateTransferRequest is the body of POST /transfers
+type CreateTransferRequest struct {
+	SourceWarehouseID      int32                 `json:"source_warehouse_id"`
+	DestinationWarehouseID int32                 `json:"destination_warehouse_id"`
+	Note                   string                `json:"note,omitempty"`
+	Items                  []TransferItemRequest `json:"items"`
+}
+
+// TransferItemRequest is one product line of a transfer; lot-tracked stock names its lot and
+// serial-tracked stock lists the units that move
+type TransferItemRequest struct {
+	IdFromProduct int32    `json:"id_from_product"`
+	Quantity      int32    `json:"quantity"`
+	LotNumber     string   `json:"lot_number,omitempty"`
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+}
+
+// TransferResponse is a transfer document with its lines
+type TransferResponse struct {
+	repo.StockTransfer
+	Items []repo.ListStockTransferItemsRow `json:"items"`
+}
+
+// ListTransfers handles GET /transfers (?status=in_transit|received|cancelled)
+func (s *Server) ListTransfers(w http.ResponseWriter, r *http.Request) {
+	status := strings.ToLower(r.URL.Query().Get("status"))
+	transfers, err := s.Repo.ListStockTransfers(r.Context(), repo.ListStockTransfersParams{
+		Status:    pgtype.Text{String: status, Valid: status != ""},
+		RowLimit:  int32(queryInt(r, "limit", 50, 1, 200)),
+		RowOffset: int32(queryInt(r, "offset", 0, 0, 1<<30)),
+	})
+	if err != nil {
+		http.Error(w, "failed to list transfers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if transfers == nil {
+		transfers = []repo.StockTransfer{}
+	}
+	writeJSON(w, http.StatusOK, transfers)
+}
+
+// GetTransfer handles GET /transfers/{id}
+func (s *Server) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := s.Repo.GetStockTransferByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "transfer not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch transfer: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	items, err := s.Repo.ListStockTransferItems(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to fetch transfer items: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, TransferResponse{transfer, items})
+}
+
+// CreateTransfer handles POST /transfers. The stock leaves the source location right away and
+// stays in transit (still counted in products.stock) until the transfer is received. Units held
+// by open orders cannot be moved; serial-tracked units travel with their serial numbers.
+func (s *Server) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var req CreateTransferRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.SourceWarehouseID == req.DestinationWarehouseID {
+		http.Error(w, "source and destination must differ", http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) == 0 {
+		http.Error(w, "items must not be empty", http.StatusBadRequest)
+		return
+	}
+	ids := make([]int32, 0, len(req.Items))
+	for _, it := range req.Items {
+		if it.Quantity <= 0 {
+			http.Error(w, "item quantity must be positive", http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, it.IdFromProduct)
+	}
+	products, err := s.Repo.GetProductsByIDs(r.Context(), ids)
+	if err != nil {
+		http.Error(w, "failed to fetch products: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(products) != len(uniqueIDs(ids)) {
+		http.Error(w, "one or more products not found", http.StatusBadRequest)
+		return
+	}
+	byID := make(map[int32]repo.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	serials := make([][]string, len(req.Items))
+	for i, it := range req.Items {
+		if serials[i], err = cleanSerials(it.SerialNumbers); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := checkSerialCount(byID[it.IdFromProduct], serials[i], it.Quantity); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, id := range []int32{req.SourceWarehouseID, req.DestinationWarehouseID} {
+		if _, err := s.Repo.GetWarehouseByID(r.Context(), id); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, fmt.Sprintf("warehouse %d not found", id), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "failed to fetch warehouse: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	note := strings.TrimSpace(req.Note)
+	var resp TransferResponse
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		var err error
+		resp.StockTransfer, err = q.CreateStockTransfer(r.Context(), repo.CreateStockTransferParams{
+			IDFromSource:      req.SourceWarehouseID,
+			IDFromDestination: req.DestinationWarehouseID,
+			Note:              pgtype.Text{String: note, Valid: note != ""},
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, it := range req.Items {
+			if err := requireUnreserved(r.Context(), q, it.IdFromProduct, req.SourceWarehouseID, it.Quantity); err != nil {
+				return fmt.Errorf("product %d: %w", it.IdFromProduct, err)
+			}
+			if err := adjustWarehouseStock(r.Context(), q, req.SourceWarehouseID, it.IdFromProduct, -it.Quantity); err != nil {
+				return fmt.Errorf("product %d: %w", it.IdFromProduct, err)
+			}
+
+			// the lot leaves the source with its expiry date, which travels on the line; a
+			// lot-tracked product must name it so the same lot arrives at the destination
+			lotNumber := strings.TrimSpace(it.LotNumber)
+			var expiry pgtype.Date
+			if lotNumber != "" {
+				lot, err := q.UpsertStockLot(r.Context(), repo.UpsertStockLotParams{
+					IDFromProduct:   it.IdFromProduct,
+					IDFromWarehouse: req.SourceWarehouseID,
+					LotNumber:       lotNumber,
+					Quantity:        -it.Quantity,
+				})
+				if err != nil {
+					if isCheckViolation(err) {
+						return fmt.Errorf("product %d lot %s: %w", it.IdFromProduct, lotNumber, errInsufficientStock)
+					}
+					return err
+				}
+				expiry = lot.ExpiryDate
+			} else {
+				lots, err := q.ListOpenLots(r.Context(), repo.ListOpenLotsParams{
+					IDFromProduct:   it.IdFromProduct,
+					IDFromWarehouse: req.SourceWarehouseID,
+					IncludeExpired:  true,
+				})
+				if err != nil {
+					return err
+				}
+				if len(lots) > 0 {
+					return fmt.Errorf("%w: product %d is lot-tracked, lot_number is required", errInvalidTransfer, it.IdFromProduct)
+				}
+			}
+
+			if err := recordTransferMovement(r.Context(), q, resp.StockTransfer, it.IdFromProduct, -it.Quantity, req.SourceWarehouseID); err != nil {
+				return err
+			}
+			if err := transferSerials(r.Context(), q, it.IdFromProduct, serials[i], true, req.SourceWarehouseID, transferReference(resp.StockTransfer)); err != nil {
+				return err
+			}
+
+			if _, err := q.CreateStockTransferItem(r.Context(), repo.CreateStockTransferItemParams{
+				IDFromTransfer: resp.ID,
+				IDFromProduct:  it.IdFromProduct,
+				Quantity:       it.Quantity,
+				LotNumber:      pgtype.Text{String: lotNumber, Valid: lotNumber != ""},
+				ExpiryDate:     expiry,
+				SerialNumbers:  serials[i],
+			}); err != nil {
+				return err
+			}
+		}
+
+		resp.Items, err = q.ListStockTransferItems(r.Context(), resp.ID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidTransfer):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, errSerialUnavailable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, errInsufficientStock):
+			http.Error(w, "insufficient stock at source location: "+err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "failed to create transfer: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ReceiveTransfer handles POST /transfers/{id}/receive: books the stock into the destination
+func (s *Server) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	s.completeTransfer(w, r, transferStatusReceived)
+}
+
+// CancelTransfer handles POST /transfers/{id}/cancel: returns the stock to the source
+func (s *Server) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	s.completeTransfer(w, r, transferStatusCancelled)
+}
+
+// completeTransfer closes an in-transit transfer, moving its stock to the destination
+// (received) or back to the source (cancelled)
+func (s *Server) completeTransfer(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp TransferResponse
+	errNotInTransit := errors.New("transfer is not in transit")
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		transfer, err := q.GetStockTransferForUpdate(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != transferStatusInTransit {
+			return errNotInTransit
+		}
+
+		target := transfer.IDFromDestination
+		if status == transferStatusCancelled {
+			target = transfer.IDFromSource
+		}
+		items, err := q.ListStockTransferItems(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		for _, it := range items {
+			if err := adjustWarehouseStock(r.Context(), q, target, it.IDFromProduct, it.Quantity); err != nil {
+				return err
+			}
+			if err := recordTransferMovement(r.Context(), q, transfer, it.IDFromProduct, it.Quantity, target); err != nil {
+				return err
+			}
+			if err := transferSerials(r.Context(), q, it.IDFromProduct, it.SerialNumbers, false, target, transferReference(transfer)); err != nil {
+				return err
+			}
+			if it.LotNumber.Valid {
+				if _, err := q.UpsertStockLot(r.Context(), repo.UpsertStockLotParams{
+					IDFromProduct:   it.IDFromProduct,
+					IDFromWarehouse: target,
+					LotNumber:       it.LotNumber.String,
+					ExpiryDate:      it.ExpiryDate,
+					Quantity:        it.Quantity,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		resp.Items = items
+		resp.StockTransfer, err = q.CompleteStockTransfer(r.Context(), repo.CompleteStockTransferParams{
+			ID:     id,
+			Status: status,
+		})
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "transfer not found", http.StatusNotFound)
+		case errors.Is(err, errNotInTransit), errors.Is(err, errSerialUnavailable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "failed to update transfer: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// recordTransferMovement writes one leg of a transfer to the stock ledger: out of the source when
+// it is sent, into the destination (or back into the source) when it is closed. products.stock
+// does not change, so stock_after is the current total.
+func recordTransferMovement(ctx context.Context, q *repo.Queries, transfer repo.StockTransfer, productID, delta, warehouseID int32) error {
+	product, err := q.GetProductByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	reason := movementReasonTransferIn
+	if delta < 0 {
+		reason = movementReasonTransferOut
+	}
+	_, err = q.CreateStockMovement(ctx, repo.CreateStockMovementParams{
+		IDFromProduct: productID,
+		Quantity:      delta,
+		StockAfter:    product.Stock,
+		Reason:        reason,
+		Reference:     pgtype.Text{String: fmt.Sprintf("%s, warehouse %d", transferReference(transfer), warehouseID), Valid: true},
+	})
+	return err
+}
+
+// transferReference names a transfer in the ledgers
+func transferReference(transfer repo.StockTransfer) string {
+	return fmt.Sprintf("transfer %d", transfer.ID)
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// defaultBaseUnit is the stock unit of products that do not name one
+const defaultBaseUnit = "pcs"
+
+// errUnknownUnit is returned when a quantity names a unit the product does not define
+var errUnknownUnit = errors.New("unknown unit")
+
+// UnitQuantity shows a base-unit quantity in a pack unit: 53 pcs = 2 box (of 24) + 5 pcs
+type UnitQuantity struct {
+	Unit      string `json:"unit"`
+	Factor    int32  `json:"factor"`
+	Quantity  int32  `json:"quantity"`  // whole packs
+	Remainder int32  `json:"remainder"` // base units left over
+}
+
+// CreateProductUnitRequest is the body of POST /products/{id}/units
+type CreateProductUnitRequest struct {
+	UnitName string `json:"unit_name"`
+	Factor   int32  `json:"factor"` // base units in one unit_name
+}
+
+// ProductUnitsResponse lists a product's base unit and pack units with its stock in each
+type ProductUnitsResponse struct {
+	BaseUnit   string             `json:"base_unit"`
+	Stock      int32              `json:"stock"`
+	Units      []repo.ProductUnit `json:"units"`
+	StockUnits []UnitQuantity     `json:"stock_units"`
+}
+
+// toBaseQuantity converts qty given in unit to the product's base unit and returns the factor
+// used. An empty unit or the base unit itself means qty is already in base units.
+func toBaseQuantity(ctx context.Context, q repo.Querier, product repo.Product, unit string, qty int32) (int32, int32, error) {
+	unit = strings.TrimSpace(unit)
+	if unit == "" || strings.EqualFold(unit, product.BaseUnit) {
+		return qty, 1, nil
+	}
+	u, err := q.GetProductUnitByName(ctx, repo.GetProductUnitByNameParams{
+		IDFromProduct: product.ID,
+		UnitName:      unit,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, fmt.Errorf("%w %q for product %s", errUnknownUnit, unit, product.ProductID)
+		}
+		return 0, 0, err
+	}
+	base := int64(qty) * int64(u.Factor)
+	if base > math.MaxInt32 || base < math.MinInt32 {
+		return 0, 0, fmt.Errorf("quantity %d %s out of range", qty, unit)
+	}
+	return int32(base), u.Factor, nil
+}
+
+// stockInUnits expresses a base-unit quantity in each pack unit
+func stockInUnits(stock int32, units []repo.ProductUnit) []UnitQuantity {
+	out := make([]UnitQuantity, 0, len(units))
+	for _, u := range units {
+		out = append(out, UnitQuantity{
+			Unit:      u.UnitName,
+			Factor:    u.Factor,
+			Quantity:  stock / u.Factor,
+			Remainder: stock % u.Factor,
+		})
+	}
+	return out
+}
+
+// productStockUnits loads the pack units of one product and expresses its stock in them
+func productStockUnits(ctx context.Context, q repo.Querier, product repo.Product) ([]UnitQuantity, error) {
+	units, err := q.ListUnitsByProductIDs(ctx, []int32{product.ID})
+	if err != nil {
+		return nil, err
+	}
+	return stockInUnits(product.Stock, units), nil
+}
+
+// ListProductUnits handles GET /products/{id}/units
+func (s *Server) ListProductUnits(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+
+	units, err := s.Repo.ListUnitsByProductIDs(r.Context(), []int32{id})
+	if err != nil {
+		http.Error(w, "failed to list units: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if units == nil {
+		units = []repo.ProductUnit{}
+	}
+	writeJSON(w, http.StatusOK, ProductUnitsResponse{
+		BaseUnit:   product.BaseUnit,
+		Stock:      product.Stock,
+		Units:      units,
+		StockUnits: stockInUnits(product.Stock, units),
+	})
+}
+
+// CreateProductUnit handles POST /products/{id}/units { "unit_name": "box", "factor": 24 }
+func (s *Server) CreateProductUnit(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req CreateProductUnitRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.UnitName = strings.TrimSpace(req.UnitName)
+	if req.UnitName == "" {
+		http.Error(w, "unit_name is required", http.StatusBadRequest)
+		return
+	}
+	if req.Factor <= 1 {
+		http.Error(w, "factor must be greater than 1", http.StatusBadRequest)
+		return
+	}
+
+	product, ok := s.productOr404(w, r, id)
+	if !ok {
+		return
+	}
+	if strings.EqualFold(req.UnitName, product.BaseUnit) {
+		http.Error(w, "unit_name is the product's base unit", http.StatusBadRequest)
+		return
+	}
+
+	unit, err := s.Repo.CreateProductUnit(r.Context(), repo.CreateProductUnitParams{
+		IDFromProduct: id,
+		UnitName:      req.UnitName,
+		Factor:        req.Factor,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, "unit already defined for this product", http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to create unit: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, unit)
+}
+
+// DeleteProductUnit handles DELETE /products/{id}/units/{unitId}
+func (s *Server) DeleteProductUnit(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	unitID, err := urlParamInt32(r, "unitId")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := s.Repo.DeleteProductUnit(r.Context(), repo.DeleteProductUnitParams{
+		ID:            unitID,
+		IDFromProduct: id,
+	})
+	if err != nil {
+		http.Error(w, "failed to delete unit: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "unit not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateProductBaseUnit handles PUT /products/{id}/base-unit { "base_unit": "pcs" }. Only the
+// name changes; stock and pack factors stay in the same base quantities.
+func (s *Server) UpdateProductBaseUnit(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		BaseUnit string `json:"base_unit"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.BaseUnit = strings.TrimSpace(req.BaseUnit)
+	if req.BaseUnit == "" {
+		http.Error(w, "base_unit is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := s.productOr404(w, r, id); !ok {
+		return
+	}
+	if _, err := s.Repo.GetProductUnitByName(r.Context(), repo.GetProductUnitByNameParams{
+		IDFromProduct: id,
+		UnitName:      req.BaseUnit,
+	}); err == nil {
+		http.Error(w, "base_unit is already a pack unit of this product", http.StatusConflict)
+		return
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "failed to check units: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	product, err := s.Repo.UpdateProductBaseUnit(r.Context(), repo.UpdateProductBaseUnitParams{
+		ID:       id,
+		BaseUnit: req.BaseUnit,
+	})
+	if err != nil {
+		http.Error(w, "failed to update base unit: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
@@ -16,6 +16,7 @@ import (
     
 	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
 	"github.com/nichorainer/backend-go/internal/config"
+	"github.com/nichorainer/backend-go/internal/middleware"
 	"github.com/nichorainer/backend-go/internal/models"
 )
 
@@ -41,6 +42,7 @@ type UserResponse struct {
     Username    string `json:"username"`
     Email       string `json:"email"`
     Permissions map[string]bool `json:"permissions"`
+    Token       string `json:"token,omitempty"` // login only: send as "Authorization: Bearer <token>"
 }
 
 // CreateUser creates a new user (Register)
@@ -203,6 +205,13 @@ func (s *Server) LoginUser(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    token, err := middleware.IssueToken(user.ID)
+    if err != nil {
+        w.WriteHeader(http.StatusInternalServerError)
+        json.NewEncoder(w).Encode(APIResponse{Status: "error", Message: "failed to issue token"})
+        return
+    }
+
     w.Header().Set("Content-Type", "application/json")
     json.NewEncoder(w).Encode(APIResponse{
         Status: "success",
@@ -211,6 +220,7 @@ func (s *Server) LoginUser(w http.ResponseWriter, r *http.Request) {
             Username:    user.Username,
             Email:       user.Email,
             Permissions: perms,
+            Token:       token,
         },
     })
 }
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// CreateVariantRequest is the expected JSON body for creating a product variant (SKU)
+type CreateVariantRequest struct {
+	ProductID   string            `json:"product_id"`          // kode SKU varian, mis. FPS001-L-RED
+	VariantName string            `json:"variant_name"`        // mis. "L / Red"
+	PriceIdr    *int64            `json:"price_idr,omitempty"` // kosong = ikut harga produk induk
+	Stock       int32             `json:"stock"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+}
+
+// VariantResponse is a variant with its effective price resolved against the parent product
+type VariantResponse struct {
+	ID               int32              `json:"id"`
+	IDFromProduct    int32              `json:"id_from_product"`
+	ProductID        string             `json:"product_id"`
+	VariantName      string             `json:"variant_name"`
+	PriceIdr         int64              `json:"price_idr"`
+	PriceOverrideIdr pgtype.Int8        `json:"price_override_idr"`
+	Stock            int32              `json:"stock"`
+	Attributes       map[string]string  `json:"attributes"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+}
+
+// newVariantResponse converts a variant row; parentPrice is used when the variant has no price override
+func newVariantResponse(v repo.ProductVariant, parentPrice int64) VariantResponse {
+	attrs := make(map[string]string)
+	if len(v.Attributes) > 0 {
+		if err := json.Unmarshal(v.Attributes, &attrs); err != nil {
+			attrs = make(map[string]string)
+		}
+	}
+
+	return VariantResponse{
+		ID:               v.ID,
+		IDFromProduct:    v.IDFromProduct,
+		ProductID:        v.ProductID,
+		VariantName:      v.VariantName,
+		PriceIdr:         variantPrice(v, parentPrice),
+		PriceOverrideIdr: v.PriceIdr,
+		Stock:            v.Stock,
+		Attributes:       attrs,
+		CreatedAt:        v.CreatedAt,
+		UpdatedAt:        v.UpdatedAt,
+	}
+}
+
+// variantPrice returns the variant's own price if set, otherwise the parent product price
+func variantPrice(v repo.ProductVariant, parentPrice int64) int64 {
+	if v.PriceIdr.Valid {
+		return v.PriceIdr.Int64
+	}
+	return parentPrice
+}
+
+// CreateProductVariant handles POST /products/{id}/variants. A variant's stock is part of its
+// parent's, so initial stock is booked on the parent product as well (ledger, default warehouse,
+// cost layer) in the same transaction.
+func (s *Server) CreateProductVariant(w http.ResponseWriter, r *http.Request) {
+	productID, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req CreateVariantRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.ProductID = strings.TrimSpace(req.ProductID)
+	req.VariantName = strings.TrimSpace(req.VariantName)
+	if req.ProductID == "" || req.VariantName == "" {
+		http.Error(w, "product_id and variant_name are required", http.StatusBadRequest)
+		return
+	}
+	if req.Stock < 0 {
+		http.Error(w, "stock must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	parent, err := s.Repo.GetProductByID(r.Context(), productID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch product: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if parent.TrackSerials && req.Stock > 0 {
+		http.Error(w, fmt.Sprintf("%s: %s, receive the stock with its serial numbers", errSerialTracked, parent.ProductID), http.StatusConflict)
+		return
+	}
+
+	if req.Attributes == nil {
+		req.Attributes = map[string]string{}
+	}
+	attrs, err := json.Marshal(req.Attributes)
+	if err != nil {
+		http.Error(w, "failed to process attributes", http.StatusInternalServerError)
+		return
+	}
+
+	arg := repo.CreateProductVariantParams{
+		IDFromProduct: parent.ID,
+		ProductID:     req.ProductID,
+		VariantName:   req.VariantName,
+		Stock:         req.Stock,
+		Attributes:    attrs,
+	}
+	if req.PriceIdr != nil {
+		arg.PriceIdr = pgtype.Int8{Int64: *req.PriceIdr, Valid: true}
+	}
+
+	var v repo.ProductVariant
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		var err error
+		v, err = q.CreateProductVariant(r.Context(), arg)
+		if err != nil || req.Stock == 0 {
+			return err
+		}
+		_, _, err = s.applyStockChange(r.Context(), q, stockChange{
+			ProductID: parent.ID,
+			Delta:     req.Stock,
+			Reason:    movementReasonInitial,
+			Reference: v.ProductID,
+		})
+		return err
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, "variant product_id already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to create variant: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newVariantResponse(v, parent.PriceIdr))
+}
+
+// ListProductVariants handles GET /products/{id}/variants
+func (s *Server) ListProductVariants(w http.ResponseWriter, r *http.Request) {
+	productID, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	parent, err := s.Repo.GetProductByID(r.Context(), productID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch product: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	variants, err := s.Repo.ListVariantsByProduct(r.Context(), parent.ID)
+	if err != nil {
+		http.Error(w, "failed to list variants: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]VariantResponse, len(variants))
+	for i, v := range variants {
+		resp[i] = newVariantResponse(v, parent.PriceIdr)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// UpdateProductVariantStock handles PATCH /products/{id}/variants/{variantId}/stock.
+// Same body as UpdateProductStock: either a delta or an absolute stock value. The change is
+// applied to the parent product through the movement ledger and the cost layers, and to the
+// variant's own stock, so the parent always holds its variants' units.
+func (s *Server) UpdateProductVariantStock(w http.ResponseWriter, r *http.Request) {
+	productID, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	variantID, err := urlParamInt32(r, "variantId")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateStockRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Delta == nil && req.Stock == nil {
+		http.Error(w, "either 'delta' or 'stock' must be provided", http.StatusBadRequest)
+		return
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		http.Error(w, "stock must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	errVariantNotFound := errors.New("variant not found")
+	var parent repo.Product
+	var updated repo.ProductVariant
+	err = s.inTx(r.Context(), func(q *repo.Queries) error {
+		var err error
+		parent, err = q.GetProductForUpdate(r.Context(), productID)
+		if err != nil {
+			return err
+		}
+		if parent.TrackSerials {
+			return fmt.Errorf("%w: %s", errSerialTracked, parent.ProductID)
+		}
+		current, err := q.GetProductVariantForUpdate(r.Context(), variantID)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && current.IDFromProduct != parent.ID) {
+			return errVariantNotFound
+		}
+		if err != nil {
+			return err
+		}
+		updated = current
+
+		var delta int32
+		if req.Delta != nil {
+			delta, _, err = toBaseQuantity(r.Context(), q, parent, req.Unit, *req.Delta)
+		} else {
+			var stock int32
+			stock, _, err = toBaseQuantity(r.Context(), q, parent, req.Unit, *req.Stock)
+			delta = stock - current.Stock
+		}
+		if err != nil {
+			return err
+		}
+		if delta == 0 {
+			return nil
+		}
+
+		change := stockChange{
+			ProductID: parent.ID,
+			Delta:     delta,
+			Reason:    movementReasonAdjustment,
+			Reference: current.ProductID,
+		}
+		if req.WarehouseID != nil {
+			change.WarehouseID = *req.WarehouseID
+		}
+		if _, _, err := s.applyStockChange(r.Context(), q, change); err != nil {
+			return err
+		}
+		updated, err = q.UpdateProductVariantStockByDelta(r.Context(), repo.UpdateProductVariantStockByDeltaParams{
+			ID:    variantID,
+			Stock: delta,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errInsufficientStock
+		}
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "product not found", http.StatusNotFound)
+		case errors.Is(err, errVariantNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, errInsufficientStock):
+			http.Error(w, "insufficient variant stock", http.StatusBadRequest)
+		case errors.Is(err, errSerialTracked):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, errWarehouseNotFound), errors.Is(err, errUnknownUnit):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "failed to update variant stock: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newVariantResponse(updated, parent.PriceIdr))
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// CreateWarehouseRequest is the body of POST /warehouses
+type CreateWarehouseRequest struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+}
+
+// LocationStock is a product's balance at one location
+type LocationStock struct {
+	WarehouseID int32  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Quantity    int32  `json:"quantity"`
+	Reserved    int64  `json:"reserved"` // held by open orders
+}
+
+// ListWarehouses handles GET /warehouses (default location first)
+func (s *Server) ListWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := s.Repo.ListWarehouses(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list warehouses: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if warehouses == nil {
+		warehouses = []repo.Warehouse{}
+	}
+	writeJSON(w, http.StatusOK, warehouses)
+}
+
+// CreateWarehouse handles POST /warehouses
+func (s *Server) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req CreateWarehouseRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	req.Name = strings.TrimSpace(req.Name)
+	req.Address = strings.TrimSpace(req.Address)
+	if req.Code == "" || req.Name == "" {
+		http.Error(w, "code and name are required", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := s.Repo.CreateWarehouse(r.Context(), repo.CreateWarehouseParams{
+		Code:    req.Code,
+		Name:    req.Name,
+		Address: pgtype.Text{String: req.Address, Valid: req.Address != ""},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, "warehouse code already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to create warehouse: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, warehouse)
+}
+
+// ListWarehouseStock handles GET /warehouses/{id}/stock: every product with stock at the location
+func (s *Server) ListWarehouseStock(w http.ResponseWriter, r *http.Request) {
+	id, err := urlParamInt32(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := s.Repo.GetWarehouseByID(r.Context(), id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "warehouse not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to fetch warehouse: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stock, err := s.Repo.ListWarehouseStock(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to list warehouse stock: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if stock == nil {
+		stock = []repo.ListWarehouseStockRow{}
+	}
+	writeJSON(w, http.StatusOK, stock)
+}
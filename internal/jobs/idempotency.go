@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// PurgeIdempotencyKeys deletes stored idempotent responses whose replay window has passed.
+func PurgeIdempotencyKeys(ctx context.Context, db *pgxpool.Pool) error {
+	n, err := repo.New(db).DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[JOB] purged %d expired idempotency key(s)", n)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// PurgeDeletedOrders permanently removes orders that were soft-deleted more than retention ago.
+func PurgeDeletedOrders(ctx context.Context, db *pgxpool.Pool, retention time.Duration) error {
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-retention), Valid: true}
+	n, err := repo.New(db).PurgeDeletedOrders(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[JOB] purged %d deleted order(s)", n)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	repo "github.com/nichorainer/backend-go/internal/adapters/postgresql/sqlc"
+)
+
+// duePriceBatch caps how many scheduled prices one run applies
+const duePriceBatch = 500
+
+// ApplyDuePrices sets products.price_idr for every scheduled price change whose effective time
+// has passed and marks it applied. Changes are applied oldest first, so when several are due
+// for one product the latest effective one wins.
+func ApplyDuePrices(ctx context.Context, db *pgxpool.Pool) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	q := repo.New(tx)
+	due, err := q.ListDuePrices(ctx, duePriceBatch)
+	if err != nil {
+		return err
+	}
+	for _, p := range due {
+		if _, err := q.UpdateProductPrice(ctx, repo.UpdateProductPriceParams{
+			ID:       p.IDFromProduct,
+			PriceIdr: p.PriceIdr,
+		}); err != nil {
+			return err
+		}
+		if err := q.MarkPriceApplied(ctx, p.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if len(due) > 0 {
+		log.Printf("[JOB] applied %d scheduled price change(s)", len(due))
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package middleware
 import (
     "net/http"
     "log"
+    "strconv"
+    "time"
 
     "github.com/go-chi/jwtauth/v5"
 )
@@ -13,6 +15,9 @@ var tokenAuth *jwtauth.JWTAuth
 // Secret key untuk signing token (sebaiknya ambil dari ENV di production)
 var jwtSecret []byte
 
+// tokenTTL is how long a login token stays valid
+const tokenTTL = 24 * time.Hour
+
 // InitJWT initializes JWT with secret key
 func InitJWT(secret string) {
     jwtSecret = []byte(secret)
@@ -24,6 +29,34 @@ func JWTMiddleware(next http.Handler) http.Handler {
     return jwtauth.Verifier(tokenAuth)(jwtauth.Authenticator(tokenAuth)(next))
 }
 
+// JWTVerifier verifies the token a request carries without rejecting requests that have none;
+// handlers read the caller with UserID
+func JWTVerifier(next http.Handler) http.Handler {
+    return jwtauth.Verifier(tokenAuth)(next)
+}
+
+// IssueToken signs a login token for users.id, carried in the "sub" claim
+func IssueToken(userID int32) (string, error) {
+    claims := map[string]interface{}{"sub": strconv.Itoa(int(userID))}
+    jwtauth.SetIssuedNow(claims)
+    jwtauth.SetExpiryIn(claims, tokenTTL)
+    _, tokenString, err := tokenAuth.Encode(claims)
+    return tokenString, err
+}
+
+// UserID returns users.id from the request's verified token; false without a valid token
+func UserID(r *http.Request) (int32, bool) {
+    token, _, err := jwtauth.FromContext(r.Context())
+    if err != nil || token == nil {
+        return 0, false
+    }
+    id, err := strconv.ParseInt(token.Subject(), 10, 32)
+    if err != nil || id <= 0 {
+        return 0, false
+    }
+    return int32(id), true
+}
+
 // ExtractClaims extracts JWT claims from request
 func ExtractClaims(r *http.Request) (map[string]interface{}, error) {
     _, claims, err := jwtauth.FromContext(r.Context())